@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
 	"dungeons-dragon-service/internal/config"
 	"dungeons-dragon-service/internal/http/server"
 	database "dungeons-dragon-service/internal/infrastructure/db"
+	"dungeons-dragon-service/internal/infrastructure/db/migrate"
+	"dungeons-dragon-service/internal/infrastructure/logging"
+	"log"
+	"log/slog"
 )
 
 //go:generate swag init -g cmd/api/main.go -o ./docs
@@ -24,6 +29,35 @@ import (
 // @description Type "Bearer {token}" to authenticate.
 func main() {
 	config.LoadConfig()
-	db := database.NewPostgresDatabase()
+	if err := logging.Setup(config.GetConfigString("LOG_LEVEL")); err != nil {
+		log.Fatal(err)
+	}
+	db, err := database.NewPostgresDatabase()
+	if err != nil {
+		logging.Fatal("failed to connect database", "error", err)
+	}
+	if config.GetConfigBool("MIGRATE_ON_START") {
+		migrateOnStart(db)
+	}
 	server.NewEchoServer(db).Start()
 }
+
+// migrateOnStart applies the embedded migrations. Replicas starting together
+// wait for each other on the migration lock.
+func migrateOnStart(db database.Database) {
+	sqlDB, err := db.ConnectDB().DB()
+	if err != nil {
+		logging.Fatal("failed to get database handle", "error", err)
+	}
+	m, err := migrate.NewEmbedded(sqlDB)
+	if err != nil {
+		logging.Fatal("failed to load migrations", "error", err)
+	}
+	done, err := m.Up(context.Background(), 0)
+	for _, mig := range done {
+		slog.Info("applied migration", "migration", mig.String())
+	}
+	if err != nil {
+		logging.Fatal("migration failed", "error", err)
+	}
+}
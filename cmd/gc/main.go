@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/config"
+	database "dungeons-dragon-service/internal/infrastructure/db"
+	"dungeons-dragon-service/internal/infrastructure/storage"
+	"dungeons-dragon-service/internal/repositories"
+	usecase "dungeons-dragon-service/internal/usecases"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// gc reconciles image storage with the image tables. It only reports unless
+// -apply is given.
+func main() {
+	config.LoadConfig()
+	apply := flag.Bool("apply", false, "delete orphan files and image rows of deleted items; without it nothing is changed")
+	grace := flag.Duration("grace", config.GetConfigDuration("IMAGE_GC_GRACE"), "leave files and rows younger than this alone")
+	flag.Parse()
+
+	store, err := storage.NewFromConfig()
+	if err != nil {
+		log.Fatalf("failed to set up blob storage: %v", err)
+	}
+	db, err := database.NewPostgresDatabase()
+	if err != nil {
+		log.Fatalf("failed to connect database: %v", err)
+	}
+	gc := usecase.NewImageGC(repositories.NewImageRepo(db.ConnectDB()), store)
+
+	// an interrupt cancels the queries of a long run
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	report, err := gc.Run(ctx, usecase.ImageGCOptions{Apply: *apply, Grace: *grace})
+	if err != nil {
+		log.Fatalf("image gc: %v", err)
+	}
+	for _, b := range report.Orphans {
+		fmt.Printf("orphan    %s\t%d bytes\t%s\n", b.Key, b.Size, b.ModTime.Format(time.RFC3339))
+	}
+	for _, d := range report.Dangling {
+		fmt.Printf("dangling  %s %s\t%s\t%s\n", d.Owner, d.ImageID, d.Key, d.Reason)
+	}
+	fmt.Println(report.Summary())
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/config"
+	database "dungeons-dragon-service/internal/infrastructure/db"
+	"dungeons-dragon-service/internal/infrastructure/db/migrate"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+const usage = `usage: migrate <command> [n]
+
+commands:
+  up [n]     apply pending migrations, all or the next n
+  down [n]   roll back the last n applied migrations (default 1)
+  status     list migrations and when they were applied
+  redo       roll back the last migration and apply it again
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	n := 0
+	if flag.NArg() > 1 {
+		var err error
+		if n, err = strconv.Atoi(flag.Arg(1)); err != nil || n < 1 {
+			log.Fatalf("n must be a positive number, got %q", flag.Arg(1))
+		}
+	}
+
+	config.LoadConfig()
+	db, err := database.NewPostgresDatabase()
+	if err != nil {
+		log.Fatalf("failed to connect database: %v", err)
+	}
+	sqlDB, err := db.ConnectDB().DB()
+	if err != nil {
+		log.Fatalf("failed to get database handle: %v", err)
+	}
+	m, err := migrate.NewEmbedded(sqlDB)
+	if err != nil {
+		log.Fatalf("failed to load migrations: %v", err)
+	}
+	ctx := context.Background()
+
+	switch flag.Arg(0) {
+	case "up":
+		done, err := m.Up(ctx, n)
+		for _, mig := range done {
+			fmt.Println("applied", mig)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(done) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		if n == 0 {
+			n = 1
+		}
+		done, err := m.Down(ctx, n)
+		for _, mig := range done {
+			fmt.Println("rolled back", mig)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "redo":
+		mig, err := m.Redo(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if mig == nil {
+			fmt.Println("no applied migrations")
+			return
+		}
+		fmt.Println("redone", mig)
+	case "status":
+		list, err := m.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range list {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Local().Format(time.RFC3339)
+			}
+			name := s.String()
+			if s.Name == "" {
+				name = fmt.Sprintf("%04d (no file)", s.Version)
+			}
+			if s.Changed {
+				state += ", file changed since"
+			}
+			fmt.Printf("%-40s %s\n", name, state)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"dungeons-dragon-service/internal/config"
+	database "dungeons-dragon-service/internal/infrastructure/db"
+	"dungeons-dragon-service/internal/infrastructure/db/seed"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+)
+
+// seed inserts the fixtures of a profile. Running it again only adds what is
+// missing.
+func main() {
+	profile := flag.String("profile", "prod", "fixture profile: prod (reference data only), dev or test (demo accounts and content)")
+	dir := flag.String("dir", "", "load fixtures from this directory instead of the embedded ones; it needs a profiles.yaml")
+	flag.Parse()
+
+	var fsys fs.FS = seed.Embedded()
+	if *dir != "" {
+		fsys = os.DirFS(*dir)
+	}
+	p, err := seed.LoadProfile(fsys, *profile)
+	if err != nil {
+		log.Fatalf("failed to load fixtures: %v", err)
+	}
+
+	config.LoadConfig()
+	db, err := database.NewPostgresDatabase()
+	if err != nil {
+		log.Fatalf("failed to connect database: %v", err)
+	}
+	res, err := seed.Run(db.ConnectDB(), p, os.Getenv)
+	if err != nil {
+		log.Fatalf("seeding failed: %v", err)
+	}
+
+	kinds := map[string]bool{}
+	for k := range res.Created {
+		kinds[k] = true
+	}
+	for k := range res.Existing {
+		kinds[k] = true
+	}
+	names := make([]string, 0, len(kinds))
+	for k := range kinds {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		fmt.Printf("%-14s %d created, %d already present\n", k, res.Created[k], res.Existing[k])
+	}
+	fmt.Printf("seeded profile %s\n", p.Name)
+}
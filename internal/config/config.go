@@ -2,6 +2,7 @@ package config
 
 import (
 	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -16,6 +17,7 @@ func LoadConfig() {
 	once.Do(func() {
 		vipe.SetConfigFile(".env")
 		vipe.AutomaticEnv()
+		setDefaults()
 		vipe.ReadInConfig()
 	})
 }
@@ -31,3 +33,55 @@ func GetConfigInt(key string) int {
 func GetConfigInt64(key string) int64 {
 	return vipe.GetInt64(key)
 }
+
+func GetConfigBool(key string) bool {
+	return vipe.GetBool(key)
+}
+
+func GetConfigDuration(key string) time.Duration {
+	return vipe.GetDuration(key)
+}
+
+func setDefaults() {
+	vipe.SetDefault("ACCESS_TOKEN_TTL", "15m")
+	vipe.SetDefault("REFRESH_TOKEN_TTL", "720h")
+	vipe.SetDefault("JWT_SIGNING_ALG", "HS256")
+	vipe.SetDefault("JWT_ISSUER", "dungeons-dragon-service")
+	vipe.SetDefault("JWT_AUDIENCE", "dungeons-dragon-api")
+	vipe.SetDefault("JWT_LEEWAY", "30s")
+	vipe.SetDefault("RATE_LIMIT_AUTH", "20/1m")
+	vipe.SetDefault("RATE_LIMIT_WRITE", "60/1m")
+	vipe.SetDefault("RATE_LIMIT_UPLOAD", "10/1m")
+	vipe.SetDefault("LOGIN_MAX_FAILURES", 5)
+	vipe.SetDefault("LOGIN_FAILURE_WINDOW", "1h")
+	vipe.SetDefault("LOGIN_LOCKOUT", "1m")
+	vipe.SetDefault("LOGIN_LOCKOUT_MAX", "1h")
+	vipe.SetDefault("MAILER", "log")
+	vipe.SetDefault("SMTP_PORT", 587)
+	vipe.SetDefault("EMAIL_VERIFICATION_URL", "http://localhost:8080/api/v1/auth/verify-email")
+	vipe.SetDefault("PASSWORD_RESET_URL", "http://localhost:8080/reset-password")
+	vipe.SetDefault("EMAIL_VERIFICATION_TTL", "24h")
+	vipe.SetDefault("PASSWORD_RESET_TTL", "1h")
+	vipe.SetDefault("REQUIRE_VERIFIED_EMAIL", false)
+	vipe.SetDefault("STORAGE_DRIVER", "fs")
+	vipe.SetDefault("S3_REGION", "us-east-1")
+	vipe.SetDefault("MAX_FILE_SIZE", 10<<20)
+	vipe.SetDefault("MAX_UPLOAD_SIZE", 50<<20)
+	vipe.SetDefault("MAX_IMAGE_DIMENSION", 10000)
+	vipe.SetDefault("MAX_IMAGE_PIXELS", 40000000)
+	vipe.SetDefault("IMAGE_WORKERS", 2)
+	vipe.SetDefault("IMAGE_URL_TTL", "1h")
+	vipe.SetDefault("IMAGE_GC_GRACE", "24h")
+	vipe.SetDefault("MIGRATE_ON_START", false)
+	vipe.SetDefault("DB_MAX_OPEN_CONNS", 25)
+	vipe.SetDefault("DB_MAX_IDLE_CONNS", 10)
+	vipe.SetDefault("DB_CONN_MAX_LIFETIME", "30m")
+	vipe.SetDefault("DB_CONN_MAX_IDLE_TIME", "5m")
+	vipe.SetDefault("DB_CONNECT_TIMEOUT", "5s")
+	vipe.SetDefault("DB_CONNECT_RETRY_WINDOW", "60s")
+	vipe.SetDefault("DB_STATEMENT_TIMEOUT", "30s")
+	vipe.SetDefault("REQUEST_TIMEOUT", "30s")
+	vipe.SetDefault("UPLOAD_TIMEOUT", "5m")
+	vipe.SetDefault("DB_SLOW_QUERY_THRESHOLD", "200ms")
+	vipe.SetDefault("LOG_LEVEL", "info")
+}
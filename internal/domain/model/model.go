@@ -11,6 +11,8 @@ import (
 type Privacy string
 type ItemStatus string
 type Role string
+type TokenPurpose string
+type VariantStatus string
 
 const (
 	PrivacyPublic  Privacy = "public"
@@ -21,6 +23,16 @@ const (
 
 	RoleUser  Role = "user"
 	RoleAdmin Role = "admin"
+
+	// Resized variants of an image are made in the background. Skipped means
+	// the original cannot be decoded here and is served as is.
+	VariantStatusPending VariantStatus = "pending"
+	VariantStatusReady   VariantStatus = "ready"
+	VariantStatusFailed  VariantStatus = "failed"
+	VariantStatusSkipped VariantStatus = "skipped"
+
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
 )
 
 type Base struct {
@@ -33,10 +45,15 @@ type Base struct {
 // Users table
 type User struct {
 	Base
-	Username     string `gorm:"type:varchar(64);unique;not null"`
-	Email        string `gorm:"type:varchar(128);unique;not null"`
-	PasswordHash string `gorm:"type:varchar(255);not null"`
-	Role         Role   `gorm:"type:user_role;default:'user';not null"`
+	Username     string     `gorm:"type:varchar(64);not null;index:idx_users_username_active,unique,where:deleted_at IS NULL"`
+	Email        string     `gorm:"type:varchar(128);not null;index:idx_users_email_active,unique,where:deleted_at IS NULL"`
+	PasswordHash string     `gorm:"type:varchar(255);not null"`
+	Role         Role       `gorm:"type:user_role;default:'user';not null"`
+	SuspendedAt  *time.Time `gorm:"type:timestamptz"`
+	// PasswordResetRequired locks the account to changing its password,
+	// set when an admin forces a reset.
+	PasswordResetRequired bool       `gorm:"not null;default:false"`
+	EmailVerifiedAt       *time.Time `gorm:"type:timestamptz"`
 }
 
 // Characters table
@@ -92,14 +109,74 @@ type QuestLevel struct {
 	IsDeleted bool   `gorm:"not null;default:false"`
 }
 
+// ImageMeta describes an uploaded image. The type and size are what was
+// sniffed and stored, OriginalName is the client file name for display only.
+type ImageMeta struct {
+	OriginalName string `gorm:"type:varchar(255)"`
+	ContentType  string `gorm:"type:varchar(32)"`
+	Size         int64
+	Width        int
+	Height       int
+}
+
+// ImageVariant is a resized copy of an image stored under its own key.
+type ImageVariant struct {
+	Name   string `json:"name"`
+	Key    string `json:"key"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
 type CharacterImage struct {
 	Base
-	CharacterID uuid.UUID `gorm:"type:uuid;not null"`
-	Path        string    `gorm:"type:text;not null"`
+	CharacterID   uuid.UUID                         `gorm:"type:uuid;not null;index"`
+	Path          string                            `gorm:"type:text;not null;index"`
+	Position      int                               `gorm:"not null;default:0"`
+	IsCover       bool                              `gorm:"not null;default:false"`
+	VariantStatus VariantStatus                     `gorm:"type:varchar(16);not null;default:'pending';index"`
+	Variants      datatypes.JSONSlice[ImageVariant] `gorm:"type:jsonb;index:idx_character_images_variants,type:gin,expression:variants jsonb_path_ops"`
+	ImageMeta
 }
 
 type QuestImage struct {
 	Base
-	QuestID uuid.UUID `gorm:"type:uuid;not null"`
-	Path    string    `gorm:"type:text;not null"`
+	QuestID       uuid.UUID                         `gorm:"type:uuid;not null;index"`
+	Path          string                            `gorm:"type:text;not null;index"`
+	Position      int                               `gorm:"not null;default:0"`
+	IsCover       bool                              `gorm:"not null;default:false"`
+	VariantStatus VariantStatus                     `gorm:"type:varchar(16);not null;default:'pending';index"`
+	Variants      datatypes.JSONSlice[ImageVariant] `gorm:"type:jsonb;index:idx_quest_images_variants,type:gin,expression:variants jsonb_path_ops"`
+	ImageMeta
+}
+
+// RefreshTokens table. Tokens are stored hashed; every rotation stays in the
+// family of the login that started it so reuse can revoke the whole chain.
+type RefreshToken struct {
+	Base
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index"`
+	User         *User      `gorm:"foreignKey:UserID"`
+	FamilyID     uuid.UUID  `gorm:"type:uuid;not null;index"`
+	TokenHash    string     `gorm:"type:varchar(64);unique;not null"`
+	ExpiresAt    time.Time  `gorm:"type:timestamptz;not null"`
+	RevokedAt    *time.Time `gorm:"type:timestamptz"`
+	ReplacedByID *uuid.UUID `gorm:"type:uuid"`
+}
+
+// RevokedTokens table, the denylist of access token IDs (jti) until they expire
+type RevokedToken struct {
+	JTI       string    `gorm:"type:varchar(64);primaryKey"`
+	ExpiresAt time.Time `gorm:"type:timestamptz;not null;index"`
+	CreatedAt time.Time `gorm:"type:timestamptz;not null;autoCreateTime"`
+}
+
+// UserTokens table, single-use tokens mailed to users for email verification
+// and password reset. Only the hash of the token is stored.
+type UserToken struct {
+	Base
+	UserID    uuid.UUID    `gorm:"type:uuid;not null;index"`
+	User      *User        `gorm:"foreignKey:UserID"`
+	Purpose   TokenPurpose `gorm:"type:varchar(32);not null"`
+	TokenHash string       `gorm:"type:varchar(64);unique;not null"`
+	ExpiresAt time.Time    `gorm:"type:timestamptz;not null"`
+	UsedAt    *time.Time   `gorm:"type:timestamptz"`
 }
@@ -0,0 +1,11 @@
+package model
+
+// Session is the current state of the user behind an access token. It is
+// loaded on every request so role changes and suspensions apply to tokens
+// that were issued before them.
+type Session struct {
+	UserID                string
+	Role                  Role
+	PasswordResetRequired bool
+	EmailVerified         bool
+}
@@ -0,0 +1,42 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrBlobNotFound        = errors.New("blob not found")
+	ErrPresignNotSupported = errors.New("presigned URLs are not supported by this store")
+)
+
+type BlobInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+	ETag        string
+}
+
+// BlobStore keeps uploaded files. Keys are slash separated relative paths.
+// Adapters live in infrastructure/storage. Cancelling ctx aborts a call
+// that is still waiting on the backend.
+type BlobStore interface {
+	// Put stores r under key, replacing an existing blob. size may be -1
+	// when unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens a blob. The reader also implements io.Seeker when the
+	// adapter supports it. Missing blobs return ErrBlobNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, *BlobInfo, error)
+	Stat(ctx context.Context, key string) (*BlobInfo, error)
+	// Delete removes a blob; deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+	// PresignGet returns a URL that allows reading the blob without
+	// credentials until it expires, or ErrPresignNotSupported.
+	PresignGet(ctx context.Context, key string, expires time.Duration) (string, error)
+	// List calls fn for every stored blob in key order. An error returned
+	// by fn stops the listing and is returned.
+	List(ctx context.Context, fn func(BlobInfo) error) error
+}
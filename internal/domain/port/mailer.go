@@ -0,0 +1,15 @@
+package port
+
+import "context"
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends outbound email. Adapters live in infrastructure/mailer.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
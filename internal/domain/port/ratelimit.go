@@ -0,0 +1,15 @@
+package port
+
+import "time"
+
+// CounterStore keeps counters that expire after a fixed window. It mirrors
+// Redis INCR + PEXPIRE + PTTL so a Redis adapter can replace the in-memory
+// one for multi-instance deployments.
+type CounterStore interface {
+	// Incr adds one to key and returns the new count and the time left
+	// until the counter resets. The window starts with the first Incr.
+	Incr(key string, window time.Duration) (int64, time.Duration, error)
+	// Get returns the count and remaining time; zero if the key is unset.
+	Get(key string) (int64, time.Duration, error)
+	Reset(key string) error
+}
@@ -0,0 +1,55 @@
+package repository
+
+import "dungeons-dragon-service/internal/domain/model"
+
+// PageRequest describes which slice of a listing to load. When Cursor is set
+// keyset pagination is used and Offset is ignored.
+type PageRequest struct {
+	Limit  int
+	Offset int
+	Cursor *Cursor
+	SortBy string
+	Desc   bool
+}
+
+// Cursor points at the last row of the previous page. Backward walks the
+// listing in the opposite direction of the requested sort.
+type Cursor struct {
+	Value    string
+	ID       string
+	Backward bool
+}
+
+// Visibility limits a listing to the items a viewer may see. Unless All is
+// set only items with one of the Privacy and one of the Status values, and
+// the items owned by OwnerID, are returned.
+type Visibility struct {
+	All     bool
+	Privacy []model.Privacy
+	Status  []model.ItemStatus
+	OwnerID string
+}
+
+type CharacterFilter struct {
+	ClassID    string
+	RaceID     string
+	UserID     string
+	Privacy    model.Privacy
+	Status     model.ItemStatus
+	Visibility Visibility
+}
+
+type QuestFilter struct {
+	QuestLevelID string
+	UserID       string
+	Privacy      model.Privacy
+	Status       model.ItemStatus
+	Visibility   Visibility
+}
+
+// UserFilter narrows the admin user listing. Query matches username or email.
+type UserFilter struct {
+	Query     string
+	Role      model.Role
+	Suspended *bool
+}
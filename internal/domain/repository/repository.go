@@ -1,66 +1,171 @@
 package repository
 
-import "dungeons-dragon-service/internal/domain/model"
+import (
+	"context"
+	"dungeons-dragon-service/internal/domain/model"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type UserRepository interface {
-	Create(*model.User) (*model.User, error)
-	FindByEmail(email string) (*model.User, error)
-	FindByUsername(username string) (*model.User, error)
-	FindByID(id string) (*model.User, error)
+	Create(ctx context.Context, m *model.User) (*model.User, error)
+	FindByEmail(ctx context.Context, email string) (*model.User, error)
+	FindByUsername(ctx context.Context, username string) (*model.User, error)
+	FindByID(ctx context.Context, id string) (*model.User, error)
+	Update(ctx context.Context, m *model.User) (*model.User, error)
+	Delete(ctx context.Context, id string) error
+	Search(ctx context.Context, filter UserFilter, page PageRequest) ([]model.User, int64, error)
 }
 
 type ClassRepository interface {
-	Create(*model.Class) (*model.Class, error)
-	Update(*model.Class) (*model.Class, error)
-	Delete(id string) error
-	FindByID(id string) (*model.Class, error)
-	List() ([]model.Class, error)
+	Create(ctx context.Context, m *model.Class) (*model.Class, error)
+	Update(ctx context.Context, m *model.Class) (*model.Class, error)
+	Delete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (*model.Class, error)
+	List(ctx context.Context) ([]model.Class, error)
 }
 
 type RaceRepository interface {
-	Create(*model.Race) (*model.Race, error)
-	Update(*model.Race) (*model.Race, error)
-	Delete(id string) error
-	FindByID(id string) (*model.Race, error)
-	List() ([]model.Race, error)
+	Create(ctx context.Context, m *model.Race) (*model.Race, error)
+	Update(ctx context.Context, m *model.Race) (*model.Race, error)
+	Delete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (*model.Race, error)
+	List(ctx context.Context) ([]model.Race, error)
 }
 
 type QuestLevelRepository interface {
-	Create(*model.QuestLevel) (*model.QuestLevel, error)
-	Update(*model.QuestLevel) (*model.QuestLevel, error)
-	Delete(id string) error
-	FindByID(id string) (*model.QuestLevel, error)
-	List() ([]model.QuestLevel, error)
+	Create(ctx context.Context, m *model.QuestLevel) (*model.QuestLevel, error)
+	Update(ctx context.Context, m *model.QuestLevel) (*model.QuestLevel, error)
+	Delete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (*model.QuestLevel, error)
+	List(ctx context.Context) ([]model.QuestLevel, error)
 }
 
 type CharacterRepository interface {
-	Create(*model.Character) (*model.Character, error)
-	Update(*model.Character) (*model.Character, error)
-	Delete(id string) error
-	FindByID(id string) (*model.Character, error)
-	ListAll() ([]model.Character, error)
-	ListPublic() ([]model.Character, error)
-	ListByUser(userID string) ([]model.Character, error)
-	ArchiveByClassID(classID string) error
-	ArchiveByRaceID(raceID string) error
+	Create(ctx context.Context, m *model.Character) (*model.Character, error)
+	Update(ctx context.Context, m *model.Character) (*model.Character, error)
+	Delete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (*model.Character, error)
+	ListAll(ctx context.Context) ([]model.Character, error)
+	ListPublic(ctx context.Context) ([]model.Character, error)
+	ListByUser(ctx context.Context, userID string) ([]model.Character, error)
+	Search(ctx context.Context, filter CharacterFilter, page PageRequest) ([]model.Character, int64, error)
+	ArchiveByClassID(ctx context.Context, classID string) error
+	ArchiveByRaceID(ctx context.Context, raceID string) error
+	ArchiveByUserID(ctx context.Context, userID string) error
 }
 
 type QuestRepository interface {
-	Create(*model.Quest) (*model.Quest, error)
-	Update(*model.Quest) (*model.Quest, error)
-	Delete(id string) error
-	FindByID(id string) (*model.Quest, error)
-	ListAll() ([]model.Quest, error)
-	ListPublic() ([]model.Quest, error)
-	ListByUser(userID string) ([]model.Quest, error)
-	ArchiveByQuestLevelID(questsLevelID string) error
+	Create(ctx context.Context, m *model.Quest) (*model.Quest, error)
+	Update(ctx context.Context, m *model.Quest) (*model.Quest, error)
+	Delete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (*model.Quest, error)
+	ListAll(ctx context.Context) ([]model.Quest, error)
+	ListPublic(ctx context.Context) ([]model.Quest, error)
+	ListByUser(ctx context.Context, userID string) ([]model.Quest, error)
+	Search(ctx context.Context, filter QuestFilter, page PageRequest) ([]model.Quest, int64, error)
+	ArchiveByQuestLevelID(ctx context.Context, questsLevelID string) error
+	ArchiveByUserID(ctx context.Context, userID string) error
+}
+
+// ErrImagesChanged is returned when the stored images of a character or quest
+// changed between reading and saving them.
+var ErrImagesChanged = errors.New("images were changed concurrently")
+
+// ImageOwnerInfo is what serving an image needs to know about the character
+// or quest it belongs to.
+type ImageOwnerInfo struct {
+	UserID  uuid.UUID
+	Privacy model.Privacy
+	Status  model.ItemStatus
+}
+
+// ImageRecord is an image row as seen when reconciling storage. Owner is
+// "character" or "quest"; OwnerDeleted is set once that item was deleted.
+type ImageRecord struct {
+	ID           uuid.UUID
+	Owner        string
+	Path         string
+	Variants     []model.ImageVariant
+	CreatedAt    time.Time
+	OwnerDeleted bool
 }
 
 type ImageRepository interface {
-	GetCharacterImageByID(characterID string) ([]model.CharacterImage, error)
-	GetQuestImageByID(questID string) ([]model.QuestImage, error)
-	DeleteCharacterImageByID(characterID string) error
-	CreateCharacterImage(img *model.CharacterImage) (*model.CharacterImage, error)
-	DeleteQuestImageByID(questID string) error
-	CreateQuestImage(img *model.QuestImage) (*model.QuestImage, error)
+	// FindOwnerByKey looks up the owner of an original or variant blob key.
+	// It returns nil if no live image row references the key.
+	FindOwnerByKey(ctx context.Context, key string) (*ImageOwnerInfo, error)
+	// GetCharacterImageByID returns the images of a character by position.
+	GetCharacterImageByID(ctx context.Context, characterID string) ([]model.CharacterImage, error)
+	GetQuestImageByID(ctx context.Context, questID string) ([]model.QuestImage, error)
+	// SaveCharacterImages makes imgs the complete, ordered image list of a
+	// character in one transaction. Rows without an ID are inserted, rows
+	// missing from imgs are deleted and returned so their files can be
+	// removed after the commit. expected holds the image ids the caller read;
+	// if the stored set differs, ErrImagesChanged is returned.
+	SaveCharacterImages(ctx context.Context, characterID string, expected []string, imgs []model.CharacterImage) ([]model.CharacterImage, error)
+	SaveQuestImages(ctx context.Context, questID string, expected []string, imgs []model.QuestImage) ([]model.QuestImage, error)
+	// SetCharacterImageVariants records the variants made for an image. It
+	// reports false if the image no longer exists.
+	SetCharacterImageVariants(ctx context.Context, imageID string, status model.VariantStatus, variants []model.ImageVariant) (bool, error)
+	SetQuestImageVariants(ctx context.Context, imageID string, status model.VariantStatus, variants []model.ImageVariant) (bool, error)
+	// PendingCharacterImages returns up to limit images still waiting for
+	// their variants, oldest first.
+	PendingCharacterImages(ctx context.Context, limit int) ([]model.CharacterImage, error)
+	PendingQuestImages(ctx context.Context, limit int) ([]model.QuestImage, error)
+	// ImageRecords returns every live character and quest image row.
+	ImageRecords(ctx context.Context) ([]ImageRecord, error)
+	// DeleteImageRecords deletes the given rows.
+	DeleteImageRecords(ctx context.Context, records []ImageRecord) error
+}
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, m *model.RefreshToken) (*model.RefreshToken, error)
+	FindByHash(ctx context.Context, hash string) (*model.RefreshToken, error)
+	// Rotate revokes current and stores next in one transaction. It reports
+	// false when current had already been revoked by a concurrent request.
+	Rotate(ctx context.Context, current *model.RefreshToken, next *model.RefreshToken) (bool, error)
+	RevokeByHash(ctx context.Context, userID string, hash string) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID string) error
+}
+
+type RevokedTokenRepository interface {
+	Add(ctx context.Context, jti string, expiresAt time.Time) error
+	Exists(ctx context.Context, jti string) (bool, error)
+}
+
+type UserTokenRepository interface {
+	Create(ctx context.Context, m *model.UserToken) (*model.UserToken, error)
+	FindByHash(ctx context.Context, hash string) (*model.UserToken, error)
+	// Consume marks the token used. It reports false when it was used already.
+	Consume(ctx context.Context, id string) (bool, error)
+	// InvalidateForUser marks every unused token of the purpose as used, so
+	// only the most recently mailed link works.
+	InvalidateForUser(ctx context.Context, userID string, purpose model.TokenPurpose) error
+}
+
+// Repositories is the full set of repositories. A TxManager hands out one
+// bound to its transaction.
+type Repositories struct {
+	Users         UserRepository
+	Classes       ClassRepository
+	Races         RaceRepository
+	QuestLevels   QuestLevelRepository
+	Characters    CharacterRepository
+	Quests        QuestRepository
+	Images        ImageRepository
+	RefreshTokens RefreshTokenRepository
+	RevokedTokens RevokedTokenRepository
+	UserTokens    UserTokenRepository
+}
+
+// TxManager runs several repository calls as one unit of work.
+type TxManager interface {
+	// WithinTx calls fn with repositories bound to a new transaction. It
+	// commits when fn returns nil and rolls back on an error, which is
+	// returned as is.
+	WithinTx(ctx context.Context, fn func(repos Repositories) error) error
 }
@@ -15,6 +15,29 @@ type ApiResponseWithPaginate[T any, P any] struct {
 	Length       int    `json:"length"`
 }
 
+// ProblemResponse is an RFC 7807 problem detail. It replaces the ApiResponse
+// of an error for clients that accept application/problem+json.
+type ProblemResponse struct {
+	Type     string `json:"type"               example:"about:blank"`
+	Title    string `json:"title"              example:"Unprocessable Entity"`
+	Status   int    `json:"status"             example:"422"`
+	Detail   string `json:"detail,omitempty"   example:"title must be a maximum of 200 characters in length"`
+	Instance string `json:"instance,omitempty" example:"/api/v1/characters"`
+	// Code is stable and meant for programs, unlike Detail
+	Code    string       `json:"code"               example:"validation_failed"`
+	TraceID string       `json:"trace_id"           example:"5b0e4d9c-7f0e-4a53-9d8e-2f4c1a6b8e21"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is one failed validation rule of a request.
+type FieldError struct {
+	Field   string `json:"field"           example:"title"`
+	Pointer string `json:"pointer"         example:"/title"`
+	Rule    string `json:"rule"            example:"max"`
+	Param   string `json:"param,omitempty" example:"200"`
+	Message string `json:"message"         example:"title must be a maximum of 200 characters in length"`
+}
+
 // for swagger documentation
 type APIErrorResponse struct {
 	Error   bool   `json:"error"   example:"true"`
@@ -29,3 +52,30 @@ type APIObjectResponse struct {
 	Data    any    `json:"data"`
 	Length  int    `json:"length"  example:"171"`
 }
+
+type PaginateResponse struct {
+	Page       int    `json:"page,omitempty"        example:"1"`
+	Limit      int    `json:"limit"                 example:"20"`
+	Total      int64  `json:"total"                 example:"42"`
+	TotalPages int    `json:"total_pages,omitempty" example:"3"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// ListQuery holds the paging and sorting query parameters shared by list endpoints.
+// A non-empty Cursor switches to cursor pagination and Page is ignored.
+type ListQuery struct {
+	Page   int    `query:"page"   validate:"omitempty,min=1"`
+	Limit  int    `query:"limit"  validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor"`
+	Sort   string `query:"sort"`
+}
+
+// for swagger documentation
+type APIPaginateResponse struct {
+	Error        bool             `json:"error"    example:"false"`
+	Message      string           `json:"message"  example:"Success"`
+	Data         any              `json:"data"`
+	PaginateData PaginateResponse `json:"paginate"`
+	Length       int              `json:"length"   example:"20"`
+}
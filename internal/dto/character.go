@@ -5,15 +5,17 @@ import (
 )
 
 type CharacterResponse struct {
-	ID          string        `json:"id"`
-	Title       string        `json:"title"`
-	Description string        `json:"description"`
-	UserID      string        `json:"user_id"`
-	ClassID     string        `json:"class_id"`
-	RaceID      string        `json:"race_id"`
-	Privacy     model.Privacy `json:"privacy"`
-	Status      string        `json:"status"`
-	Images      []string      `json:"images"`
+	ID          string          `json:"id"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	UserID      string          `json:"user_id"`
+	ClassID     string          `json:"class_id"`
+	Class       string          `json:"class,omitempty"`
+	RaceID      string          `json:"race_id"`
+	Race        string          `json:"race,omitempty"`
+	Privacy     model.Privacy   `json:"privacy"`
+	Status      string          `json:"status"`
+	Images      []ImageResponse `json:"images"`
 }
 
 type CreateCharacterInput struct {
@@ -46,3 +48,12 @@ type CharacterUpdateRequest struct {
 	RaceID      *string        `json:"race_id"`
 	Privacy     *model.Privacy `json:"privacy" validate:"omitempty,oneof=public private"`
 }
+
+type CharacterListQuery struct {
+	ListQuery
+	ClassID string           `query:"class_id" validate:"omitempty,uuid"`
+	RaceID  string           `query:"race_id"  validate:"omitempty,uuid"`
+	Owner   string           `query:"owner"    validate:"omitempty,uuid"`
+	Privacy model.Privacy    `query:"privacy"  validate:"omitempty,oneof=public private"`
+	Status  model.ItemStatus `query:"status"   validate:"omitempty,oneof=active archived"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+// ImageResponse describes a stored image. Status is "pending" until the
+// resized variants are ready.
+type ImageResponse struct {
+	ID       string                 `json:"id"`
+	URL      string                 `json:"url"`
+	Position int                    `json:"position"`
+	IsCover  bool                   `json:"is_cover"`
+	Width    int                    `json:"width"`
+	Height   int                    `json:"height"`
+	Status   string                 `json:"status"`
+	Variants []ImageVariantResponse `json:"variants,omitempty"`
+	SrcSet   string                 `json:"srcset,omitempty"`
+}
+
+type ImageVariantResponse struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+type ReorderImagesRequest struct {
+	ImageIDs []string `json:"image_ids" validate:"required,min=1,max=10,dive,uuid"`
+}
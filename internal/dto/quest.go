@@ -5,13 +5,15 @@ import (
 )
 
 type QuestResponse struct {
-	ID          string        `json:"id"`
-	Title       string        `json:"title"`
-	Description string        `json:"description"`
-	QuestLevel  string        `json:"quest_level"`
-	Privacy     model.Privacy `json:"privacy"`
-	Status      string        `json:"status"`
-	Images      []string      `json:"images"`
+	ID           string          `json:"id"`
+	Title        string          `json:"title"`
+	Description  string          `json:"description"`
+	UserID       string          `json:"user_id"`
+	QuestLevelID string          `json:"quest_level_id"`
+	QuestLevel   string          `json:"quest_level"`
+	Privacy      model.Privacy   `json:"privacy"`
+	Status       string          `json:"status"`
+	Images       []ImageResponse `json:"images"`
 }
 
 type CreateQuestInput struct {
@@ -40,3 +42,11 @@ type QuestUpdateRequest struct {
 	QuestLevelID *string        `json:"quest_level_id"`
 	Privacy      *model.Privacy `json:"privacy" validate:"omitempty,oneof=public private"`
 }
+
+type QuestListQuery struct {
+	ListQuery
+	QuestLevelID string           `query:"quest_level_id" validate:"omitempty,uuid"`
+	Owner        string           `query:"owner"          validate:"omitempty,uuid"`
+	Privacy      model.Privacy    `query:"privacy"        validate:"omitempty,oneof=public private"`
+	Status       model.ItemStatus `query:"status"         validate:"omitempty,oneof=active archived"`
+}
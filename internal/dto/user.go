@@ -1,13 +1,23 @@
 package dto
 
+import (
+	"dungeons-dragon-service/internal/domain/model"
+	"time"
+)
+
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int64  `json:"expires_in" example:"900"`
 }
 
 type UserProfileResponse struct {
-	ID    string `json:"id"`
-	Email string `json:"email"`
-	Role  string `json:"role"`
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
 }
 
 type LoginRequest struct {
@@ -16,7 +26,69 @@ type LoginRequest struct {
 }
 
 type RegisterRequest struct {
-	Username string `json:"username" validate:"required"`
-	Email    string `json:"email"`
+	Username string `json:"username" validate:"required,max=64"`
+	Email    string `json:"email" validate:"required,email,max=128"`
 	Password string `json:"password" validate:"required,min=6"`
 }
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// UpdateProfileRequest changes only the fields that are set.
+type UpdateProfileRequest struct {
+	Username *string `json:"username" validate:"omitempty,min=1,max=64"`
+	Email    *string `json:"email" validate:"omitempty,email,max=128"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// AdminUserResponse is the view of an account in the admin user management.
+type AdminUserResponse struct {
+	ID                    string     `json:"id"`
+	Username              string     `json:"username"`
+	Email                 string     `json:"email"`
+	Role                  string     `json:"role"`
+	SuspendedAt           *time.Time `json:"suspended_at"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+	CreatedAt             time.Time  `json:"created_at"`
+}
+
+type UserListQuery struct {
+	ListQuery
+	Q         string     `query:"q"         validate:"omitempty,max=128"`
+	Role      model.Role `query:"role"      validate:"omitempty,oneof=user admin"`
+	Suspended string     `query:"suspended" validate:"omitempty,oneof=true false"`
+}
+
+type UpdateRoleRequest struct {
+	Role model.Role `json:"role" validate:"required,oneof=user admin"`
+}
+
+type PasswordResetResponse struct {
+	TemporaryPassword string `json:"temporary_password"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" query:"token" validate:"required"`
+}
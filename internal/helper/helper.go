@@ -2,16 +2,11 @@ package helper
 
 import (
 	"crypto/rand"
-	"path/filepath"
 
-	"dungeons-dragon-service/internal/config"
 	"dungeons-dragon-service/internal/domain/model"
 	"dungeons-dragon-service/internal/http/custom"
 	"encoding/base64"
 	"fmt"
-	"io"
-	"mime/multipart"
-	"os"
 	"strings"
 
 	"github.com/google/uuid"
@@ -74,32 +69,3 @@ func ParseUUIDOrNil(s string) uuid.UUID {
 	}
 	return id
 }
-
-func SaveUploadedFile(file *multipart.FileHeader, dst string) error {
-	src, err := file.Open()
-	if err != nil {
-		return err
-	}
-	defer src.Close()
-
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	_, err = io.Copy(out, src)
-	return err
-}
-
-func DeleteFileIfExists(path string) error {
-	if _, err := os.Stat(path); err == nil {
-		return os.Remove(path)
-	}
-	return nil
-}
-
-func GetImageURL(path string) string {
-	// Assuming the server serves images from the /images/ directory
-	return config.GetConfigString("DOMAIN") + "/api/v1/pictures/" + filepath.Base(path)
-}
@@ -0,0 +1,96 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+
+	_ "golang.org/x/image/webp"
+)
+
+// ImageSniffLen is how many leading bytes DetectImageType needs.
+const ImageSniffLen = 16
+
+var ErrInvalidImage = errors.New("invalid image")
+
+// accepted upload types and the extension used for their storage keys
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// DetectImageType returns the MIME type of an image from its magic bytes.
+// Only JPEG, PNG, GIF and WebP are recognised.
+func DetectImageType(head []byte) (string, bool) {
+	switch {
+	case bytes.HasPrefix(head, []byte("\xFF\xD8\xFF")):
+		return "image/jpeg", true
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png", true
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return "image/gif", true
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return "image/webp", true
+	}
+	return "", false
+}
+
+// ImageExtension returns the file extension for an accepted image type.
+func ImageExtension(contentType string) string {
+	return imageExtensions[contentType]
+}
+
+// DecodeImageSize reads the width and height from the image header without
+// decoding the pixels.
+func DecodeImageSize(r io.Reader, contentType string) (int, int, error) {
+	if contentType == "image/webp" {
+		return decodeWebPSize(r)
+	}
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return 0, 0, ErrInvalidImage
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// decodeWebPSize parses the first chunk of a WebP file. The lossy (VP8),
+// lossless (VP8L) and extended (VP8X) headers are read by hand, so only
+// those 30 bytes are needed.
+func decodeWebPSize(r io.Reader) (int, int, error) {
+	var h [30]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return 0, 0, ErrInvalidImage
+	}
+	if string(h[:4]) != "RIFF" || string(h[8:12]) != "WEBP" {
+		return 0, 0, ErrInvalidImage
+	}
+	data := h[20:]
+	switch string(h[12:16]) {
+	case "VP8 ":
+		// 3 byte frame tag, then the 9d 01 2a start code
+		if data[3] != 0x9d || data[4] != 0x01 || data[5] != 0x2a {
+			return 0, 0, ErrInvalidImage
+		}
+		w := int(binary.LittleEndian.Uint16(data[6:8]) & 0x3fff)
+		hgt := int(binary.LittleEndian.Uint16(data[8:10]) & 0x3fff)
+		return w, hgt, nil
+	case "VP8L":
+		if data[0] != 0x2f {
+			return 0, 0, ErrInvalidImage
+		}
+		bits := binary.LittleEndian.Uint32(data[1:5])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1, nil
+	case "VP8X":
+		w := int(data[4]) | int(data[5])<<8 | int(data[6])<<16
+		hgt := int(data[7]) | int(data[8])<<8 | int(data[9])<<16
+		return w + 1, hgt + 1, nil
+	}
+	return 0, 0, ErrInvalidImage
+}
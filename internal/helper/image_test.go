@@ -0,0 +1,84 @@
+package helper
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectImageType(t *testing.T) {
+	cases := map[string]string{
+		"\xFF\xD8\xFF\xE0":                 "image/jpeg",
+		"\x89PNG\r\n\x1a\n\x00":            "image/png",
+		"GIF89a\x01\x00":                   "image/gif",
+		"RIFF\x00\x00\x00\x00WEBPVP8 ":     "image/webp",
+		"<svg xmlns='http://www.w3.org/'>": "",
+		"RIFF\x00\x00\x00\x00AVI LIST":     "",
+	}
+	for head, want := range cases {
+		got, ok := DetectImageType([]byte(head))
+		require.Equal(t, want != "", ok, head)
+		require.Equal(t, want, got, head)
+	}
+}
+
+func TestDecodeWebPSize(t *testing.T) {
+	riff := func(chunk string, data []byte) []byte {
+		b := []byte("RIFF\x00\x00\x00\x00WEBP" + chunk + "\x00\x00\x00\x00")
+		return append(b, append(data, make([]byte, 10)...)...)
+	}
+
+	// lossy: frame tag, start code, 14 bit width and height
+	lossy := riff("VP8 ", []byte{0, 0, 0, 0x9d, 0x01, 0x2a, 0x80, 0x02, 0xe0, 0x01})
+	w, h, err := DecodeImageSize(bytes.NewReader(lossy), "image/webp")
+	require.NoError(t, err)
+	require.Equal(t, []int{640, 480}, []int{w, h})
+
+	// lossless: signature, then width-1 and height-1 packed in 14 bits each
+	bits := uint32(99) | uint32(49)<<14
+	lossless := riff("VP8L", []byte{0x2f, byte(bits), byte(bits >> 8), byte(bits >> 16), byte(bits >> 24)})
+	w, h, err = DecodeImageSize(bytes.NewReader(lossless), "image/webp")
+	require.NoError(t, err)
+	require.Equal(t, []int{100, 50}, []int{w, h})
+
+	// extended: 24 bit canvas width-1 and height-1
+	extended := riff("VP8X", []byte{0, 0, 0, 0, 0x1f, 0x4e, 0x00, 0x1f, 0x4e, 0x00})
+	w, h, err = DecodeImageSize(bytes.NewReader(extended), "image/webp")
+	require.NoError(t, err)
+	require.Equal(t, []int{20000, 20000}, []int{w, h})
+
+	_, _, err = DecodeImageSize(bytes.NewReader([]byte("RIFF")), "image/webp")
+	require.ErrorIs(t, err, ErrInvalidImage)
+}
+
+func TestResizeImage(t *testing.T) {
+	require.Equal(t, []int{160, 80}, fit(2000, 1000, 160))
+	require.Equal(t, []int{50, 160}, fit(500, 1600, 160))
+	require.Equal(t, []int{100, 100}, fit(100, 100, 160))
+
+	// a 4x2 image of black and white columns averages to grey
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			v := uint8(255 * (x % 2))
+			src.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	dst := ResizeImage(src, 2, 1)
+	require.Equal(t, image.Rect(0, 0, 2, 1), dst.Bounds())
+	require.Equal(t, color.RGBA{R: 127, G: 127, B: 127, A: 255}, dst.RGBAAt(0, 0))
+
+	var buf bytes.Buffer
+	contentType, ext, err := EncodeVariant(&buf, dst)
+	require.NoError(t, err)
+	require.Equal(t, "image/jpeg", contentType)
+	require.Equal(t, ".jpg", ext)
+}
+
+func fit(w, h, limit int) []int {
+	fw, fh := FitSize(w, h, limit)
+	return []int{fw, fh}
+}
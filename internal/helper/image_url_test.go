@@ -0,0 +1,45 @@
+package helper
+
+import (
+	"dungeons-dragon-service/internal/config"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignedImageURL(t *testing.T) {
+	require.NotContains(t, GetImageURL("uploads/a.png", false), "?")
+
+	u, err := url.Parse(GetImageURL("uploads/a.png", true))
+	require.NoError(t, err)
+	key, exp, sig := path.Base(u.Path), u.Query().Get("exp"), u.Query().Get("sig")
+	require.Equal(t, "a.png", key)
+
+	now := time.Now()
+	require.True(t, VerifyImageSignature(key, exp, sig, now))
+	require.False(t, VerifyImageSignature("b.png", exp, sig, now))
+	require.False(t, VerifyImageSignature(key, exp+"0", sig, now))
+	require.False(t, VerifyImageSignature(key, exp, strings.ToUpper(sig), now))
+	require.False(t, VerifyImageSignature(key, exp, "", now))
+	require.False(t, VerifyImageSignature(key, exp, sig, now.Add(3*defaultImageURLTTL)))
+
+	// URLs are stable within a TTL window so browsers can cache them
+	require.Equal(t, imageURLExpiry(now.Truncate(defaultImageURLTTL)), imageURLExpiry(now.Truncate(defaultImageURLTTL).Add(time.Minute)))
+}
+
+func TestImageURLSecret(t *testing.T) {
+	config.LoadConfig()
+	t.Setenv("IMAGE_URL_SECRET", "one")
+	sig := signImageKey("a.png", 1)
+
+	// the JWT secret plays no part
+	t.Setenv("JWT_SECRET", "jwt")
+	require.Equal(t, sig, signImageKey("a.png", 1))
+
+	t.Setenv("IMAGE_URL_SECRET", "two")
+	require.NotEqual(t, sig, signImageKey("a.png", 1))
+}
@@ -0,0 +1,96 @@
+package helper
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// FitSize scales w x h down to fit in a limit x limit box, keeping the
+// aspect ratio. Sizes that already fit are returned unchanged.
+func FitSize(w, h, limit int) (int, int) {
+	if w <= limit && h <= limit {
+		return w, h
+	}
+	if w >= h {
+		return limit, max(1, h*limit/w)
+	}
+	return max(1, w*limit/h), limit
+}
+
+// ResizeImage downscales src to w x h by averaging the source pixels each
+// target pixel covers. It is meant for shrinking; upscaling just repeats
+// pixels. The source is read in place, without a full-size RGBA copy.
+func ResizeImage(src image.Image, w, h int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	// average premultiplied values so transparent pixels do not bleed colour
+	pixel := premultiplied(src)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := y * sh / h
+		y1 := max(y0+1, (y+1)*sh/h)
+		for x := 0; x < w; x++ {
+			x0 := x * sw / w
+			x1 := max(x0+1, (x+1)*sw/w)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := pixel(b.Min.X+sx, b.Min.Y+sy)
+					r += uint64(pr)
+					g += uint64(pg)
+					bl += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(bl / n), A: uint8(a / n)})
+		}
+	}
+	return dst
+}
+
+// premultiplied returns a reader of 8-bit premultiplied pixels. The types
+// the JPEG, PNG and WebP decoders return are read straight from their
+// buffers; anything else goes through At.
+func premultiplied(src image.Image) func(x, y int) (uint32, uint32, uint32, uint32) {
+	switch img := src.(type) {
+	case *image.RGBA:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			s := img.Pix[img.PixOffset(x, y):]
+			return uint32(s[0]), uint32(s[1]), uint32(s[2]), uint32(s[3])
+		}
+	case *image.NRGBA:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			s := img.Pix[img.PixOffset(x, y):]
+			a := uint32(s[3])
+			return uint32(s[0]) * a / 255, uint32(s[1]) * a / 255, uint32(s[2]) * a / 255, a
+		}
+	case *image.YCbCr:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			ci := img.COffset(x, y)
+			r, g, b := color.YCbCrToRGB(img.Y[img.YOffset(x, y)], img.Cb[ci], img.Cr[ci])
+			return uint32(r), uint32(g), uint32(b), 255
+		}
+	case *image.Gray:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			v := uint32(img.Pix[img.PixOffset(x, y)])
+			return v, v, v, 255
+		}
+	}
+	return func(x, y int) (uint32, uint32, uint32, uint32) {
+		r, g, b, a := src.At(x, y).RGBA()
+		return r >> 8, g >> 8, b >> 8, a >> 8
+	}
+}
+
+// EncodeVariant writes img as JPEG, or as PNG when it has transparency, and
+// returns the content type and file extension used.
+func EncodeVariant(w io.Writer, img *image.RGBA) (string, string, error) {
+	if img.Opaque() {
+		return "image/jpeg", ".jpg", jpeg.Encode(w, img, &jpeg.Options{Quality: 82})
+	}
+	return "image/png", ".png", png.Encode(w, img)
+}
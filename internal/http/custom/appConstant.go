@@ -18,11 +18,27 @@ const (
 	NoContent
 )
 
+// responseStatuses holds the status code and message of every
+// ResponseStatus, in declaration order.
+var responseStatuses = [...]struct {
+	code    int
+	message string
+}{
+	{http.StatusOK, "Success"},
+	{http.StatusNotFound, "Data Not Found"},
+	{http.StatusBadRequest, "Bad Request"},
+	{http.StatusUnauthorized, "Unauthorized"},
+	{http.StatusForbidden, "Forbidden"},
+	{http.StatusUnprocessableEntity, "Unprocessable Entity"},
+	{http.StatusInternalServerError, "Internal Server Error"},
+	{http.StatusConflict, "Conflict"},
+	{http.StatusNoContent, "No Content"},
+}
+
 func (r ResponseStatus) GetResponseStatus() bool {
-	responseStatus := [...]int{http.StatusOK, http.StatusNotFound, http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusUnprocessableEntity, http.StatusInternalServerError, http.StatusConflict, http.StatusNoContent}[r-1]
-	return responseStatus != http.StatusOK
+	return responseStatuses[r-1].code != http.StatusOK
 }
 
 func (r ResponseStatus) GetResponseMessage() string {
-	return [...]string{"Success", "Data Not Found", "Bad Request", "Unauthorized", "Internal Server Error", "Conflict", "No Content"}[r-1]
+	return responseStatuses[r-1].message
 }
@@ -1,10 +1,23 @@
 package custom
 
-import "net/http"
+import (
+	"context"
+	"dungeons-dragon-service/internal/dto"
+	"errors"
+	"net/http"
+	"time"
+)
 
 type AppError struct {
 	Code    int
 	Message string
+	// RetryAfter is sent as Retry-After header when set
+	RetryAfter time.Duration
+	// ErrorCode is the machine-readable code of problem responses. Empty
+	// means the default for the status, see DefaultErrorCode.
+	ErrorCode string
+	// Fields lists the failed rules of a validation error
+	Fields []dto.FieldError
 }
 
 func (e *AppError) Error() string {
@@ -49,6 +62,76 @@ func NewConflictError(message string) error {
 	return NewAppError(http.StatusConflict, message, "conflict")
 }
 
+func NewPayloadTooLargeError(message string) error {
+	return NewAppError(http.StatusRequestEntityTooLarge, message, "payload too large")
+}
+
+func NewUnsupportedMediaTypeError(message string) error {
+	return NewAppError(http.StatusUnsupportedMediaType, message, "unsupported media type")
+}
+
 func NewNoContentError() error {
 	return NewAppError(http.StatusNoContent, "no content", "no content")
 }
+
+func NewTooManyRequestsError(message string, retryAfter time.Duration) error {
+	if message == "" {
+		message = "too many requests"
+	}
+	return &AppError{Code: http.StatusTooManyRequests, Message: message, RetryAfter: retryAfter}
+}
+
+// RetryAfterSeconds rounds d up to whole seconds for the Retry-After header.
+func RetryAfterSeconds(d time.Duration) int {
+	secs := int((d + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
+
+// errorCodes are the default ErrorCodes by status.
+var errorCodes = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusConflict:              "conflict",
+	http.StatusRequestEntityTooLarge: "payload_too_large",
+	http.StatusUnsupportedMediaType:  "unsupported_media_type",
+	http.StatusUnprocessableEntity:   "validation_failed",
+	http.StatusTooManyRequests:       "too_many_requests",
+	StatusClientClosedRequest:        "request_canceled",
+	http.StatusInternalServerError:   "internal_error",
+	http.StatusServiceUnavailable:    "service_unavailable",
+	http.StatusGatewayTimeout:        "request_timeout",
+}
+
+// DefaultErrorCode is the ErrorCode of errors that do not set one.
+func DefaultErrorCode(status int) string {
+	if code, ok := errorCodes[status]; ok {
+		return code
+	}
+	if status >= http.StatusInternalServerError {
+		return "internal_error"
+	}
+	return "request_failed"
+}
+
+// StatusClientClosedRequest is the non-standard status nginx logs for
+// requests the client gave up on before the response was ready.
+const StatusClientClosedRequest = 499
+
+// ContextError describes why the request context ended: a 504 once its
+// deadline passed, a 499 when the client went away. It returns nil while the
+// context is still live, so any error the request ran into is reported as is.
+func ContextError(ctx context.Context) *AppError {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return &AppError{Code: http.StatusGatewayTimeout, Message: "request timed out"}
+	case errors.Is(ctx.Err(), context.Canceled):
+		return &AppError{Code: StatusClientClosedRequest, Message: "request canceled"}
+	}
+	return nil
+}
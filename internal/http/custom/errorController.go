@@ -1,72 +1,131 @@
 package custom
 
 import (
-	"fmt"
+	"context"
+	"dungeons-dragon-service/internal/dto"
+	"dungeons-dragon-service/internal/infrastructure/logging"
+	"errors"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
-func PanicController(c echo.Context) error {
-	if err := recover(); err != nil {
-		str := fmt.Sprint(err)
-		parts := strings.SplitN(str, ":", 2)
+// MIMEApplicationProblemJSON is the media type of RFC 7807 problem details.
+const MIMEApplicationProblemJSON = "application/problem+json"
 
-		code := http.StatusInternalServerError
-		msg := "unexpected error"
-		if len(parts) == 2 {
-			if parsedCode, parseErr := strconv.Atoi(strings.TrimSpace(parts[0])); parseErr == nil {
-				code = parsedCode
-				msg = strings.TrimSpace(parts[1])
-			} else {
-				msg = str
-			}
-		} else {
-			msg = str
-		}
+// HTTPErrorHandler is the echo.HTTPErrorHandler of the server. Handlers and
+// middlewares return their errors and the response for them is written here.
+// Clients that accept application/problem+json get a problem detail, others
+// the usual response envelope.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	ctx := c.Request().Context()
+	appErr := FromError(ctx, err)
+	if appErr.Code >= http.StatusInternalServerError && appErr.Code != http.StatusGatewayTimeout {
+		logging.FromContext(ctx).ErrorContext(ctx, "request failed", "error", err)
+	}
+	if appErr.RetryAfter > 0 {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(RetryAfterSeconds(appErr.RetryAfter)))
+	}
 
-		var respStatus interface{}
-		switch code {
-		case http.StatusNotFound:
-			respStatus = DataNotFound.GetResponseStatus()
-		case http.StatusBadRequest:
-			respStatus = BadRequest.GetResponseStatus()
-		case http.StatusUnauthorized:
-			respStatus = Unauthorized.GetResponseStatus()
-		case http.StatusForbidden:
-			respStatus = Forbidden.GetResponseStatus()
-		case http.StatusUnprocessableEntity:
-			respStatus = UnprocessableEntity.GetResponseStatus()
-		case http.StatusConflict:
-			respStatus = Conflict.GetResponseStatus()
-		case http.StatusNoContent:
-			respStatus = NoContent.GetResponseStatus()
-		default:
-			respStatus = InternalServerError.GetResponseStatus()
-		}
+	var writeErr error
+	switch {
+	case c.Request().Method == http.MethodHead || appErr.Code == http.StatusNoContent:
+		writeErr = c.NoContent(appErr.Code)
+	case acceptsProblem(c.Request()):
+		writeErr = writeProblem(c, appErr)
+	default:
+		writeErr = c.JSON(appErr.Code, BuildResponse_(true, appErr.Message, Null()))
+	}
+	if writeErr != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to write error response", "error", writeErr)
+	}
+}
 
-		return c.JSON(code, BuildResponse_(respStatus.(bool), msg, Null()))
+// FromError maps an error to the AppError sent to the client. Errors that
+// are not AppErrors keep their details out of the response.
+func FromError(ctx context.Context, err error) *AppError {
+	// whatever failed, a request that ran out of time or lost its client
+	// is reported as such rather than as an unexpected error
+	if ctxErr := ContextError(ctx); ctxErr != nil {
+		return ctxErr
 	}
-	return nil
+
+	var appErr *AppError
+	var validationErrs validator.ValidationErrors
+	var httpErr *echo.HTTPError
+	switch {
+	case errors.As(err, &appErr):
+		return appErr
+	case errors.As(err, &validationErrs):
+		return validationError(validationErrs)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return &AppError{Code: http.StatusNotFound, Message: "not found"}
+	case errors.As(err, &httpErr):
+		// raised by echo itself: unknown routes, wrong methods, bad binds
+		msg, ok := httpErr.Message.(string)
+		if !ok {
+			msg = http.StatusText(httpErr.Code)
+		}
+		return &AppError{Code: httpErr.Code, Message: strings.ToLower(msg)}
+	}
+	return &AppError{Code: http.StatusInternalServerError, Message: "unexpected error"}
 }
 
-func PanicException(err error) {
-	switch e := err.(type) {
-	case *AppError:
-		PanicException_(e.Code, e.Message)
-	case validator.ValidationErrors:
-		for _, ve := range e {
-			fieldErr := fmt.Sprintf("'%s' field is required", ve.Field())
-			PanicException_(http.StatusBadRequest, fieldErr)
+// acceptsProblem reports whether the client opted in to problem details.
+func acceptsProblem(r *http.Request) bool {
+	for _, accept := range r.Header.Values(echo.HeaderAccept) {
+		for part := range strings.SplitSeq(accept, ",") {
+			if mediaType, _, err := mime.ParseMediaType(part); err == nil && mediaType == MIMEApplicationProblemJSON {
+				return true
+			}
 		}
-	default:
-		PanicException_(http.StatusInternalServerError, "unexpected error")
 	}
+	return false
+}
+
+func writeProblem(c echo.Context, appErr *AppError) error {
+	code := appErr.ErrorCode
+	if code == "" {
+		code = DefaultErrorCode(appErr.Code)
+	}
+	title := http.StatusText(appErr.Code)
+	if appErr.Code == StatusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+	// c.JSON keeps a content type that is already set
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	return c.JSON(appErr.Code, dto.ProblemResponse{
+		Type:     "about:blank",
+		Title:    title,
+		Status:   appErr.Code,
+		Detail:   appErr.Message,
+		Instance: c.Request().URL.Path,
+		Code:     code,
+		TraceID:  traceID(c),
+		Errors:   appErr.Fields,
+	})
 }
 
-func PanicException_(statusCode int, message string) {
-	panic(fmt.Errorf("%d: %s", statusCode, message))
+// traceID is the X-Request-ID of the request. Requests without one get a
+// new id, echoed in the response so it can be quoted to support.
+func traceID(c echo.Context) string {
+	res := c.Response().Header()
+	if id := res.Get(echo.HeaderXRequestID); id != "" {
+		return id
+	}
+	id := c.Request().Header.Get(echo.HeaderXRequestID)
+	if id == "" {
+		id = uuid.NewString()
+	}
+	res.Set(echo.HeaderXRequestID, id)
+	return id
 }
@@ -0,0 +1,135 @@
+package custom
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/dto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestFromError(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		code int
+		msg  string
+	}{
+		{NewForbiddenError("admin access required"), http.StatusForbidden, "admin access required"},
+		{fmt.Errorf("load: %w", NewNotFoundError("character not found")), http.StatusNotFound, "character not found"},
+		{fmt.Errorf("find: %w", gorm.ErrRecordNotFound), http.StatusNotFound, "not found"},
+		{echo.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "method not allowed"},
+		{errors.New("pq: connection refused"), http.StatusInternalServerError, "unexpected error"},
+	} {
+		appErr := FromError(t.Context(), tc.err)
+		require.Equal(t, tc.code, appErr.Code, tc.err)
+		require.Equal(t, tc.msg, appErr.Message, tc.err)
+	}
+
+	canceled, cancel := context.WithCancel(t.Context())
+	cancel()
+	require.Equal(t, StatusClientClosedRequest, FromError(canceled, NewNotFoundError("")).Code)
+	expired, cancel := context.WithTimeout(t.Context(), -time.Second)
+	defer cancel()
+	require.Equal(t, http.StatusGatewayTimeout, FromError(expired, errors.New("query failed")).Code)
+}
+
+func TestHTTPErrorHandler(t *testing.T) {
+	e := echo.New()
+	serve := func(method string, err error) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		HTTPErrorHandler(err, e.NewContext(httptest.NewRequest(method, "/", nil), rec))
+		return rec
+	}
+
+	rec := serve(http.MethodGet, NewTooManyRequestsError("", 1500*time.Millisecond))
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "2", rec.Header().Get("Retry-After"))
+	var body struct {
+		Error   bool   `json:"error"`
+		Message string `json:"message"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.True(t, body.Error)
+	require.Equal(t, "too many requests", body.Message)
+
+	rec = serve(http.MethodHead, NewNotFoundError(""))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Empty(t, rec.Body.String())
+
+	rec = serve(http.MethodGet, NewNoContentError())
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Empty(t, rec.Body.String())
+}
+
+func TestResponseStatusMessages(t *testing.T) {
+	require.Equal(t, "Forbidden", Forbidden.GetResponseMessage())
+	require.Equal(t, "Conflict", Conflict.GetResponseMessage())
+	require.Equal(t, "No Content", NoContent.GetResponseMessage())
+	require.False(t, Success.GetResponseStatus())
+	require.True(t, NoContent.GetResponseStatus())
+}
+
+type pageQuery struct {
+	Limit int `query:"limit" validate:"omitempty,max=100"`
+}
+
+type validated struct {
+	pageQuery
+	Title    string   `json:"title" validate:"required"`
+	Privacy  string   `json:"privacy" validate:"oneof=public private"`
+	ImageIDs []string `json:"image_ids" validate:"dive,uuid"`
+}
+
+func TestValidationError(t *testing.T) {
+	err := Validator().Struct(validated{pageQuery: pageQuery{Limit: 500}, Privacy: "secret", ImageIDs: []string{"x"}})
+	appErr := FromError(t.Context(), err)
+	require.Equal(t, http.StatusUnprocessableEntity, appErr.Code)
+	require.Equal(t, "validation_failed", appErr.ErrorCode)
+	require.Equal(t, []dto.FieldError{
+		{Field: "limit", Pointer: "/limit", Rule: "max", Param: "100", Message: "limit must be 100 or less"},
+		{Field: "title", Pointer: "/title", Rule: "required", Message: "title is a required field"},
+		{Field: "privacy", Pointer: "/privacy", Rule: "oneof", Param: "public private", Message: "privacy must be one of [public private]"},
+		{Field: "image_ids[0]", Pointer: "/image_ids/0", Rule: "uuid", Message: "image_ids[0] must be a valid UUID"},
+	}, appErr.Fields)
+	// every failure is named, not only the first one
+	require.Contains(t, appErr.Message, "title is a required field; ")
+}
+
+func TestProblemResponse(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/characters", nil)
+	req.Header.Set(echo.HeaderAccept, "application/json;q=0.5, application/problem+json")
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	rec := httptest.NewRecorder()
+	HTTPErrorHandler(Validator().Struct(validated{Privacy: "public"}), e.NewContext(req, rec))
+
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	require.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+	var problem dto.ProblemResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	require.Equal(t, "validation_failed", problem.Code)
+	require.Equal(t, "req-1", problem.TraceID)
+	require.Equal(t, "/api/v1/characters", problem.Instance)
+	require.Equal(t, "Unprocessable Entity", problem.Title)
+	require.Len(t, problem.Errors, 1)
+	require.Equal(t, "/title", problem.Errors[0].Pointer)
+
+	// without a request id one is made up and returned
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/characters/1", nil)
+	req.Header.Set(echo.HeaderAccept, MIMEApplicationProblemJSON)
+	rec = httptest.NewRecorder()
+	HTTPErrorHandler(NewNotFoundError("character not found"), e.NewContext(req, rec))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	require.Equal(t, "not_found", problem.Code)
+	require.Equal(t, "character not found", problem.Detail)
+	require.NotEmpty(t, problem.TraceID)
+	require.Equal(t, problem.TraceID, rec.Header().Get(echo.HeaderXRequestID))
+}
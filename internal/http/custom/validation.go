@@ -0,0 +1,98 @@
+package custom
+
+import (
+	"dungeons-dragon-service/internal/dto"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+)
+
+// translator renders failed rules as messages. Translations are registered
+// on each validator, so only errors of Validator are translated.
+var translator ut.Translator
+
+func init() {
+	english := en.New()
+	translator, _ = ut.New(english, english).GetTranslator("en")
+}
+
+// Validator is the request validator shared by the handlers. Its errors
+// name fields by their json or query parameter name and are translated.
+var Validator = sync.OnceValue(func() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(fieldName)
+	if err := en_translations.RegisterDefaultTranslations(v, translator); err != nil {
+		panic(err)
+	}
+	return v
+})
+
+// fieldName is the name a client uses for a field. Fields with neither a
+// json nor a query name, like embedded structs, keep their Go name.
+func fieldName(f reflect.StructField) string {
+	for _, key := range []string{"json", "query"} {
+		if name, _, _ := strings.Cut(f.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+func validationError(errs validator.ValidationErrors) *AppError {
+	fields := make([]dto.FieldError, 0, len(errs))
+	messages := make([]string, 0, len(errs))
+	for _, fe := range errs {
+		path := fieldPath(fe)
+		msg := fe.Translate(translator)
+		fields = append(fields, dto.FieldError{
+			Field:   strings.Join(path, "."),
+			Pointer: jsonPointer(path),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: msg,
+		})
+		messages = append(messages, msg)
+	}
+	return &AppError{
+		Code:      http.StatusUnprocessableEntity,
+		Message:   strings.Join(messages, "; "),
+		ErrorCode: DefaultErrorCode(http.StatusUnprocessableEntity),
+		Fields:    fields,
+	}
+}
+
+// fieldPath splits the namespace of fe into client names, leaving out the
+// validated struct and embedded structs. Those are the segments fieldName
+// did not rename, so they read the same in both namespaces.
+func fieldPath(fe validator.FieldError) []string {
+	names := strings.Split(fe.Namespace(), ".")[1:]
+	goNames := strings.Split(fe.StructNamespace(), ".")[1:]
+	path := make([]string, 0, len(names))
+	for i, name := range names {
+		if i < len(names)-1 && i < len(goNames) && name == goNames[i] {
+			continue
+		}
+		path = append(path, name)
+	}
+	return path
+}
+
+// jsonPointer turns a field path like image_ids[2] into the RFC 6901
+// pointer /image_ids/2.
+func jsonPointer(path []string) string {
+	escape := strings.NewReplacer("~", "~0", "/", "~1")
+	var b strings.Builder
+	for _, name := range path {
+		for part := range strings.SplitSeq(strings.ReplaceAll(name, "]", ""), "[") {
+			b.WriteString("/")
+			b.WriteString(escape.Replace(part))
+		}
+	}
+	return b.String()
+}
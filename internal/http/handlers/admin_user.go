@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"dungeons-dragon-service/internal/dto"
+	"dungeons-dragon-service/internal/http/custom"
+	middleware "dungeons-dragon-service/internal/http/middlewares"
+	usecase "dungeons-dragon-service/internal/usecases"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type AdminUserHandler struct {
+	uc usecase.AdminUserUseCase
+	v  *validator.Validate
+}
+
+func NewAdminUserHandler(uc usecase.AdminUserUseCase) *AdminUserHandler {
+	return &AdminUserHandler{uc: uc, v: custom.Validator()}
+}
+
+// ListUsers godoc
+// @Summary      List users
+// @Description  Retrieves a page of users, optionally searched by username or email.
+// @Tags         admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        page       query     int     false  "Page number (offset paging)"
+// @Param        limit      query     int     false  "Page size (max 100)"
+// @Param        cursor     query     string  false  "Opaque cursor from next_cursor/prev_cursor"
+// @Param        sort       query     string  false  "Sort field, prefix with - for descending"  Enums(created_at, -created_at, updated_at, -updated_at, username, -username)
+// @Param        q          query     string  false  "Search in username and email"
+// @Param        role       query     string  false  "Filter by role"       Enums(user, admin)
+// @Param        suspended  query     string  false  "Filter by suspension"  Enums(true, false)
+// @Success      200  {object}  dto.APIPaginateResponse{data=[]dto.AdminUserResponse}  "List of users"
+// @Failure      400  {object}  dto.APIErrorResponse{data=interface{}}  "Invalid query"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Router       /admin/users [get]
+func (h *AdminUserHandler) List(c echo.Context) error {
+	var q dto.UserListQuery
+	if err := c.Bind(&q); err != nil {
+		return custom.NewBadRequestError("invalid query")
+	}
+	if err := h.v.Struct(q); err != nil {
+		return err
+	}
+	list, paginate, err := h.uc.List(c.Request().Context(), &q)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponseWithPaginate(custom.Success, list, paginate))
+}
+
+// GetUser godoc
+// @Summary      Get user
+// @Tags         admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  dto.APIObjectResponse{data=dto.AdminUserResponse}  "User"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "User not found"
+// @Router       /admin/users/{id} [get]
+func (h *AdminUserHandler) Get(c echo.Context) error {
+	user, err := h.uc.Get(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, user))
+}
+
+// UpdateRole godoc
+// @Summary      Change user role
+// @Description  Promotes or demotes a user. Takes effect on the user's existing tokens immediately.
+// @Tags         admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id                 path      string                 true  "User ID"
+// @Param        updateRoleRequest  body      dto.UpdateRoleRequest  true  "New role"
+// @Success      200                {object}  dto.APIObjectResponse{data=dto.AdminUserResponse}  "Updated user"
+// @Failure      400                {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
+// @Failure      403                {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404                {object}  dto.APIErrorResponse{data=interface{}}  "User not found"
+// @Router       /admin/users/{id}/role [put]
+func (h *AdminUserHandler) UpdateRole(c echo.Context) error {
+	var req dto.UpdateRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return custom.NewBadRequestError("invalid request body")
+	}
+	if err := h.v.Struct(req); err != nil {
+		return err
+	}
+	adminID, _ := middleware.GetUserID(c)
+	user, err := h.uc.SetRole(c.Request().Context(), adminID, c.Param("id"), req.Role)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, user))
+}
+
+// Suspend godoc
+// @Summary      Suspend user
+// @Description  Blocks login and rejects the user's existing tokens until unsuspended.
+// @Tags         admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  dto.APIObjectResponse{data=dto.AdminUserResponse}  "Suspended user"
+// @Failure      400  {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "User not found"
+// @Router       /admin/users/{id}/suspend [post]
+func (h *AdminUserHandler) Suspend(c echo.Context) error {
+	adminID, _ := middleware.GetUserID(c)
+	user, err := h.uc.Suspend(c.Request().Context(), adminID, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, user))
+}
+
+// Unsuspend godoc
+// @Summary      Unsuspend user
+// @Tags         admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  dto.APIObjectResponse{data=dto.AdminUserResponse}  "User"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "User not found"
+// @Router       /admin/users/{id}/unsuspend [post]
+func (h *AdminUserHandler) Unsuspend(c echo.Context) error {
+	user, err := h.uc.Unsuspend(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, user))
+}
+
+// ForcePasswordReset godoc
+// @Summary      Force password reset
+// @Description  Replaces the user's password with a temporary one and signs the user out.
+// @Description  After logging in with it the user can only change the password.
+// @Tags         admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  dto.APIObjectResponse{data=dto.PasswordResetResponse}  "Temporary password"
+// @Failure      400  {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "User not found"
+// @Router       /admin/users/{id}/password-reset [post]
+func (h *AdminUserHandler) ForcePasswordReset(c echo.Context) error {
+	adminID, _ := middleware.GetUserID(c)
+	res, err := h.uc.ForcePasswordReset(c.Request().Context(), adminID, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, res))
+}
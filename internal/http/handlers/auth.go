@@ -3,6 +3,7 @@ package handlers
 import (
 	"dungeons-dragon-service/internal/dto"
 	"dungeons-dragon-service/internal/http/custom"
+	middleware "dungeons-dragon-service/internal/http/middlewares"
 	usecase "dungeons-dragon-service/internal/usecases"
 	"net/http"
 
@@ -11,12 +12,13 @@ import (
 )
 
 type AuthHandler struct {
-	uc usecase.AuthUseCase
-	v  *validator.Validate
+	uc      usecase.AuthUseCase
+	account usecase.AccountUseCase
+	v       *validator.Validate
 }
 
-func NewAuthHandler(uc usecase.AuthUseCase) *AuthHandler {
-	return &AuthHandler{uc: uc, v: validator.New()}
+func NewAuthHandler(uc usecase.AuthUseCase, account usecase.AccountUseCase) *AuthHandler {
+	return &AuthHandler{uc: uc, account: account, v: custom.Validator()}
 }
 
 // Login godoc
@@ -28,22 +30,20 @@ func NewAuthHandler(uc usecase.AuthUseCase) *AuthHandler {
 // @Param        loginRequest  body      dto.LoginRequest  true  "Login Request"
 // @Success      200           {object}  dto.APIObjectResponse{data=dto.LoginResponse}  "Successful login"
 // @Failure      400           {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
-// @Failure      401           {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      401           {object}  dto.APIErrorResponse{data=interface{}}  "Invalid username or password"
+// @Failure      429           {object}  dto.APIErrorResponse{data=interface{}}  "Too many attempts, see Retry-After"
 // @Router       /auth/login [post]
 func (h *AuthHandler) Login(c echo.Context) error {
-	defer custom.PanicController(c)
 	var req dto.LoginRequest
 	if err := c.Bind(&req); err != nil {
-		e := custom.NewBadRequestError("invalid request body")
-		custom.PanicException(e)
+		return custom.NewBadRequestError("invalid request body")
 	}
 	if err := h.v.Struct(req); err != nil {
-		e := custom.NewValidationError("required fields are missing or invalid")
-		custom.PanicException(e)
+		return err
 	}
-	token, err := h.uc.Login(req.Username, req.Password)
+	token, err := h.uc.Login(c.Request().Context(), req.Username, req.Password)
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, token))
 }
@@ -60,19 +60,178 @@ func (h *AuthHandler) Login(c echo.Context) error {
 // @Failure      409              {object}  dto.APIErrorResponse{data=interface{}}  "Conflict - Email or username already exists"
 // @Router       /auth/register [post]
 func (h *AuthHandler) Register(c echo.Context) error {
-	defer custom.PanicController(c)
 	var req dto.RegisterRequest
 	if err := c.Bind(&req); err != nil {
-		e := custom.NewBadRequestError("invalid request body")
-		custom.PanicException(e)
+		return custom.NewBadRequestError("invalid request body")
 	}
 	if err := h.v.Struct(req); err != nil {
-		e := custom.NewValidationError("required fields are missing or invalid")
-		custom.PanicException(e)
+		return err
 	}
-	auth, err := h.uc.Register(req.Username, req.Email, req.Password)
+	auth, err := h.uc.Register(c.Request().Context(), req.Username, req.Email, req.Password)
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, auth))
 }
+
+// Refresh godoc
+// @Summary      Refresh tokens
+// @Description  Exchanges a refresh token for a new access and refresh token. Refresh tokens are single use;
+// @Description  presenting one twice revokes every token issued from the same login.
+// @Tags         authentication
+// @Accept       json
+// @Produce      json
+// @Param        refreshRequest  body      dto.RefreshRequest  true  "Refresh Request"
+// @Success      200             {object}  dto.APIObjectResponse{data=dto.LoginResponse}  "New token pair"
+// @Failure      400             {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
+// @Failure      401             {object}  dto.APIErrorResponse{data=interface{}}  "Invalid, expired or reused refresh token"
+// @Router       /auth/refresh [post]
+func (h *AuthHandler) Refresh(c echo.Context) error {
+	var req dto.RefreshRequest
+	if err := c.Bind(&req); err != nil {
+		return custom.NewBadRequestError("invalid request body")
+	}
+	if err := h.v.Struct(req); err != nil {
+		return err
+	}
+	token, err := h.uc.Refresh(c.Request().Context(), req.RefreshToken)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, token))
+}
+
+// Logout godoc
+// @Summary      Logout
+// @Description  Revokes the current access token and the given refresh token.
+// @Tags         authentication
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        logoutRequest  body      dto.LogoutRequest  false  "Logout Request"
+// @Success      200            {object}  dto.APIObjectResponse{data=string}  "Logged out"
+// @Failure      401            {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Router       /auth/logout [post]
+func (h *AuthHandler) Logout(c echo.Context) error {
+	var req dto.LogoutRequest
+	if err := c.Bind(&req); err != nil {
+		return custom.NewBadRequestError("invalid request body")
+	}
+	uid, _ := middleware.GetUserID(c)
+	jti, exp := middleware.GetTokenID(c)
+	if err := h.uc.Logout(c.Request().Context(), uid, jti, exp, req.RefreshToken); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "logged out"))
+}
+
+// LogoutAll godoc
+// @Summary      Logout all sessions
+// @Description  Revokes every refresh token of the authenticated user and the current access token.
+// @Tags         authentication
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  dto.APIObjectResponse{data=string}  "Logged out everywhere"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Router       /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c echo.Context) error {
+	uid, _ := middleware.GetUserID(c)
+	jti, exp := middleware.GetTokenID(c)
+	if err := h.uc.LogoutAll(c.Request().Context(), uid, jti, exp); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "logged out from all sessions"))
+}
+
+// ForgotPassword godoc
+// @Summary      Request a password reset
+// @Description  Mails a single-use password reset link if the email belongs to an account.
+// @Description  Always answers with success so it cannot be used to discover accounts.
+// @Tags         authentication
+// @Accept       json
+// @Produce      json
+// @Param        forgotPasswordRequest  body      dto.ForgotPasswordRequest  true  "Account email"
+// @Success      200                    {object}  dto.APIObjectResponse{data=string}  "Reset link sent if the account exists"
+// @Failure      400                    {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
+// @Router       /auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c echo.Context) error {
+	var req dto.ForgotPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return custom.NewBadRequestError("invalid request body")
+	}
+	if err := h.v.Struct(req); err != nil {
+		return err
+	}
+	if err := h.account.ForgotPassword(c.Request().Context(), req.Email); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "if the account exists, a reset link has been sent"))
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Sets a new password with the token from the reset email and revokes every session of the user.
+// @Tags         authentication
+// @Accept       json
+// @Produce      json
+// @Param        resetPasswordRequest  body      dto.ResetPasswordRequest  true  "Token and new password"
+// @Success      200                   {object}  dto.APIObjectResponse{data=string}  "Password reset"
+// @Failure      400                   {object}  dto.APIErrorResponse{data=interface{}}  "Invalid or expired token"
+// @Router       /auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c echo.Context) error {
+	var req dto.ResetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return custom.NewBadRequestError("invalid request body")
+	}
+	if err := h.v.Struct(req); err != nil {
+		return err
+	}
+	if err := h.account.ResetPassword(c.Request().Context(), req.Token, req.NewPassword); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "password has been reset, please log in"))
+}
+
+// VerifyEmail godoc
+// @Summary      Verify email
+// @Description  Confirms the email address with the token from the verification email.
+// @Description  Accepts the token as query parameter (GET, for the mailed link) or in the body (POST).
+// @Tags         authentication
+// @Accept       json
+// @Produce      json
+// @Param        token  query     string  false  "Verification token"
+// @Success      200    {object}  dto.APIObjectResponse{data=string}  "Email verified"
+// @Failure      400    {object}  dto.APIErrorResponse{data=interface{}}  "Invalid or expired token"
+// @Router       /auth/verify-email [get]
+// @Router       /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c echo.Context) error {
+	var req dto.VerifyEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return custom.NewBadRequestError("invalid request")
+	}
+	if err := h.v.Struct(req); err != nil {
+		return err
+	}
+	if err := h.account.VerifyEmail(c.Request().Context(), req.Token); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "email verified"))
+}
+
+// ResendVerification godoc
+// @Summary      Resend verification email
+// @Description  Mails a new verification link to the authenticated user. Earlier links stop working.
+// @Tags         authentication
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  dto.APIObjectResponse{data=string}  "Verification email sent"
+// @Failure      400  {object}  dto.APIErrorResponse{data=interface{}}  "Email already verified"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Router       /auth/verify-email/resend [post]
+func (h *AuthHandler) ResendVerification(c echo.Context) error {
+	uid, _ := middleware.GetUserID(c)
+	if err := h.account.SendVerification(c.Request().Context(), uid); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "verification email sent"))
+}
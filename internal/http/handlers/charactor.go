@@ -18,27 +18,83 @@ type CharacterHandler struct {
 }
 
 func NewCharacterHandler(uc usecase.CharacterUseCase) *CharacterHandler {
-	return &CharacterHandler{uc: uc, v: validator.New()}
+	return &CharacterHandler{uc: uc, v: custom.Validator()}
 }
 
 // ListCharacters godoc
 // @Summary      List characters
-// @Description  Retrieves a list of characters for the authenticated user.
+// @Description  Retrieves a page of characters. Visitors only see public characters, users also see
+// @Description  their own private characters and admins see everything.
+// @Description  Use page/limit for offset paging or cursor for keyset paging.
 // @Tags         characters
 // @Security     BearerAuth
 // @Accept       json
 // @Produce      json
-// @Success      200  {object}  dto.APIObjectResponse{data=[]dto.CharacterResponse}  "List of characters"
+// @Param        page      query     int     false  "Page number (offset paging)"
+// @Param        limit     query     int     false  "Page size (max 100)"
+// @Param        cursor    query     string  false  "Opaque cursor from next_cursor/prev_cursor"
+// @Param        sort      query     string  false  "Sort field, prefix with - for descending"  Enums(created_at, -created_at, updated_at, -updated_at, title, -title)
+// @Param        class_id  query     string  false  "Filter by class ID"
+// @Param        race_id   query     string  false  "Filter by race ID"
+// @Param        owner     query     string  false  "Filter by owner user ID"
+// @Param        privacy   query     string  false  "Filter by privacy"  Enums(public, private)
+// @Param        status    query     string  false  "Filter by status"   Enums(active, archived)
+// @Success      200  {object}  dto.APIPaginateResponse{data=[]dto.CharacterResponse}  "List of characters"
+// @Failure      400  {object}  dto.APIErrorResponse{data=interface{}}  "Invalid query"
 // @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
 // @Router       /characters [get]
 func (h *CharacterHandler) List(c echo.Context) error {
-	defer custom.PanicController(c)
-	auth := middleware.IsAuthenticated(c)
-	list, err := h.uc.ListForUser(auth)
+	var q dto.CharacterListQuery
+	if err := c.Bind(&q); err != nil {
+		return custom.NewBadRequestError("invalid query")
+	}
+	if err := h.v.Struct(q); err != nil {
+		return err
+	}
+	list, paginate, err := h.uc.ListForUser(c.Request().Context(), viewerFrom(c), &q)
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 
+	return c.JSON(http.StatusOK, custom.BuildResponseWithPaginate(custom.Success, list, paginate))
+}
+
+// GetCharacter godoc
+// @Summary      Get character
+// @Description  Retrieves a single character. Private characters are only visible to their owner or an admin.
+// @Tags         characters
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Character ID"
+// @Success      200  {object}  dto.APIObjectResponse{data=dto.CharacterResponse}  "Character"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "Character not found"
+// @Router       /characters/{id} [get]
+func (h *CharacterHandler) Get(c echo.Context) error {
+	id := c.Param("id")
+	res, err := h.uc.Get(c.Request().Context(), viewerFrom(c), id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, res))
+}
+
+// ListMyCharacters godoc
+// @Summary      List my characters
+// @Description  Retrieves all active characters owned by the authenticated user, private ones included.
+// @Tags         characters
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  dto.APIObjectResponse{data=[]dto.CharacterResponse}  "List of characters"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Router       /me/characters [get]
+func (h *CharacterHandler) ListMine(c echo.Context) error {
+	uid, _ := middleware.GetUserID(c)
+	list, err := h.uc.ListByOwner(c.Request().Context(), uid)
+	if err != nil {
+		return err
+	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, list))
 }
 
@@ -57,23 +113,21 @@ func (h *CharacterHandler) List(c echo.Context) error {
 func (h *CharacterHandler) Create(c echo.Context) error {
 	var req dto.CharacterCreateRequest
 	if err := c.Bind(&req); err != nil {
-		e := custom.NewBadRequestError("invalid payload")
-		custom.PanicException(e)
+		return custom.NewBadRequestError("invalid payload")
 	}
 	if req.Privacy == "" {
 		req.Privacy = model.PrivacyPublic
 	}
 	if err := h.v.Struct(req); err != nil {
-		e := custom.NewValidationError("required fields are missing or invalid")
-		custom.PanicException(e)
+		return err
 	}
 	uid, _ := middleware.GetUserID(c)
-	_, err := h.uc.Create(uid, &dto.CreateCharacterInput{
+	_, err := h.uc.Create(c.Request().Context(), uid, &dto.CreateCharacterInput{
 		Title: req.Title, Description: req.Description, ClassID: req.ClassID,
 		RaceID: req.RaceID, Privacy: req.Privacy,
 	})
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusCreated, custom.BuildResponse(custom.Success, "character created"))
 }
@@ -93,24 +147,21 @@ func (h *CharacterHandler) Create(c echo.Context) error {
 // @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "Character not found"
 // @Router       /characters/{id} [put]
 func (h *CharacterHandler) Update(c echo.Context) error {
-	defer custom.PanicController(c)
 	id := c.Param("id")
 	var req dto.CharacterUpdateRequest
 	if err := c.Bind(&req); err != nil {
-		e := custom.NewBadRequestError("invalid payload")
-		custom.PanicException(e)
+		return custom.NewBadRequestError("invalid payload")
 	}
 	if err := h.v.Struct(req); err != nil {
-		e := custom.NewValidationError("required fields are missing or invalid")
-		custom.PanicException(e)
+		return err
 	}
 	uid, _ := middleware.GetUserID(c)
-	err := h.uc.Update(uid, id, &dto.UpdateCharacterInput{
+	err := h.uc.Update(c.Request().Context(), uid, id, &dto.UpdateCharacterInput{
 		Title: req.Title, Description: req.Description, ClassID: req.ClassID,
 		RaceID: req.RaceID, Privacy: req.Privacy,
 	})
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "character updated"))
 }
@@ -128,11 +179,10 @@ func (h *CharacterHandler) Update(c echo.Context) error {
 // @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "Character not found"
 // @Router       /characters/{id} [delete]
 func (h *CharacterHandler) Delete(c echo.Context) error {
-	defer custom.PanicController(c)
 	id := c.Param("id")
 	uid, _ := middleware.GetUserID(c)
-	if err := h.uc.Delete(uid, id); err != nil {
-		custom.PanicException(err)
+	if err := h.uc.Delete(c.Request().Context(), uid, id); err != nil {
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "character deleted"))
 }
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/infrastructure/logging"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// readyTimeout bounds the database check so a stuck pool fails the probe
+// instead of hanging it.
+const readyTimeout = 2 * time.Second
+
+// Pinger is what the readiness check needs from the database.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	db Pinger
+}
+
+func NewHealthHandler(db Pinger) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// Ready godoc
+// @Summary      Readiness check
+// @Description  Reports whether the service can reach its database. Unlike /health it fails while the database is down, so load balancers stop routing to the instance.
+// @Tags         Health
+// @Produce      plain
+// @Success      200  {string}  string  "ready"
+// @Failure      503  {string}  string  "database unavailable"
+// @Router       /ready [get]
+func (h *HealthHandler) Ready(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), readyTimeout)
+	defer cancel()
+	if err := h.db.Ping(ctx); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "readiness check failed", "error", err)
+		return c.String(http.StatusServiceUnavailable, "database unavailable")
+	}
+	return c.String(http.StatusOK, "ready")
+}
@@ -1,101 +1,441 @@
 package handlers
 
 import (
-	"dungeons-dragon-service/internal/config"
+	"context"
+	"dungeons-dragon-service/internal/domain/port"
+	"dungeons-dragon-service/internal/dto"
 	"dungeons-dragon-service/internal/http/custom"
 	"dungeons-dragon-service/internal/http/middlewares"
 	usecase "dungeons-dragon-service/internal/usecases"
-	"path/filepath"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 )
 
 type ImageHandler struct {
 	uc usecase.ImageUseCase
+	v  *validator.Validate
 }
 
 func NewImageHandler(uc usecase.ImageUseCase) *ImageHandler {
-	return &ImageHandler{uc: uc}
+	return &ImageHandler{uc: uc, v: custom.Validator()}
 }
 
-// UploadCharacterImage godoc
-// @Summary      Upload character image
-// @Description  Uploads an image for a specific character.
+// AddCharacterImages godoc
+// @Summary      Add character images
+// @Description  Appends images to a character, up to 10 in total. The first image becomes the cover if there is none.
 // @Tags         images
 // @Security     BearerAuth
 // @Accept       multipart/form-data
 // @Produce      json
-// @Param        id      path      string                true  "Character ID"
-// @Param        images  formData  file   true  "List of character images (can upload multiple)"
-// @Success      200     {object}  dto.APIObjectResponse{data=string}  "Character images uploaded successfully"
-// @Failure      400     {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
-// @Failure      401     {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Param        id      path      string  true  "Character ID"
+// @Param        images  formData  file    true  "Images (can upload multiple)"
+// @Success      200  {object}  dto.APIObjectResponse{data=[]dto.ImageResponse}  "Images of the character"
+// @Failure      400  {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "Not found"
+// @Failure      409  {object}  dto.APIErrorResponse{data=interface{}}  "Images changed concurrently"
+// @Failure      413  {object}  dto.APIErrorResponse{data=interface{}}  "Image or request too large"
+// @Failure      415  {object}  dto.APIErrorResponse{data=interface{}}  "Not a jpeg, png, gif or webp image"
 // @Router       /characters/{id}/images [post]
-func (h *ImageHandler) UploadCharacterImage(c echo.Context) error {
-	defer custom.PanicController(c)
+func (h *ImageHandler) AddCharacterImages(c echo.Context) error {
+	return h.upload(c, usecase.ImageOwnerCharacter, h.uc.AddImages)
+}
 
-	id := c.Param("id")
-	if id == "" {
-		custom.PanicException(custom.NewBadRequestError("character id is required"))
-	}
+// ReplaceCharacterImages godoc
+// @Summary      Replace character images
+// @Description  Replaces all images of a character with the uploaded ones.
+// @Tags         images
+// @Security     BearerAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id      path      string  true  "Character ID"
+// @Param        images  formData  file    true  "Images (can upload multiple)"
+// @Success      200  {object}  dto.APIObjectResponse{data=[]dto.ImageResponse}  "Images of the character"
+// @Failure      400  {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "Not found"
+// @Failure      409  {object}  dto.APIErrorResponse{data=interface{}}  "Images changed concurrently"
+// @Failure      413  {object}  dto.APIErrorResponse{data=interface{}}  "Image or request too large"
+// @Failure      415  {object}  dto.APIErrorResponse{data=interface{}}  "Not a jpeg, png, gif or webp image"
+// @Router       /characters/{id}/images [put]
+func (h *ImageHandler) ReplaceCharacterImages(c echo.Context) error {
+	return h.upload(c, usecase.ImageOwnerCharacter, h.uc.ReplaceImages)
+}
+
+// DeleteCharacterImage godoc
+// @Summary      Delete a character image
+// @Description  Deletes one image of a character. If it was the cover, the next image becomes the cover.
+// @Tags         images
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id       path  string  true  "Character ID"
+// @Param        imageId  path  string  true  "Image ID"
+// @Success      200  {object}  dto.APIObjectResponse{data=[]dto.ImageResponse}  "Remaining images of the character"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "Not found"
+// @Failure      409  {object}  dto.APIErrorResponse{data=interface{}}  "Images changed concurrently"
+// @Router       /characters/{id}/images/{imageId} [delete]
+func (h *ImageHandler) DeleteCharacterImage(c echo.Context) error {
 	uid, _ := middlewares.GetUserID(c)
-	// Parse multipart form with a reasonable max memory
-	form, err := c.MultipartForm()
+	images, err := h.uc.DeleteImage(c.Request().Context(), uid, usecase.ImageOwnerCharacter, c.Param("id"), c.Param("imageId"))
 	if err != nil {
-		custom.PanicException(custom.NewBadRequestError("invalid form data"))
+		return err
 	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, images))
+}
 
-	images := form.File["images"]
-	//upload to usecase
-	if err := h.uc.UploadCharacterImage(uid, id, images); err != nil {
-		custom.PanicException(err)
+// ReorderCharacterImages godoc
+// @Summary      Reorder character images
+// @Description  Sets the order of the images of a character. Every image id must be listed once.
+// @Tags         images
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id                    path  string                    true  "Character ID"
+// @Param        reorderImagesRequest  body  dto.ReorderImagesRequest  true  "Every image id in the new order"
+// @Success      200  {object}  dto.APIObjectResponse{data=[]dto.ImageResponse}  "Reordered images of the character"
+// @Failure      400  {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "Not found"
+// @Failure      409  {object}  dto.APIErrorResponse{data=interface{}}  "Images changed concurrently"
+// @Router       /characters/{id}/images/order [put]
+func (h *ImageHandler) ReorderCharacterImages(c echo.Context) error {
+	var req dto.ReorderImagesRequest
+	if err := c.Bind(&req); err != nil {
+		return custom.NewBadRequestError("invalid request body")
 	}
-	return c.JSON(200, custom.BuildResponse(custom.Success, "character images uploaded successfully"))
+	if err := h.v.Struct(req); err != nil {
+		return err
+	}
+	uid, _ := middlewares.GetUserID(c)
+	images, err := h.uc.ReorderImages(c.Request().Context(), uid, usecase.ImageOwnerCharacter, c.Param("id"), req.ImageIDs)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, images))
+}
 
+// SetCharacterCover godoc
+// @Summary      Set character cover image
+// @Description  Marks one image as the cover of a character.
+// @Tags         images
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id       path  string  true  "Character ID"
+// @Param        imageId  path  string  true  "Image ID"
+// @Success      200  {object}  dto.APIObjectResponse{data=[]dto.ImageResponse}  "Images of the character"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "Not found"
+// @Failure      409  {object}  dto.APIErrorResponse{data=interface{}}  "Images changed concurrently"
+// @Router       /characters/{id}/images/{imageId}/cover [put]
+func (h *ImageHandler) SetCharacterCover(c echo.Context) error {
+	uid, _ := middlewares.GetUserID(c)
+	images, err := h.uc.SetCover(c.Request().Context(), uid, usecase.ImageOwnerCharacter, c.Param("id"), c.Param("imageId"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, images))
 }
 
-// UploadQuestImage godoc
-// @Summary      Upload quest image
-// @Description  Uploads an image for a specific quest.
+// AddQuestImages godoc
+// @Summary      Add quest images
+// @Description  Appends images to a quest, up to 10 in total. The first image becomes the cover if there is none.
 // @Tags         images
 // @Security     BearerAuth
 // @Accept       multipart/form-data
 // @Produce      json
-// @Param        id      path      string                true  "Quest ID"
-// @Param        images  formData  file   true  "List of character images (can upload multiple)"
-// @Success      200     {object}  dto.APIObjectResponse{data=string}  "Quest images uploaded successfully"
-// @Failure      400     {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
-// @Failure      401     {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Param        id      path      string  true  "Quest ID"
+// @Param        images  formData  file    true  "Images (can upload multiple)"
+// @Success      200  {object}  dto.APIObjectResponse{data=[]dto.ImageResponse}  "Images of the quest"
+// @Failure      400  {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "Not found"
+// @Failure      409  {object}  dto.APIErrorResponse{data=interface{}}  "Images changed concurrently"
+// @Failure      413  {object}  dto.APIErrorResponse{data=interface{}}  "Image or request too large"
+// @Failure      415  {object}  dto.APIErrorResponse{data=interface{}}  "Not a jpeg, png, gif or webp image"
 // @Router       /quests/{id}/images [post]
-func (h *ImageHandler) UploadQuestImage(c echo.Context) error {
-	defer custom.PanicController(c)
-	id := c.Param("id")
-	if id == "" {
-		custom.PanicException(custom.NewBadRequestError("quest id is required"))
+func (h *ImageHandler) AddQuestImages(c echo.Context) error {
+	return h.upload(c, usecase.ImageOwnerQuest, h.uc.AddImages)
+}
+
+// ReplaceQuestImages godoc
+// @Summary      Replace quest images
+// @Description  Replaces all images of a quest with the uploaded ones.
+// @Tags         images
+// @Security     BearerAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id      path      string  true  "Quest ID"
+// @Param        images  formData  file    true  "Images (can upload multiple)"
+// @Success      200  {object}  dto.APIObjectResponse{data=[]dto.ImageResponse}  "Images of the quest"
+// @Failure      400  {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "Not found"
+// @Failure      409  {object}  dto.APIErrorResponse{data=interface{}}  "Images changed concurrently"
+// @Failure      413  {object}  dto.APIErrorResponse{data=interface{}}  "Image or request too large"
+// @Failure      415  {object}  dto.APIErrorResponse{data=interface{}}  "Not a jpeg, png, gif or webp image"
+// @Router       /quests/{id}/images [put]
+func (h *ImageHandler) ReplaceQuestImages(c echo.Context) error {
+	return h.upload(c, usecase.ImageOwnerQuest, h.uc.ReplaceImages)
+}
+
+// DeleteQuestImage godoc
+// @Summary      Delete a quest image
+// @Description  Deletes one image of a quest. If it was the cover, the next image becomes the cover.
+// @Tags         images
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id       path  string  true  "Quest ID"
+// @Param        imageId  path  string  true  "Image ID"
+// @Success      200  {object}  dto.APIObjectResponse{data=[]dto.ImageResponse}  "Remaining images of the quest"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "Not found"
+// @Failure      409  {object}  dto.APIErrorResponse{data=interface{}}  "Images changed concurrently"
+// @Router       /quests/{id}/images/{imageId} [delete]
+func (h *ImageHandler) DeleteQuestImage(c echo.Context) error {
+	uid, _ := middlewares.GetUserID(c)
+	images, err := h.uc.DeleteImage(c.Request().Context(), uid, usecase.ImageOwnerQuest, c.Param("id"), c.Param("imageId"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, images))
+}
+
+// ReorderQuestImages godoc
+// @Summary      Reorder quest images
+// @Description  Sets the order of the images of a quest. Every image id must be listed once.
+// @Tags         images
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id                    path  string                    true  "Quest ID"
+// @Param        reorderImagesRequest  body  dto.ReorderImagesRequest  true  "Every image id in the new order"
+// @Success      200  {object}  dto.APIObjectResponse{data=[]dto.ImageResponse}  "Reordered images of the quest"
+// @Failure      400  {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "Not found"
+// @Failure      409  {object}  dto.APIErrorResponse{data=interface{}}  "Images changed concurrently"
+// @Router       /quests/{id}/images/order [put]
+func (h *ImageHandler) ReorderQuestImages(c echo.Context) error {
+	var req dto.ReorderImagesRequest
+	if err := c.Bind(&req); err != nil {
+		return custom.NewBadRequestError("invalid request body")
+	}
+	if err := h.v.Struct(req); err != nil {
+		return err
 	}
 	uid, _ := middlewares.GetUserID(c)
+	images, err := h.uc.ReorderImages(c.Request().Context(), uid, usecase.ImageOwnerQuest, c.Param("id"), req.ImageIDs)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, images))
+}
 
-	// Parse multipart form with a reasonable max memory
-	form, err := c.MultipartForm()
+// SetQuestCover godoc
+// @Summary      Set quest cover image
+// @Description  Marks one image as the cover of a quest.
+// @Tags         images
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id       path  string  true  "Quest ID"
+// @Param        imageId  path  string  true  "Image ID"
+// @Success      200  {object}  dto.APIObjectResponse{data=[]dto.ImageResponse}  "Images of the quest"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      403  {object}  dto.APIErrorResponse{data=interface{}}  "Forbidden"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "Not found"
+// @Failure      409  {object}  dto.APIErrorResponse{data=interface{}}  "Images changed concurrently"
+// @Router       /quests/{id}/images/{imageId}/cover [put]
+func (h *ImageHandler) SetQuestCover(c echo.Context) error {
+	uid, _ := middlewares.GetUserID(c)
+	images, err := h.uc.SetCover(c.Request().Context(), uid, usecase.ImageOwnerQuest, c.Param("id"), c.Param("imageId"))
 	if err != nil {
-		custom.PanicException(custom.NewBadRequestError("invalid form data"))
+		return err
 	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, images))
+}
 
-	images := form.File["images"]
-	//upload to usecase
-	if err := h.uc.UploadQuestImage(uid, id, images); err != nil {
-		custom.PanicException(err)
+// upload handles the multipart image endpoints.
+func (h *ImageHandler) upload(c echo.Context, owner usecase.ImageOwner, action func(ctx context.Context, uid string, owner usecase.ImageOwner, ownerID string, images []*multipart.FileHeader) ([]dto.ImageResponse, error)) error {
+	id := c.Param("id")
+	if id == "" {
+		return custom.NewBadRequestError(string(owner) + " id is required")
+	}
+	uid, _ := middlewares.GetUserID(c)
+	form, err := h.parseImageForm(c)
+	if err != nil {
+		return err
+	}
+	images, err := action(c.Request().Context(), uid, owner, id, form.File["images"])
+	if err != nil {
+		return err
 	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, images))
+}
 
-	return c.JSON(200, custom.BuildResponse(custom.Success, "quest images uploaded successfully"))
+// parseImageForm reads the multipart body, refusing bodies beyond the upload
+// limit while they stream in.
+func (h *ImageHandler) parseImageForm(c echo.Context) (*multipart.Form, error) {
+	// leave room for the multipart framing around the files
+	limit := h.uc.MaxUploadSize() + 1<<20
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+	form, err := c.MultipartForm()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, custom.NewPayloadTooLargeError(fmt.Sprintf("request body must be at most %d bytes", limit))
+		}
+		return nil, custom.NewBadRequestError("invalid form data")
+	}
+	return form, nil
 }
 
+// GetImage godoc
+// @Produce      octet-stream
+// @Summary      Get image
+// @Description  Streams a stored character or quest image. Images of private or inactive items are served to their owner, admins, or with the exp and sig of a signed URL. Supports conditional and range requests.
+// @Tags         images
+// @Param        filename  path      string  true   "Image file name"
+// @Param        exp       query     int     false  "Expiry of a signed URL (unix seconds)"
+// @Param        sig       query     string  false  "Signature of a signed URL"
+// @Success      200       {file}    file
+// @Success      206       {file}    file
+// @Success      304       "Not modified"
+// @Failure      404       {object}  dto.APIErrorResponse{data=interface{}}  "Image not found"
+// @Router       /pictures/{filename} [get]
 func (h *ImageHandler) GetImage(c echo.Context) error {
-	defer custom.PanicController(c)
 	filename := c.Param("filename")
 	if filename == "" {
-		custom.PanicException(custom.NewBadRequestError("filename is required"))
+		return custom.NewBadRequestError("filename is required")
+	}
+	file, err := h.uc.Open(c.Request().Context(), viewerFrom(c), filename, c.QueryParam("exp"), c.QueryParam("sig"))
+	if err != nil {
+		return err
+	}
+	defer file.Body.Close()
+
+	res := c.Response()
+	// the bytes behind a key never change, but the item may turn private or
+	// be archived: shared caches revalidate after a few minutes so access
+	// control catches up, and the ETag keeps that revalidation cheap
+	if file.Public {
+		res.Header().Set("Cache-Control", "public, max-age=300, must-revalidate")
+	} else {
+		res.Header().Set("Cache-Control", "private, no-cache")
+		res.Header().Set("Vary", echo.HeaderAuthorization)
+	}
+	if file.Info.ContentType != "" {
+		res.Header().Set(echo.HeaderContentType, file.Info.ContentType)
+	}
+	if file.Info.ETag != "" {
+		res.Header().Set("ETag", file.Info.ETag)
+	}
+	// seekable blobs (filesystem, memory, S3) get range and conditional
+	// requests; other streams of known size can still skip to a range
+	rs, ok := file.Body.(io.ReadSeeker)
+	if !ok && file.Info.Size > 0 {
+		rs, ok = &forwardSeeker{r: file.Body, size: file.Info.Size}, true
+		if res.Header().Get(echo.HeaderContentType) == "" {
+			// sniffing the type would need to seek back
+			res.Header().Set(echo.HeaderContentType, echo.MIMEOctetStream)
+		}
+		if strings.Contains(c.Request().Header.Get("Range"), ",") {
+			// several ranges may go backwards; answer with the whole file
+			c.Request().Header.Del("Range")
+		}
+	}
+	if ok {
+		http.ServeContent(res, c.Request(), filename, file.Info.ModTime, rs)
+		return nil
+	}
+	if !file.Info.ModTime.IsZero() {
+		res.Header().Set(echo.HeaderLastModified, file.Info.ModTime.UTC().Format(http.TimeFormat))
+	}
+	if notModified(c.Request(), file.Info) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.Stream(http.StatusOK, file.Info.ContentType, file.Body)
+}
+
+// forwardSeeker lets http.ServeContent serve a stream of known size. It only
+// moves forward: the bytes in front of a new position are read and dropped.
+type forwardSeeker struct {
+	r    io.Reader
+	size int64
+	pos  int64 // bytes consumed from r
+	off  int64 // position the next Read starts at
+}
+
+func (s *forwardSeeker) Read(p []byte) (int, error) {
+	if s.off < s.pos {
+		return 0, errors.New("cannot seek backwards in a stream")
+	}
+	if s.off > s.pos {
+		n, err := io.CopyN(io.Discard, s.r, s.off-s.pos)
+		s.pos += n
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := s.r.Read(p)
+	s.pos += int64(n)
+	s.off = s.pos
+	return n, err
+}
+
+func (s *forwardSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.off
+	case io.SeekEnd:
+		offset += s.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position %d", offset)
+	}
+	s.off = offset
+	return offset, nil
+}
+
+// notModified evaluates If-None-Match and If-Modified-Since for blobs that
+// cannot go through http.ServeContent.
+func notModified(r *http.Request, info *port.BlobInfo) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if info.ETag == "" {
+			return false
+		}
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(info.ETag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get(echo.HeaderIfModifiedSince); ims != "" && !info.ModTime.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !info.ModTime.Truncate(time.Second).After(t)
 	}
-	filePath := filepath.Join(config.GetConfigString("FILE_STORAGE_PATH"), filename)
-	return c.File(filePath)
+	return false
 }
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/domain/port"
+	usecase "dungeons-dragon-service/internal/usecases"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// streamUseCase serves every image from a reader that cannot seek, the way
+// a streaming blob store would.
+type streamUseCase struct {
+	usecase.ImageUseCase
+	data string
+}
+
+func (u streamUseCase) Open(context.Context, usecase.Viewer, string, string, string) (*usecase.ImageFile, error) {
+	return &usecase.ImageFile{
+		Body:   io.NopCloser(strings.NewReader(u.data)),
+		Info:   &port.BlobInfo{Size: int64(len(u.data)), ContentType: "image/png", ETag: `"v1"`, ModTime: time.Unix(1700000000, 0)},
+		Public: true,
+	}, nil
+}
+
+func TestGetImageRangeOnNonSeekableStore(t *testing.T) {
+	h := NewImageHandler(streamUseCase{data: "0123456789abcdef"})
+	get := func(header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/pictures/a.png", nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetParamNames("filename")
+		c.SetParamValues("a.png")
+		require.NoError(t, h.GetImage(c))
+		return rec
+	}
+
+	rec := get(nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "public, max-age=300, must-revalidate", rec.Header().Get("Cache-Control"))
+
+	rec = get(map[string]string{"Range": "bytes=4-9"})
+	require.Equal(t, http.StatusPartialContent, rec.Code)
+	require.Equal(t, "bytes 4-9/16", rec.Header().Get("Content-Range"))
+	require.Equal(t, "456789", rec.Body.String())
+
+	rec = get(map[string]string{"Range": "bytes=-3"})
+	require.Equal(t, http.StatusPartialContent, rec.Code)
+	require.Equal(t, "def", rec.Body.String())
+
+	// several ranges cannot be served from a stream; the whole file is sent
+	rec = get(map[string]string{"Range": "bytes=8-9,0-1"})
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "0123456789abcdef", rec.Body.String())
+
+	rec = get(map[string]string{"Range": "bytes=20-"})
+	require.Equal(t, http.StatusRequestedRangeNotSatisfiable, rec.Code)
+
+	rec = get(map[string]string{"If-None-Match": `"v1"`})
+	require.Equal(t, http.StatusNotModified, rec.Code)
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"dungeons-dragon-service/internal/infrastructure/jwt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type JWKSHandler struct {
+	keys *jwt.KeySet
+}
+
+func NewJWKSHandler(keys *jwt.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// JWKS godoc
+// @Summary      Public signing keys
+// @Description  JSON Web Key Set with the public keys access tokens can be verified with. Empty when tokens are signed with HS256.
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object}  jwt.JWKS
+// @Router       /.well-known/jwks.json [get]
+func (h *JWKSHandler) JWKS(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, h.keys.JWKS())
+}
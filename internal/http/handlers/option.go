@@ -18,7 +18,7 @@ type OptionHandler struct {
 func NewOptionHandler(optUseCase usecase.OptionUseCase) *OptionHandler {
 	return &OptionHandler{
 		uc: optUseCase,
-		v:  validator.New(),
+		v:  custom.Validator(),
 	}
 }
 
@@ -34,10 +34,9 @@ func NewOptionHandler(optUseCase usecase.OptionUseCase) *OptionHandler {
 // @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
 // @Router       /options/classes [get]
 func (h *OptionHandler) ListClasses(c echo.Context) error {
-	defer custom.PanicController(c)
-	res, err := h.uc.ListClasses()
+	res, err := h.uc.ListClasses(c.Request().Context())
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, res))
 }
@@ -54,10 +53,9 @@ func (h *OptionHandler) ListClasses(c echo.Context) error {
 // @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
 // @Router       /options/races [get]
 func (h *OptionHandler) ListRaces(c echo.Context) error {
-	defer custom.PanicController(c)
-	res, err := h.uc.ListRaces()
+	res, err := h.uc.ListRaces(c.Request().Context())
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, res))
 }
@@ -74,10 +72,9 @@ func (h *OptionHandler) ListRaces(c echo.Context) error {
 // @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
 // @Router       /options/quest-levels [get]
 func (h *OptionHandler) ListQuestLevels(c echo.Context) error {
-	defer custom.PanicController(c)
-	res, err := h.uc.ListQuestLevels()
+	res, err := h.uc.ListQuestLevels(c.Request().Context())
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, res))
 }
@@ -95,19 +92,16 @@ func (h *OptionHandler) ListQuestLevels(c echo.Context) error {
 // @Failure      401    {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
 // @Router       /admin/options/classes [post]
 func (h *OptionHandler) CreateClass(c echo.Context) error {
-	defer custom.PanicController(c)
 	var req dto.OptionReq
 	if err := c.Bind(&req); err != nil {
-		e := custom.NewBadRequestError("invalid payload")
-		custom.PanicException(e)
+		return custom.NewBadRequestError("invalid payload")
 	}
 	if err := h.v.Struct(req); err != nil {
-		e := custom.NewValidationError("required fields are missing or invalid")
-		custom.PanicException(e)
+		return err
 	}
-	err := h.uc.CreateClass(req.Name)
+	err := h.uc.CreateClass(c.Request().Context(), req.Name)
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusCreated, custom.BuildResponse(custom.Success, "class created"))
 }
@@ -127,20 +121,17 @@ func (h *OptionHandler) CreateClass(c echo.Context) error {
 // @Failure      404    {object}  dto.APIErrorResponse{data=interface{}}  "Class not found"
 // @Router       /admin/options/classes/{id} [put]
 func (h *OptionHandler) UpdateClass(c echo.Context) error {
-	defer custom.PanicController(c)
 	id := c.Param("id")
 	var req dto.OptionReq
 	if err := c.Bind(&req); err != nil {
-		e := custom.NewBadRequestError("invalid payload")
-		custom.PanicException(e)
+		return custom.NewBadRequestError("invalid payload")
 	}
 	if err := h.v.Struct(req); err != nil {
-		e := custom.NewValidationError("required fields are missing or invalid")
-		custom.PanicException(e)
+		return err
 	}
-	err := h.uc.UpdateClass(id, req.Name)
+	err := h.uc.UpdateClass(c.Request().Context(), id, req.Name)
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "class updated"))
 }
@@ -159,10 +150,9 @@ func (h *OptionHandler) UpdateClass(c echo.Context) error {
 // @Failure      404    {object}  dto.APIErrorResponse{data=interface{}}  "Class not found"
 // @Router       /admin/options/classes/{id} [delete]
 func (h *OptionHandler) DeleteClass(c echo.Context) error {
-	defer custom.PanicController(c)
 	id := c.Param("id")
-	if err := h.uc.DeleteClass(id); err != nil {
-		custom.PanicException(err)
+	if err := h.uc.DeleteClass(c.Request().Context(), id); err != nil {
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "class deleted"))
 }
@@ -180,19 +170,16 @@ func (h *OptionHandler) DeleteClass(c echo.Context) error {
 // @Failure      401    {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
 // @Router       /admin/options/races [post]
 func (h *OptionHandler) CreateRace(c echo.Context) error {
-	defer custom.PanicController(c)
 	var req dto.OptionReq
 	if err := c.Bind(&req); err != nil {
-		e := custom.NewBadRequestError("invalid payload")
-		custom.PanicException(e)
+		return custom.NewBadRequestError("invalid payload")
 	}
 	if err := h.v.Struct(req); err != nil {
-		e := custom.NewValidationError("required fields are missing or invalid")
-		custom.PanicException(e)
+		return err
 	}
-	err := h.uc.CreateRace(req.Name)
+	err := h.uc.CreateRace(c.Request().Context(), req.Name)
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusCreated, custom.BuildResponse(custom.Success, "race created"))
 }
@@ -212,20 +199,17 @@ func (h *OptionHandler) CreateRace(c echo.Context) error {
 // @Failure      404    {object}  dto.APIErrorResponse{data=interface{}}  "Not Found"
 // @Router       /admin/options/races/{id} [put]
 func (h *OptionHandler) UpdateRace(c echo.Context) error {
-	defer custom.PanicController(c)
 	id := c.Param("id")
 	var req dto.OptionReq
 	if err := c.Bind(&req); err != nil {
-		e := custom.NewBadRequestError("invalid payload")
-		custom.PanicException(e)
+		return custom.NewBadRequestError("invalid payload")
 	}
 	if err := h.v.Struct(req); err != nil {
-		e := custom.NewValidationError("required fields are missing or invalid")
-		custom.PanicException(e)
+		return err
 	}
-	err := h.uc.UpdateRace(id, req.Name)
+	err := h.uc.UpdateRace(c.Request().Context(), id, req.Name)
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "race updated"))
 }
@@ -244,10 +228,9 @@ func (h *OptionHandler) UpdateRace(c echo.Context) error {
 // @Failure      404    {object}  dto.APIErrorResponse{data=interface{}}  "Not Found"
 // @Router       /admin/options/races/{id} [delete]
 func (h *OptionHandler) DeleteRace(c echo.Context) error {
-	defer custom.PanicController(c)
 	id := c.Param("id")
-	if err := h.uc.DeleteRace(id); err != nil {
-		custom.PanicException(err)
+	if err := h.uc.DeleteRace(c.Request().Context(), id); err != nil {
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "race deleted"))
 }
@@ -265,19 +248,16 @@ func (h *OptionHandler) DeleteRace(c echo.Context) error {
 // @Failure      401    {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
 // @Router       /admin/options/quest-levels [post]
 func (h *OptionHandler) CreateQuestLevel(c echo.Context) error {
-	defer custom.PanicController(c)
 	var req dto.OptionReq
 	if err := c.Bind(&req); err != nil {
-		e := custom.NewBadRequestError("invalid payload")
-		custom.PanicException(e)
+		return custom.NewBadRequestError("invalid payload")
 	}
 	if err := h.v.Struct(req); err != nil {
-		e := custom.NewValidationError("required fields are missing or invalid")
-		custom.PanicException(e)
+		return err
 	}
-	err := h.uc.CreateQuestLevel(req.Name)
+	err := h.uc.CreateQuestLevel(c.Request().Context(), req.Name)
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusCreated, custom.BuildResponse(custom.Success, "quest level created"))
 }
@@ -297,20 +277,17 @@ func (h *OptionHandler) CreateQuestLevel(c echo.Context) error {
 // @Failure      404    {object}  dto.APIErrorResponse{data=interface{}}  "Not Found"
 // @Router       /admin/options/quest-levels/{id} [put]
 func (h *OptionHandler) UpdateQuestLevel(c echo.Context) error {
-	defer custom.PanicController(c)
 	id := c.Param("id")
 	var req dto.OptionReq
 	if err := c.Bind(&req); err != nil {
-		e := custom.NewBadRequestError("invalid payload")
-		custom.PanicException(e)
+		return custom.NewBadRequestError("invalid payload")
 	}
 	if err := h.v.Struct(req); err != nil {
-		e := custom.NewValidationError("required fields are missing or invalid")
-		custom.PanicException(e)
+		return err
 	}
-	err := h.uc.UpdateQuestLevel(id, req.Name)
+	err := h.uc.UpdateQuestLevel(c.Request().Context(), id, req.Name)
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "quest level updated"))
 }
@@ -329,10 +306,9 @@ func (h *OptionHandler) UpdateQuestLevel(c echo.Context) error {
 // @Failure      404    {object}  dto.APIErrorResponse{data=interface{}}  "Not Found"
 // @Router       /admin/options/quest-levels/{id} [delete]
 func (h *OptionHandler) DeleteQuestLevel(c echo.Context) error {
-	defer custom.PanicController(c)
 	id := c.Param("id")
-	if err := h.uc.DeleteQuestLevel(id); err != nil {
-		custom.PanicException(err)
+	if err := h.uc.DeleteQuestLevel(c.Request().Context(), id); err != nil {
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "quest level deleted"))
 }
@@ -17,28 +17,82 @@ type QuestHandler struct {
 }
 
 func NewQuestHandler(uc usecase.QuestUseCase) *QuestHandler {
-	return &QuestHandler{uc: uc, v: validator.New()}
+	return &QuestHandler{uc: uc, v: custom.Validator()}
 }
 
 // List godoc
 // @Summary      List quests
-// @Description  Retrieves a list of quests for the authenticated user.
+// @Description  Retrieves a page of quests. Visitors only see public quests, users also see
+// @Description  their own private quests and admins see everything.
+// @Description  Use page/limit for offset paging or cursor for keyset paging.
 // @Tags         quests
 // @Security     BearerAuth
 // @Accept       json
 // @Produce      json
-// @Success      200  {object}  dto.APIObjectResponse{data=[]dto.QuestResponse}  "List of quests"
+// @Param        page            query     int     false  "Page number (offset paging)"
+// @Param        limit           query     int     false  "Page size (max 100)"
+// @Param        cursor          query     string  false  "Opaque cursor from next_cursor/prev_cursor"
+// @Param        sort            query     string  false  "Sort field, prefix with - for descending"  Enums(created_at, -created_at, updated_at, -updated_at, title, -title)
+// @Param        quest_level_id  query     string  false  "Filter by quest level ID"
+// @Param        owner           query     string  false  "Filter by owner user ID"
+// @Param        privacy         query     string  false  "Filter by privacy"  Enums(public, private)
+// @Param        status          query     string  false  "Filter by status"   Enums(active, archived)
+// @Success      200  {object}  dto.APIPaginateResponse{data=[]dto.QuestResponse}  "List of quests"
 // @Failure      400  {object}  dto.APIErrorResponse{data=interface{}} "Invalid request"
 // @Failure      401  {object}  dto.APIErrorResponse{data=interface{}} "Unauthorized"
 // @Router       /quests [get]
 func (h *QuestHandler) List(c echo.Context) error {
-	defer custom.PanicController(c)
-	auth := middleware.IsAuthenticated(c)
-	list, err := h.uc.ListForUser(auth)
+	var q dto.QuestListQuery
+	if err := c.Bind(&q); err != nil {
+		return custom.NewBadRequestError("invalid query")
+	}
+	if err := h.v.Struct(q); err != nil {
+		return err
+	}
+	list, paginate, err := h.uc.ListForUser(c.Request().Context(), viewerFrom(c), &q)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, custom.BuildResponseWithPaginate(custom.Success, list, paginate))
+}
+
+// Get godoc
+// @Summary      Get quest
+// @Description  Retrieves a single quest. Private quests are only visible to their owner or an admin.
+// @Tags         quests
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Quest ID"
+// @Success      200  {object}  dto.APIObjectResponse{data=dto.QuestResponse}  "Quest"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "Quest not found"
+// @Router       /quests/{id} [get]
+func (h *QuestHandler) Get(c echo.Context) error {
+	id := c.Param("id")
+	res, err := h.uc.Get(c.Request().Context(), viewerFrom(c), id)
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, res))
+}
 
+// ListMine godoc
+// @Summary      List my quests
+// @Description  Retrieves all active quests owned by the authenticated user, private ones included.
+// @Tags         quests
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  dto.APIObjectResponse{data=[]dto.QuestResponse}  "List of quests"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Router       /me/quests [get]
+func (h *QuestHandler) ListMine(c echo.Context) error {
+	uid, _ := middleware.GetUserID(c)
+	list, err := h.uc.ListByOwner(c.Request().Context(), uid)
+	if err != nil {
+		return err
+	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, list))
 }
 
@@ -55,25 +109,22 @@ func (h *QuestHandler) List(c echo.Context) error {
 // @Failure      401    {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
 // @Router       /quests [post]
 func (h *QuestHandler) Create(c echo.Context) error {
-	defer custom.PanicController(c)
 	var req dto.QuestCreateRequest
 	if err := c.Bind(&req); err != nil {
-		e := custom.NewBadRequestError("invalid payload")
-		custom.PanicException(e)
+		return custom.NewBadRequestError("invalid payload")
 	}
 	if err := h.v.Struct(req); err != nil {
-		e := custom.NewValidationError("required fields are missing or invalid")
-		custom.PanicException(e)
+		return err
 	}
 	uid, _ := middleware.GetUserID(c)
-	err := h.uc.Create(uid, &dto.CreateQuestInput{
+	err := h.uc.Create(c.Request().Context(), uid, &dto.CreateQuestInput{
 		Title:        req.Title,
 		Description:  req.Description,
 		QuestLevelID: req.QuestLevelID,
 		Privacy:      req.Privacy,
 	})
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusCreated, custom.BuildResponse(custom.Success, "quest created"))
 }
@@ -92,26 +143,23 @@ func (h *QuestHandler) Create(c echo.Context) error {
 // @Failure      401    {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
 // @Router       /quests/{id} [put]
 func (h *QuestHandler) Update(c echo.Context) error {
-	defer custom.PanicController(c)
 	id := c.Param("id")
 	var req dto.QuestUpdateRequest
 	if err := c.Bind(&req); err != nil {
-		e := custom.NewBadRequestError("invalid payload")
-		custom.PanicException(e)
+		return custom.NewBadRequestError("invalid payload")
 	}
 	if err := h.v.Struct(req); err != nil {
-		e := custom.NewValidationError("required fields are missing or invalid")
-		custom.PanicException(e)
+		return err
 	}
 	uid, _ := middleware.GetUserID(c)
-	err := h.uc.Update(uid, id, &dto.UpdateQuestInput{
+	err := h.uc.Update(c.Request().Context(), uid, id, &dto.UpdateQuestInput{
 		Title:        req.Title,
 		Description:  req.Description,
 		QuestLevelID: req.QuestLevelID,
 		Privacy:      req.Privacy,
 	})
 	if err != nil {
-		custom.PanicException(err)
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "quest updated"))
 }
@@ -129,11 +177,10 @@ func (h *QuestHandler) Update(c echo.Context) error {
 // @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
 // @Router       /quests/{id} [delete]
 func (h *QuestHandler) Delete(c echo.Context) error {
-	defer custom.PanicController(c)
 	id := c.Param("id")
 	uid, _ := middleware.GetUserID(c)
-	if err := h.uc.Delete(uid, id); err != nil {
-		custom.PanicException(err)
+	if err := h.uc.Delete(c.Request().Context(), uid, id); err != nil {
+		return err
 	}
 	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "quest deleted"))
 }
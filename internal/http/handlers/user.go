@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"dungeons-dragon-service/internal/dto"
+	"dungeons-dragon-service/internal/http/custom"
+	middleware "dungeons-dragon-service/internal/http/middlewares"
+	usecase "dungeons-dragon-service/internal/usecases"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type UserHandler struct {
+	uc usecase.UserUseCase
+	v  *validator.Validate
+}
+
+func NewUserHandler(uc usecase.UserUseCase) *UserHandler {
+	return &UserHandler{uc: uc, v: custom.Validator()}
+}
+
+// GetMe godoc
+// @Summary      Get my profile
+// @Description  Returns the profile of the authenticated user.
+// @Tags         users
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  dto.APIObjectResponse{data=dto.UserProfileResponse}  "Profile"
+// @Failure      401  {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      404  {object}  dto.APIErrorResponse{data=interface{}}  "User not found"
+// @Router       /me [get]
+func (h *UserHandler) GetMe(c echo.Context) error {
+	uid, _ := middleware.GetUserID(c)
+	profile, err := h.uc.GetProfile(c.Request().Context(), uid)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, profile))
+}
+
+// UpdateMe godoc
+// @Summary      Update my profile
+// @Description  Changes the username and/or email of the authenticated user. Both must stay unique.
+// @Tags         users
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        updateProfileRequest  body      dto.UpdateProfileRequest  true  "Fields to change"
+// @Success      200                   {object}  dto.APIObjectResponse{data=dto.UserProfileResponse}  "Updated profile"
+// @Failure      400                   {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
+// @Failure      401                   {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      409                   {object}  dto.APIErrorResponse{data=interface{}}  "Email or username already exists"
+// @Router       /me [patch]
+func (h *UserHandler) UpdateMe(c echo.Context) error {
+	var req dto.UpdateProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return custom.NewBadRequestError("invalid request body")
+	}
+	if err := h.v.Struct(req); err != nil {
+		return err
+	}
+	uid, _ := middleware.GetUserID(c)
+	profile, err := h.uc.UpdateProfile(c.Request().Context(), uid, &req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, profile))
+}
+
+// ChangePassword godoc
+// @Summary      Change my password
+// @Description  Verifies the old password, stores the new one and revokes every session of the user,
+// @Description  including the current access token.
+// @Tags         users
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        changePasswordRequest  body      dto.ChangePasswordRequest  true  "Old and new password"
+// @Success      200                    {object}  dto.APIObjectResponse{data=string}  "Password changed"
+// @Failure      400                    {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
+// @Failure      401                    {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      403                    {object}  dto.APIErrorResponse{data=interface{}}  "Wrong password"
+// @Router       /me/password [post]
+func (h *UserHandler) ChangePassword(c echo.Context) error {
+	var req dto.ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return custom.NewBadRequestError("invalid request body")
+	}
+	if err := h.v.Struct(req); err != nil {
+		return err
+	}
+	uid, _ := middleware.GetUserID(c)
+	jti, exp := middleware.GetTokenID(c)
+	if err := h.uc.ChangePassword(c.Request().Context(), uid, jti, exp, req.OldPassword, req.NewPassword); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "password changed, please log in again"))
+}
+
+// DeleteMe godoc
+// @Summary      Delete my account
+// @Description  Archives the user's characters and quests, revokes every session and deletes the account.
+// @Tags         users
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        deleteAccountRequest  body      dto.DeleteAccountRequest  true  "Password confirmation"
+// @Success      200                   {object}  dto.APIObjectResponse{data=string}  "Account deleted"
+// @Failure      400                   {object}  dto.APIErrorResponse{data=interface{}}  "Invalid request"
+// @Failure      401                   {object}  dto.APIErrorResponse{data=interface{}}  "Unauthorized"
+// @Failure      403                   {object}  dto.APIErrorResponse{data=interface{}}  "Wrong password"
+// @Router       /me [delete]
+func (h *UserHandler) DeleteMe(c echo.Context) error {
+	var req dto.DeleteAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return custom.NewBadRequestError("invalid request body")
+	}
+	if err := h.v.Struct(req); err != nil {
+		return err
+	}
+	uid, _ := middleware.GetUserID(c)
+	jti, exp := middleware.GetTokenID(c)
+	if err := h.uc.DeleteAccount(c.Request().Context(), uid, jti, exp, req.Password); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, custom.BuildResponse(custom.Success, "account deleted"))
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"dungeons-dragon-service/internal/domain/model"
+	middleware "dungeons-dragon-service/internal/http/middlewares"
+	usecase "dungeons-dragon-service/internal/usecases"
+
+	"github.com/labstack/echo/v4"
+)
+
+// viewerFrom builds the usecase viewer from the parsed JWT claims, if any.
+func viewerFrom(c echo.Context) usecase.Viewer {
+	uid, _ := middleware.GetUserID(c)
+	return usecase.Viewer{UserID: uid, Role: model.Role(middleware.GetRole(c))}
+}
@@ -1,19 +1,34 @@
 package middlewares
 
 import (
+	"context"
+	"dungeons-dragon-service/internal/domain/model"
 	"dungeons-dragon-service/internal/http/custom"
+	"dungeons-dragon-service/internal/infrastructure/jwt"
+	"dungeons-dragon-service/internal/infrastructure/logging"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 )
 
+// SessionResolver loads the live session of a token: it fails for revoked
+// tokens and deleted or suspended users and returns the current role.
+type SessionResolver interface {
+	ResolveSession(ctx context.Context, userID, jti string) (*model.Session, error)
+}
+
 type JWTMiddleware struct {
-	secret []byte
+	tokens               *jwt.Manager
+	sessions             SessionResolver
+	requireVerifiedEmail bool
 }
 
-func NewJWTMiddleware(secret string) *JWTMiddleware {
-	return &JWTMiddleware{secret: []byte(secret)}
+func NewJWTMiddleware(tokens *jwt.Manager, sessions SessionResolver, requireVerifiedEmail bool) *JWTMiddleware {
+	return &JWTMiddleware{tokens: tokens, sessions: sessions, requireVerifiedEmail: requireVerifiedEmail}
 }
 
 func (m *JWTMiddleware) Parse(next echo.HandlerFunc) echo.HandlerFunc {
@@ -23,41 +38,74 @@ func (m *JWTMiddleware) Parse(next echo.HandlerFunc) echo.HandlerFunc {
 			// No token provided: proceed as unauthenticated
 			return next(c)
 		}
-		tokenStr := auth
-		// Support "Bearer <token>"
-		if len(auth) > 7 && auth[:7] == "Bearer " {
-			tokenStr = auth[7:]
+		scheme, tokenStr, ok := strings.Cut(auth, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || tokenStr == "" {
+			return custom.NewUnauthorizedError("authorization header must be: Bearer <token>")
+		}
+
+		claims, err := m.tokens.ParseToken(tokenStr)
+		if err != nil {
+			return custom.NewUnauthorizedError("invalid token")
+		}
+		session, err := m.sessions.ResolveSession(c.Request().Context(), claims.Sub, claims.ID)
+		if err != nil {
+			return sessionError(err)
 		}
 
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			return m.secret, nil
-		})
-		if err != nil || !token.Valid {
-			return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+		// Set user in context; the role comes from the database, not the
+		// token, so role changes apply immediately
+		c.Set("userID", claims.Sub)
+		c.Set("role", string(session.Role))
+		c.Set("jti", claims.ID)
+		c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+		c.Set("passwordResetRequired", session.PasswordResetRequired)
+		c.Set("emailVerified", session.EmailVerified)
+		// whatever the request logs names the user
+		c.SetRequest(c.Request().WithContext(logging.With(c.Request().Context(), "user_id", claims.Sub)))
+		return next(c)
+	}
+}
+
+// RequireVerifiedEmail rejects users whose email is not verified yet, if the
+// middleware was created with requireVerifiedEmail. Use after RequireAuth.
+func (m *JWTMiddleware) RequireVerifiedEmail(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !m.requireVerifiedEmail || c.Request().Method == http.MethodOptions {
+			return next(c)
 		}
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			// Set user in context
-			if sub, ok := claims["sub"]; ok {
-				switch v := sub.(type) {
-				case string:
-					c.Set("userID", v)
-				}
-			}
-			if role, ok := claims["role"].(string); ok {
-				c.Set("role", role)
-			}
+		if verified, _ := c.Get("emailVerified").(bool); !verified {
+			return &custom.AppError{Code: http.StatusForbidden, Message: "email verification required", ErrorCode: "email_verification_required"}
 		}
 		return next(c)
 	}
 }
 
+func sessionError(err error) error {
+	var appErr *custom.AppError
+	if errors.As(err, &appErr) && (appErr.Code == http.StatusUnauthorized || appErr.Code == http.StatusForbidden) {
+		return appErr
+	}
+	return fmt.Errorf("failed to verify token: %w", err)
+}
+
 func RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	return RequireAuthAllowingReset(func(c echo.Context) error {
+		if pending, _ := c.Get("passwordResetRequired").(bool); pending && c.Request().Method != http.MethodOptions {
+			return &custom.AppError{Code: http.StatusForbidden, Message: "password reset required", ErrorCode: "password_reset_required"}
+		}
+		return next(c)
+	})
+}
+
+// RequireAuthAllowingReset is RequireAuth for the few routes a user whose
+// password reset was forced may still use, like changing the password.
+func RequireAuthAllowingReset(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		if c.Request().Method == http.MethodOptions {
 			return next(c)
 		}
 		if c.Get("userID") == nil {
-			return c.JSON(http.StatusUnauthorized, custom.BuildResponse(custom.Unauthorized, "authentication required"))
+			return custom.NewUnauthorizedError("authentication required")
 		}
 		return next(c)
 	}
@@ -70,7 +118,7 @@ func RequireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
 		}
 		role, _ := c.Get("role").(string)
 		if role != "admin" {
-			return c.JSON(http.StatusForbidden, custom.BuildResponse(custom.Forbidden, "admin access required"))
+			return &custom.AppError{Code: http.StatusForbidden, Message: "admin access required", ErrorCode: "admin_required"}
 		}
 		return next(c)
 	}
@@ -81,6 +129,18 @@ func GetUserID(c echo.Context) (string, bool) {
 	return id, ok
 }
 
+func GetRole(c echo.Context) string {
+	role, _ := c.Get("role").(string)
+	return role
+}
+
+// GetTokenID returns the ID and expiry of the access token of the request.
+func GetTokenID(c echo.Context) (string, time.Time) {
+	jti, _ := c.Get("jti").(string)
+	exp, _ := c.Get("tokenExpiresAt").(time.Time)
+	return jti, exp
+}
+
 func IsAuthenticated(c echo.Context) bool {
 	_, ok := c.Get("userID").(string)
 	return ok
@@ -0,0 +1,100 @@
+package middlewares
+
+import (
+	"dungeons-dragon-service/internal/domain/port"
+	"dungeons-dragon-service/internal/http/custom"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Rate allows Limit requests per Window. A zero Limit disables limiting.
+type Rate struct {
+	Limit  int64
+	Window time.Duration
+}
+
+// ParseRate reads a rate like "20/1m". An empty string or "0" disables the limit.
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return Rate{}, nil
+	}
+	count, window, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("rate %q must look like 20/1m", s)
+	}
+	limit, err := strconv.ParseInt(count, 10, 64)
+	if err != nil || limit < 0 {
+		return Rate{}, fmt.Errorf("rate %q: invalid count", s)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return Rate{}, fmt.Errorf("rate %q: invalid window", s)
+	}
+	return Rate{Limit: limit, Window: d}, nil
+}
+
+type RateLimits struct {
+	Auth   Rate // per client IP on login, register and the other auth endpoints
+	Write  Rate // per user on create, update and delete
+	Upload Rate // per user on image uploads
+}
+
+type RateLimiter struct {
+	store  port.CounterStore
+	limits RateLimits
+}
+
+func NewRateLimiter(store port.CounterStore, limits RateLimits) *RateLimiter {
+	return &RateLimiter{store: store, limits: limits}
+}
+
+func (r *RateLimiter) Auth(next echo.HandlerFunc) echo.HandlerFunc {
+	return r.limit("auth", r.limits.Auth, clientIP, next)
+}
+
+// Write is keyed by user; use after RequireAuth.
+func (r *RateLimiter) Write(next echo.HandlerFunc) echo.HandlerFunc {
+	return r.limit("write", r.limits.Write, userOrIP, next)
+}
+
+// Upload is keyed by user; use after RequireAuth.
+func (r *RateLimiter) Upload(next echo.HandlerFunc) echo.HandlerFunc {
+	return r.limit("upload", r.limits.Upload, userOrIP, next)
+}
+
+func (r *RateLimiter) limit(name string, rate Rate, key func(echo.Context) string, next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if rate.Limit <= 0 || c.Request().Method == http.MethodOptions {
+			return next(c)
+		}
+		count, ttl, err := r.store.Incr("rl:"+name+":"+key(c), rate.Window)
+		if err != nil {
+			// fail open: an unavailable store must not take the API down
+			return next(c)
+		}
+		h := c.Response().Header()
+		h.Set("X-RateLimit-Limit", strconv.FormatInt(rate.Limit, 10))
+		h.Set("X-RateLimit-Remaining", strconv.FormatInt(max(rate.Limit-count, 0), 10))
+		if count > rate.Limit {
+			return custom.NewTooManyRequestsError("", ttl)
+		}
+		return next(c)
+	}
+}
+
+func clientIP(c echo.Context) string {
+	return "ip:" + c.RealIP()
+}
+
+func userOrIP(c echo.Context) string {
+	if uid, ok := GetUserID(c); ok {
+		return "user:" + uid
+	}
+	return clientIP(c)
+}
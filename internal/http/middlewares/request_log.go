@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"dungeons-dragon-service/internal/infrastructure/logging"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// maxRequestIDLength bounds ids taken from clients, they end up in every log
+// line of the request.
+const maxRequestIDLength = 128
+
+// RequestID keeps the X-Request-ID a client or proxy sent, or makes one up
+// when it is missing or unusable. The id is echoed in the response and added
+// to the logger of the request context.
+func RequestID(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Request().Header.Get(echo.HeaderXRequestID)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Response().Header().Set(echo.HeaderXRequestID, id)
+		c.SetRequest(c.Request().WithContext(logging.With(c.Request().Context(), "request_id", id)))
+		return next(c)
+	}
+}
+
+// validRequestID accepts printable ASCII without spaces, so ids cannot forge
+// log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestLogger adds the route to the logger of the request context and logs
+// every request once it is answered, with its status, latency and user. Use
+// after RequestID.
+func RequestLogger(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		req := c.Request()
+		ctx := logging.With(req.Context(), "method", req.Method, "route", c.Path())
+		c.SetRequest(req.WithContext(ctx))
+
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+
+		res := c.Response()
+		level := slog.LevelInfo
+		if res.Status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.Int("status", res.Status),
+			logging.Millis("latency_ms", time.Since(start)),
+			slog.String("uri", req.RequestURI),
+			slog.String("remote_ip", c.RealIP()),
+			slog.Int64("bytes_out", res.Size),
+		}
+		if uid, ok := GetUserID(c); ok {
+			attrs = append(attrs, slog.String("user_id", uid))
+		}
+		logging.FromContext(ctx).LogAttrs(ctx, level, "request", attrs...)
+		return nil
+	}
+}
@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequestTimeout gives every request a deadline. Queries still running when
+// it passes are cancelled and the request fails with 504. Multipart requests,
+// the image uploads, get the upload deadline instead, since receiving their
+// body alone can take longer than d. Zero disables a deadline.
+func RequestTimeout(d, upload time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			timeout := d
+			if isMultipart(req) {
+				timeout = upload
+			}
+			if timeout <= 0 {
+				return next(c)
+			}
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+			c.SetRequest(req.WithContext(ctx))
+			// a panic recovered further out must not look like a request
+			// cancelled by the deferred cancel above
+			defer c.SetRequest(req)
+			// errors are reported here, while a passed deadline can still be
+			// told apart from that cancel
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+			return nil
+		}
+	}
+}
+
+func isMultipart(req *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get(echo.HeaderContentType))
+	return mediaType == echo.MIMEMultipartForm
+}
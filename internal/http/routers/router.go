@@ -8,20 +8,26 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-func NewEchoRouter(e *echo.Echo, jwtMW *middleware.JWTMiddleware, auth usecase.AuthUseCase, opt usecase.OptionUseCase, ch usecase.CharacterUseCase, q usecase.QuestUseCase, img usecase.ImageUseCase) {
+func NewEchoRouter(e *echo.Echo, db handlers.Pinger, jwtMW *middleware.JWTMiddleware, rateMW *middleware.RateLimiter, auth usecase.AuthUseCase, account usecase.AccountUseCase, user usecase.UserUseCase, adminUser usecase.AdminUserUseCase, opt usecase.OptionUseCase, ch usecase.CharacterUseCase, q usecase.QuestUseCase, img usecase.ImageUseCase) {
 	apiV1 := e.Group("/api/v1")
 	apiV1.GET("/health", func(c echo.Context) error {
 		//message with emoji
 		return c.String(200, "still alive! 🧙‍♂️")
 	})
+	apiV1.GET("/ready", handlers.NewHealthHandler(db).Ready)
 
 	// Global JWT parser (non-blocking)
 	apiV1.Use(jwtMW.Parse)
 
 	// Auth
-	authH := handlers.NewAuthHandler(auth)
-	apiV1.POST("/auth/login", authH.Login)
-	apiV1.POST("/auth/register", authH.Register)
+	authH := handlers.NewAuthHandler(auth, account)
+	apiV1.POST("/auth/login", authH.Login, rateMW.Auth)
+	apiV1.POST("/auth/register", authH.Register, rateMW.Auth)
+	apiV1.POST("/auth/refresh", authH.Refresh, rateMW.Auth)
+	apiV1.POST("/auth/forgot-password", authH.ForgotPassword, rateMW.Auth)
+	apiV1.POST("/auth/reset-password", authH.ResetPassword, rateMW.Auth)
+	apiV1.GET("/auth/verify-email", authH.VerifyEmail, rateMW.Auth)
+	apiV1.POST("/auth/verify-email", authH.VerifyEmail, rateMW.Auth)
 
 	// Public and registered access
 	charH := handlers.NewCharacterHandler(ch)
@@ -29,8 +35,10 @@ func NewEchoRouter(e *echo.Echo, jwtMW *middleware.JWTMiddleware, auth usecase.A
 	optH := handlers.NewOptionHandler(opt)
 	imgH := handlers.NewImageHandler(img)
 
-	apiV1.GET("/characters", charH.List) // Public => public only, Registered => all
+	apiV1.GET("/characters", charH.List) // Public => public only, Registered => public + own, Admin => all
+	apiV1.GET("/characters/:id", charH.Get)
 	apiV1.GET("/quests", questH.List)
+	apiV1.GET("/quests/:id", questH.Get)
 
 	// Options are public for listing
 	apiV1.GET("/options/classes", optH.ListClasses)
@@ -39,18 +47,41 @@ func NewEchoRouter(e *echo.Echo, jwtMW *middleware.JWTMiddleware, auth usecase.A
 
 	apiV1.GET("/pictures/:filename", imgH.GetImage)
 
+	// Still open to users whose password reset was forced by an admin
+	userH := handlers.NewUserHandler(user)
+	gSession := apiV1.Group("", middleware.RequireAuthAllowingReset)
+	gSession.POST("/auth/logout", authH.Logout)
+	gSession.POST("/auth/logout-all", authH.LogoutAll)
+	gSession.GET("/me", userH.GetMe)
+	gSession.POST("/me/password", userH.ChangePassword)
+	gSession.POST("/auth/verify-email/resend", authH.ResendVerification, rateMW.Auth)
+
 	// Registered users can create/edit/delete their own
 	gAuth := apiV1.Group("", middleware.RequireAuth)
-	gAuth.POST("/characters", charH.Create)
-	gAuth.PUT("/characters/:id", charH.Update)
-	gAuth.DELETE("/characters/:id", charH.Delete)
+	gAuth.PATCH("/me", userH.UpdateMe)
+	gAuth.DELETE("/me", userH.DeleteMe)
+
+	gAuth.GET("/me/characters", charH.ListMine)
+	gAuth.GET("/me/quests", questH.ListMine)
 
-	gAuth.POST("/quests", questH.Create)
-	gAuth.PUT("/quests/:id", questH.Update)
-	gAuth.DELETE("/quests/:id", questH.Delete)
+	gAuth.POST("/characters", charH.Create, rateMW.Write, jwtMW.RequireVerifiedEmail)
+	gAuth.PUT("/characters/:id", charH.Update, rateMW.Write)
+	gAuth.DELETE("/characters/:id", charH.Delete, rateMW.Write)
 
-	gAuth.POST("/characters/:id/images", imgH.UploadCharacterImage)
-	gAuth.POST("/quests/:id/images", imgH.UploadQuestImage)
+	gAuth.POST("/quests", questH.Create, rateMW.Write, jwtMW.RequireVerifiedEmail)
+	gAuth.PUT("/quests/:id", questH.Update, rateMW.Write)
+	gAuth.DELETE("/quests/:id", questH.Delete, rateMW.Write)
+
+	gAuth.POST("/characters/:id/images", imgH.AddCharacterImages, rateMW.Upload)
+	gAuth.PUT("/characters/:id/images", imgH.ReplaceCharacterImages, rateMW.Upload)
+	gAuth.PUT("/characters/:id/images/order", imgH.ReorderCharacterImages, rateMW.Write)
+	gAuth.PUT("/characters/:id/images/:imageId/cover", imgH.SetCharacterCover, rateMW.Write)
+	gAuth.DELETE("/characters/:id/images/:imageId", imgH.DeleteCharacterImage, rateMW.Write)
+	gAuth.POST("/quests/:id/images", imgH.AddQuestImages, rateMW.Upload)
+	gAuth.PUT("/quests/:id/images", imgH.ReplaceQuestImages, rateMW.Upload)
+	gAuth.PUT("/quests/:id/images/order", imgH.ReorderQuestImages, rateMW.Write)
+	gAuth.PUT("/quests/:id/images/:imageId/cover", imgH.SetQuestCover, rateMW.Write)
+	gAuth.DELETE("/quests/:id/images/:imageId", imgH.DeleteQuestImage, rateMW.Write)
 
 	// Admin option management
 	gAdmin := apiV1.Group("/admin", middleware.RequireAuth, middleware.RequireAdmin)
@@ -65,4 +96,12 @@ func NewEchoRouter(e *echo.Echo, jwtMW *middleware.JWTMiddleware, auth usecase.A
 	gAdmin.POST("/options/quest-levels", optH.CreateQuestLevel)
 	gAdmin.PUT("/options/quest-levels/:id", optH.UpdateQuestLevel)
 	gAdmin.DELETE("/options/quest-levels/:id", optH.DeleteQuestLevel)
+
+	adminUserH := handlers.NewAdminUserHandler(adminUser)
+	gAdmin.GET("/users", adminUserH.List)
+	gAdmin.GET("/users/:id", adminUserH.Get)
+	gAdmin.PUT("/users/:id/role", adminUserH.UpdateRole)
+	gAdmin.POST("/users/:id/suspend", adminUserH.Suspend)
+	gAdmin.POST("/users/:id/unsuspend", adminUserH.Unsuspend)
+	gAdmin.POST("/users/:id/password-reset", adminUserH.ForcePasswordReset)
 }
@@ -4,11 +4,15 @@ import (
 	"context"
 	"dungeons-dragon-service/docs"
 	"dungeons-dragon-service/internal/config"
+	"dungeons-dragon-service/internal/domain/port"
+	"dungeons-dragon-service/internal/http/custom"
 	"dungeons-dragon-service/internal/http/handlers"
 	"dungeons-dragon-service/internal/http/middlewares"
 	router "dungeons-dragon-service/internal/http/routers"
 	"dungeons-dragon-service/internal/repositories"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -17,17 +21,23 @@ import (
 	"time"
 
 	database "dungeons-dragon-service/internal/infrastructure/db"
+	"dungeons-dragon-service/internal/infrastructure/jwt"
+	"dungeons-dragon-service/internal/infrastructure/logging"
+	"dungeons-dragon-service/internal/infrastructure/mailer"
+	"dungeons-dragon-service/internal/infrastructure/ratelimit"
+	"dungeons-dragon-service/internal/infrastructure/storage"
 
 	usecase "dungeons-dragon-service/internal/usecases"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
-	"github.com/labstack/gommon/log"
 )
 
 type echoServer struct {
 	app *echo.Echo
 	db  database.Database
+	// stopJobs cancels the background workers on shutdown
+	stopJobs context.CancelFunc
 }
 
 var (
@@ -38,7 +48,16 @@ var (
 func NewEchoServer(db database.Database) Server {
 	echoApp := echo.New()
 	echoApp.HideBanner = true
-	echoApp.Logger.SetLevel(log.DEBUG)
+	echoApp.HidePort = true
+	// handlers return errors, the response for them is written in one place
+	echoApp.HTTPErrorHandler = custom.HTTPErrorHandler
+	// client IPs feed the rate limiter, so only trust X-Forwarded-For
+	// behind a proxy
+	if config.GetConfigBool("TRUST_PROXY") {
+		echoApp.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		echoApp.IPExtractor = echo.ExtractIPDirect()
+	}
 
 	once.Do(func() {
 		app = &echoServer{
@@ -50,9 +69,17 @@ func NewEchoServer(db database.Database) Server {
 }
 
 func (s *echoServer) Start() {
-	s.app.Use(middleware.Recover())
-	s.app.Use(middleware.Logger())
+	// outermost, so panics recovered below are logged with their request
+	s.app.Use(middlewares.RequestID)
+	s.app.Use(middlewares.RequestLogger)
+	s.app.Use(middleware.RecoverWithConfig(middleware.RecoverConfig{
+		LogErrorFunc: func(c echo.Context, err error, stack []byte) error {
+			logging.FromContext(c.Request().Context()).Error("panic recovered", "error", err, "stack", string(stack))
+			return err
+		},
+	}))
 	s.app.Use(middleware.CORS())
+	s.app.Use(middlewares.RequestTimeout(config.GetConfigDuration("REQUEST_TIMEOUT"), config.GetConfigDuration("UPLOAD_TIMEOUT")))
 
 	s.initializeRouter()
 	s.httpListening()
@@ -62,9 +89,15 @@ func (s *echoServer) httpListening() {
 	// Start server in a goroutine
 	serverUrl := fmt.Sprintf(":%d", config.GetConfigInt("PORT"))
 
+	// request contexts derive from this one, so shutdown can cancel them
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	s.app.Server.BaseContext = func(net.Listener) context.Context { return baseCtx }
+
 	go func() {
+		slog.Info("server listening", "addr", serverUrl)
 		if err := s.app.Start(serverUrl); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("shutting down the server: %v", err)
+			logging.Fatal("shutting down the server", "error", err)
 		}
 	}()
 
@@ -72,12 +105,22 @@ func (s *echoServer) httpListening() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
-	log.Info("Shutting down server...")
+	slog.Info("shutting down server")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := s.app.Shutdown(ctx); err != nil {
-		log.Fatal(err)
+		// cancel the requests still running so their queries are aborted,
+		// and give them a moment to unwind
+		cancelRequests()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := s.app.Shutdown(ctx); err != nil {
+			logging.Fatal("server shutdown failed", "error", err)
+		}
+	}
+	if s.stopJobs != nil {
+		s.stopJobs()
 	}
 }
 
@@ -92,16 +135,47 @@ func (s *echoServer) initializeRouter() {
 	charRepo := repositories.NewCharacterRepo(gormDB)
 	questRepo := repositories.NewQuestRepo(gormDB)
 	imageRepo := repositories.NewImageRepo(gormDB)
+	refreshTokenRepo := repositories.NewRefreshTokenRepo(gormDB)
+	revokedTokenRepo := repositories.NewRevokedTokenRepo(gormDB)
+	userTokenRepo := repositories.NewUserTokenRepo(gormDB)
+	txManager := repositories.NewTxManager(gormDB)
+
+	jwtKeys, err := loadJWTKeys()
+	if err != nil {
+		logging.Fatal("failed to load JWT keys", "error", err)
+	}
+	if config.GetConfigString("IMAGE_URL_SECRET") == "" {
+		logging.Fatal("IMAGE_URL_SECRET is required to sign image URLs")
+	}
+	jwtManager := jwt.NewManager(jwtKeys, jwt.Options{
+		Issuer:   config.GetConfigString("JWT_ISSUER"),
+		Audience: config.GetConfigString("JWT_AUDIENCE"),
+		Leeway:   config.GetConfigDuration("JWT_LEEWAY"),
+	})
 
 	// Use cases
-	authUC := usecase.NewAuthUsecase(userRepo, config.GetConfigString("JWT_SECRET"))
-	optUC := usecase.NewOptionUseCase(classRepo, raceRepo, questLevelRepo, charRepo, questRepo)
+	accountUC := usecase.NewAccountUseCase(userRepo, userTokenRepo, refreshTokenRepo, newMailer())
+	counters := ratelimit.NewMemoryStore()
+	authUC := usecase.NewAuthUsecase(userRepo, refreshTokenRepo, revokedTokenRepo, jwtManager, accountUC, counters)
+	userUC := usecase.NewUserUseCase(userRepo, refreshTokenRepo, revokedTokenRepo, txManager)
+	adminUserUC := usecase.NewAdminUserUseCase(userRepo, refreshTokenRepo)
+	optUC := usecase.NewOptionUseCase(classRepo, raceRepo, questLevelRepo, txManager)
 	charUC := usecase.NewCharacterUsecase(charRepo, classRepo, raceRepo)
 	questUC := usecase.NewQuestUsecase(questRepo, questLevelRepo)
-	imageUC := usecase.NewImageUsecase(imageRepo, charRepo, questRepo)
+	blobStore, err := storage.NewFromConfig()
+	if err != nil {
+		logging.Fatal("failed to set up blob storage", "error", err)
+	}
+	variantWorker := usecase.NewVariantWorker(imageRepo, blobStore, config.GetConfigInt("IMAGE_WORKERS"))
+	imageUC := usecase.NewImageUsecase(imageRepo, charRepo, questRepo, blobStore, variantWorker)
 
 	// Middlewares
-	jwtMW := middlewares.NewJWTMiddleware(config.GetConfigString("JWT_SECRET"))
+	jwtMW := middlewares.NewJWTMiddleware(jwtManager, authUC, config.GetConfigBool("REQUIRE_VERIFIED_EMAIL"))
+	rateLimits, err := loadRateLimits()
+	if err != nil {
+		logging.Fatal("invalid rate limit", "error", err)
+	}
+	rateMW := middlewares.NewRateLimiter(counters, rateLimits)
 	// Swagger setup
 	docs.SwaggerInfo.Title = "Dungeon Dragon API Documentation"
 	docs.SwaggerInfo.Description = "API for managing D&D characters and quests."
@@ -124,6 +198,68 @@ func (s *echoServer) initializeRouter() {
 	// Serve RapiDoc UI
 	s.app.GET("/rapidoc", handlers.RapiDoc)
 
+	// Public keys for services verifying our access tokens
+	s.app.GET("/.well-known/jwks.json", handlers.NewJWKSHandler(jwtKeys).JWKS)
+
+	// Background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	s.stopJobs = stopJobs
+	go variantWorker.Run(jobsCtx)
+	if interval := config.GetConfigDuration("IMAGE_GC_INTERVAL"); interval > 0 {
+		gc := usecase.NewImageGC(imageRepo, blobStore)
+		go gc.Schedule(jobsCtx, interval, usecase.ImageGCOptions{
+			Apply: config.GetConfigBool("IMAGE_GC_APPLY"),
+			Grace: config.GetConfigDuration("IMAGE_GC_GRACE"),
+		})
+	}
+
 	// Routes
-	router.NewEchoRouter(s.app, jwtMW, authUC, optUC, charUC, questUC, imageUC)
+	router.NewEchoRouter(s.app, s.db, jwtMW, rateMW, authUC, accountUC, userUC, adminUserUC, optUC, charUC, questUC, imageUC)
+}
+
+// loadJWTKeys builds the signing key set from config. HS256 uses JWT_SECRET;
+// RS256 and EdDSA load PEM keys from JWT_KEYS_DIR and sign with JWT_ACTIVE_KID.
+func loadJWTKeys() (*jwt.KeySet, error) {
+	alg := config.GetConfigString("JWT_SIGNING_ALG")
+	if alg == "HS256" {
+		return jwt.NewHMACKeySet(config.GetConfigString("JWT_SECRET")), nil
+	}
+	return jwt.LoadKeySet(alg, config.GetConfigString("JWT_KEYS_DIR"), config.GetConfigString("JWT_ACTIVE_KID"))
+}
+
+// newMailer picks the mail adapter: "smtp" sends through SMTP_HOST, "log"
+// writes mails to MAILER_FILE or, if unset, to stdout.
+func newMailer() port.Mailer {
+	if config.GetConfigString("MAILER") == "smtp" {
+		return mailer.NewSMTPMailer(
+			config.GetConfigString("SMTP_HOST"),
+			config.GetConfigInt("SMTP_PORT"),
+			config.GetConfigString("SMTP_USERNAME"),
+			config.GetConfigString("SMTP_PASSWORD"),
+			config.GetConfigString("MAIL_FROM"),
+		)
+	}
+	if path := config.GetConfigString("MAILER_FILE"); path != "" {
+		m, err := mailer.NewFileMailer(path)
+		if err != nil {
+			logging.Fatal("failed to open mailer file", "error", err)
+		}
+		return m
+	}
+	return mailer.NewLogMailer(os.Stdout)
+}
+
+func loadRateLimits() (middlewares.RateLimits, error) {
+	var limits middlewares.RateLimits
+	var err error
+	if limits.Auth, err = middlewares.ParseRate(config.GetConfigString("RATE_LIMIT_AUTH")); err != nil {
+		return limits, err
+	}
+	if limits.Write, err = middlewares.ParseRate(config.GetConfigString("RATE_LIMIT_WRITE")); err != nil {
+		return limits, err
+	}
+	if limits.Upload, err = middlewares.ParseRate(config.GetConfigString("RATE_LIMIT_UPLOAD")); err != nil {
+		return limits, err
+	}
+	return limits, nil
 }
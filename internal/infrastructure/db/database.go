@@ -1,7 +1,13 @@
 package database
 
-import "gorm.io/gorm"
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
 
 type Database interface {
 	ConnectDB() *gorm.DB
+	// Ping checks the database answers, for readiness probes.
+	Ping(ctx context.Context) error
 }
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/infrastructure/logging"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// gormLogger sends GORM's logs to the logger of the query's context, so a
+// query carries the request id of the request that ran it. Failed queries are
+// errors, queries slower than slow are warnings and, at debug level, every
+// query is logged.
+type gormLogger struct {
+	slow  time.Duration
+	level gormlogger.LogLevel
+}
+
+func newGormLogger(slow time.Duration) *gormLogger {
+	return &gormLogger{slow: slow, level: gormlogger.Info}
+}
+
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	c := *l
+	c.level = level
+	return &c
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, gormlogger.Info, slog.LevelInfo, msg, args)
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, gormlogger.Warn, slog.LevelWarn, msg, args)
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, gormlogger.Error, slog.LevelError, msg, args)
+}
+
+func (l *gormLogger) log(ctx context.Context, from gormlogger.LogLevel, level slog.Level, msg string, args []any) {
+	if l.level >= from {
+		logging.FromContext(ctx).Log(ctx, level, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	logger := logging.FromContext(ctx)
+	switch {
+	// a missing row is an answer, and a cancelled request is not the
+	// database's fault
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, context.Canceled):
+		if l.level >= gormlogger.Error {
+			sql, rows := fc()
+			logger.LogAttrs(ctx, slog.LevelError, "query failed", slog.String("error", err.Error()),
+				slog.String("sql", sql), slog.Int64("rows", rows), logging.Millis("elapsed_ms", elapsed))
+		}
+	case l.slow > 0 && elapsed > l.slow:
+		if l.level >= gormlogger.Warn {
+			sql, rows := fc()
+			logger.LogAttrs(ctx, slog.LevelWarn, "slow query", slog.String("sql", sql), slog.Int64("rows", rows),
+				logging.Millis("elapsed_ms", elapsed), logging.Millis("threshold_ms", l.slow))
+		}
+	case logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		logger.LogAttrs(ctx, slog.LevelDebug, "query", slog.String("sql", sql), slog.Int64("rows", rows),
+			logging.Millis("elapsed_ms", elapsed))
+	}
+}
+
+// ParamsFilter keeps the values of a query out of the logs, they may be
+// password hashes or tokens.
+func (l *gormLogger) ParamsFilter(_ context.Context, sql string, _ ...any) (string, []any) {
+	return sql, nil
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"dungeons-dragon-service/internal/infrastructure/logging"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestGormLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := logging.New(&buf, "info")
+	require.NoError(t, err)
+	ctx := logging.With(logging.WithLogger(t.Context(), l), "request_id", "req-1")
+	query := func() (string, int64) { return `SELECT * FROM "users" WHERE id = $1`, 1 }
+	gl := newGormLogger(200 * time.Millisecond)
+
+	// fast and successful, or nothing worth reporting
+	gl.Trace(ctx, time.Now(), query, nil)
+	gl.Trace(ctx, time.Now(), query, gorm.ErrRecordNotFound)
+	gl.Trace(ctx, time.Now(), query, context.Canceled)
+	require.Empty(t, buf.String())
+
+	gl.Trace(ctx, time.Now().Add(-time.Second), query, nil)
+	require.Contains(t, buf.String(), `"msg":"slow query"`)
+	require.Contains(t, buf.String(), `"request_id":"req-1"`)
+	require.Contains(t, buf.String(), `"threshold_ms":200`)
+
+	buf.Reset()
+	gl.Trace(ctx, time.Now(), query, errors.New("relation does not exist"))
+	require.Contains(t, buf.String(), `"level":"ERROR","msg":"query failed"`)
+
+	// debug logs every query, silent none
+	debug, err := logging.New(&buf, "debug")
+	require.NoError(t, err)
+	buf.Reset()
+	gl.Trace(logging.WithLogger(t.Context(), debug), time.Now(), query, nil)
+	require.Contains(t, buf.String(), `"msg":"query"`)
+	buf.Reset()
+	gl.LogMode(gormlogger.Silent).Trace(ctx, time.Now(), query, errors.New("boom"))
+	require.Empty(t, buf.String())
+
+	sql, vars := gl.ParamsFilter(ctx, "SELECT 1 WHERE password_hash = $1", "secret")
+	require.Equal(t, "SELECT 1 WHERE password_hash = $1", sql)
+	require.Nil(t, vars)
+}
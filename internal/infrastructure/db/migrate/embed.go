@@ -0,0 +1,28 @@
+package migrate
+
+import (
+	"database/sql"
+	"embed"
+	"io/fs"
+)
+
+//go:embed sql/*.sql
+var embedded embed.FS
+
+// Embedded returns the migrations compiled into the binary.
+func Embedded() ([]Migration, error) {
+	sub, err := fs.Sub(embedded, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub)
+}
+
+// NewEmbedded is a Migrator for the migrations compiled into the binary.
+func NewEmbedded(db *sql.DB) (*Migrator, error) {
+	migrations, err := Embedded()
+	if err != nil {
+		return nil, err
+	}
+	return New(db, migrations), nil
+}
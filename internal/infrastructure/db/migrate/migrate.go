@@ -0,0 +1,330 @@
+// Package migrate applies numbered SQL migrations and records them in the
+// schema_migrations table.
+//
+// Migrations are pairs of files named <version>_<name>.up.sql and
+// <version>_<name>.down.sql; the down file is optional. A migration runs in a
+// transaction unless its up or down file starts with the line
+// "-- migrate:no-transaction" (needed for CREATE INDEX CONCURRENTLY). Every
+// run holds a Postgres advisory lock, so replicas starting at the same time
+// apply each migration once.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockID identifies the advisory lock taken while migrating.
+const lockID int64 = 0x64645f6d696772 // "dd_migr"
+
+const noTransaction = "-- migrate:no-transaction"
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration was changed")
+	ErrNoDownMigration  = errors.New("migration has no down file")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// Checksum is the SHA-256 of Up. A changed file is refused once the
+	// migration was applied.
+	Checksum string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Status is a migration together with when it was applied. Applied
+// migrations without a file (a newer binary ran them) have an empty Name.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+	// Changed is set when the file no longer matches what was applied.
+	Changed bool
+}
+
+// Load reads the migrations in the root of fsys, ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+		parts := fileName.FindStringSubmatch(e.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("migration %s: name must look like 0001_create_users.up.sql", e.Name())
+		}
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version", e.Name())
+		}
+		data, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = m
+		} else if m.Name != parts[2] {
+			return nil, fmt.Errorf("migration %s: version %d is also used by %s", e.Name(), version, m.Name)
+		}
+		if parts[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %s: up file is missing or empty", m)
+		}
+		sum := sha256.Sum256([]byte(m.Up))
+		m.Checksum = hex.EncodeToString(sum[:])
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// appliedMigration is a row of schema_migrations.
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// pending returns the migrations not applied yet. It fails if an applied
+// migration's file was edited since.
+func pending(migrations []Migration, applied map[int64]appliedMigration) ([]Migration, error) {
+	var list []Migration
+	for _, m := range migrations {
+		a, ok := applied[m.Version]
+		if !ok {
+			list = append(list, m)
+			continue
+		}
+		if a.Checksum != m.Checksum {
+			return nil, fmt.Errorf("%w: %s", ErrChecksumMismatch, m)
+		}
+	}
+	return list, nil
+}
+
+// Migrator runs migrations against a Postgres database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func New(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Up applies pending migrations in order, at most n of them if n > 0.
+func (m *Migrator) Up(ctx context.Context, n int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int64]appliedMigration) error {
+		todo, err := pending(m.migrations, applied)
+		if err != nil {
+			return err
+		}
+		if n > 0 && len(todo) > n {
+			todo = todo[:n]
+		}
+		for _, mig := range todo {
+			if err := apply(ctx, conn, mig.Up, func(ex execer) error {
+				_, err := ex.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+					mig.Version, mig.Name, mig.Checksum)
+				return err
+			}); err != nil {
+				return fmt.Errorf("migration %s: %w", mig, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the last n applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int64]appliedMigration) error {
+		files := map[int64]Migration{}
+		for _, mig := range m.migrations {
+			files[mig.Version] = mig
+		}
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+		if n > 0 && len(versions) > n {
+			versions = versions[:n]
+		}
+		for _, v := range versions {
+			mig, ok := files[v]
+			if !ok || strings.TrimSpace(mig.Down) == "" {
+				return fmt.Errorf("%w: %04d_%s", ErrNoDownMigration, v, applied[v].Name)
+			}
+			if err := apply(ctx, conn, mig.Down, func(ex execer) error {
+				_, err := ex.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, v)
+				return err
+			}); err != nil {
+				return fmt.Errorf("rolling back %s: %w", mig, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Redo rolls back the last applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	done, err := m.Down(ctx, 1)
+	if err != nil || len(done) == 0 {
+		return nil, err
+	}
+	if _, err := m.Up(ctx, 1); err != nil {
+		return nil, err
+	}
+	return &done[0], nil
+}
+
+// Status lists known and applied migrations by version.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var list []Status
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int64]appliedMigration) error {
+		seen := map[int64]bool{}
+		for _, mig := range m.migrations {
+			s := Status{Migration: mig}
+			if a, ok := applied[mig.Version]; ok {
+				s.AppliedAt = &a.AppliedAt
+				s.Changed = a.Checksum != mig.Checksum
+			}
+			seen[mig.Version] = true
+			list = append(list, s)
+		}
+		for v, a := range applied {
+			if !seen[v] {
+				appliedAt := a.AppliedAt
+				list = append(list, Status{Migration: Migration{Version: v, Checksum: a.Checksum}, AppliedAt: &appliedAt})
+			}
+		}
+		return nil
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, err
+}
+
+// locked runs fn on one connection holding the advisory lock, after making
+// sure schema_migrations exists.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, applied map[int64]appliedMigration) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// DB_STATEMENT_TIMEOUT guards requests, not schema changes: waiting for
+	// the lock or rewriting a large table may legitimately take longer. The
+	// connection goes back to the pool afterwards, so the default is restored.
+	if _, err := conn.ExecContext(ctx, `SET statement_timeout = 0`); err != nil {
+		return fmt.Errorf("disable statement timeout: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `RESET statement_timeout`)
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		// a fresh context: the lock must go even if ctx was cancelled
+		if _, unlockErr := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID); unlockErr != nil && err == nil {
+			err = fmt.Errorf("release migration lock: %w", unlockErr)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint PRIMARY KEY,
+		name       text NOT NULL,
+		checksum   text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	applied, err := loadApplied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, applied)
+}
+
+func loadApplied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+	applied := map[int64]appliedMigration{}
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[a.Version] = a
+	}
+	return applied, rows.Err()
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// apply runs script and record, together in a transaction unless the script
+// opts out.
+func apply(ctx context.Context, conn *sql.Conn, script string, record func(execer) error) error {
+	if strings.HasPrefix(strings.TrimSpace(script), noTransaction) {
+		if _, err := conn.ExecContext(ctx, script); err != nil {
+			return err
+		}
+		return record(conn)
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
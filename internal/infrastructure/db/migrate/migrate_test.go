@@ -0,0 +1,80 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":   {Data: []byte("CREATE INDEX i ON t (c);")},
+		"0001_create_t.up.sql":    {Data: []byte("CREATE TABLE t (c int);")},
+		"0001_create_t.down.sql":  {Data: []byte("DROP TABLE t;")},
+		"README.md":               {Data: []byte("not a migration")},
+		"0010_later_one.up.sql":   {Data: []byte("SELECT 1;")},
+		"0010_later_one.down.sql": {Data: []byte("SELECT 1;")},
+		"0002_add_index.down.sql": {Data: []byte("DROP INDEX i;")},
+	}
+	list, err := Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	require.Equal(t, "0001_create_t", list[0].String())
+	require.Equal(t, "DROP TABLE t;", list[0].Down)
+	require.Equal(t, int64(2), list[1].Version)
+	require.Equal(t, int64(10), list[2].Version)
+	require.Len(t, list[0].Checksum, 64)
+	require.NotEqual(t, list[0].Checksum, list[1].Checksum)
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"bad name":      {"1-create.up.sql": {Data: []byte("x")}},
+		"missing up":    {"0001_a.down.sql": {Data: []byte("x")}},
+		"empty up":      {"0001_a.up.sql": {Data: []byte("  \n")}},
+		"version clash": {"0001_a.up.sql": {Data: []byte("x")}, "0001_b.up.sql": {Data: []byte("y")}},
+	} {
+		_, err := Load(fsys)
+		require.Error(t, err, name)
+	}
+}
+
+func TestPending(t *testing.T) {
+	list, err := Load(fstest.MapFS{
+		"0001_a.up.sql": {Data: []byte("SELECT 1;")},
+		"0002_b.up.sql": {Data: []byte("SELECT 2;")},
+		"0003_c.up.sql": {Data: []byte("SELECT 3;")},
+	})
+	require.NoError(t, err)
+
+	todo, err := pending(list, map[int64]appliedMigration{})
+	require.NoError(t, err)
+	require.Len(t, todo, 3)
+
+	// versions applied out of order are not repeated
+	todo, err = pending(list, map[int64]appliedMigration{
+		1: {Version: 1, Checksum: list[0].Checksum},
+		3: {Version: 3, Checksum: list[2].Checksum},
+		// applied by a newer release, unknown here
+		9: {Version: 9, Checksum: "x"},
+	})
+	require.NoError(t, err)
+	require.Len(t, todo, 1)
+	require.Equal(t, int64(2), todo[0].Version)
+
+	_, err = pending(list, map[int64]appliedMigration{1: {Version: 1, Checksum: "edited"}})
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+func TestEmbedded(t *testing.T) {
+	list, err := Embedded()
+	require.NoError(t, err)
+	require.NotEmpty(t, list)
+	// the baseline must match the AutoMigrate schema; later columns belong in their own migrations
+	require.NotContains(t, list[0].Up, "variant_status")
+	for i, m := range list {
+		require.NotEmpty(t, m.Down, m.String())
+		if i > 0 {
+			require.Greater(t, m.Version, list[i-1].Version)
+		}
+	}
+}
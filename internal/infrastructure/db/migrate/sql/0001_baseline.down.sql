@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS quest_images;
+DROP TABLE IF EXISTS character_images;
+DROP TABLE IF EXISTS quests;
+DROP TABLE IF EXISTS characters;
+DROP TABLE IF EXISTS quest_levels;
+DROP TABLE IF EXISTS races;
+DROP TABLE IF EXISTS classes;
+DROP TABLE IF EXISTS users;
+
+DROP TYPE IF EXISTS item_status;
+DROP TYPE IF EXISTS privacy;
+DROP TYPE IF EXISTS user_role;
@@ -0,0 +1,121 @@
+-- Baseline schema: the tables the former AutoMigrate step created. It is
+-- guarded with IF NOT EXISTS, so such databases adopt it as they are; every
+-- later change is a migration of its own.
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'user_role') THEN
+		CREATE TYPE user_role AS ENUM ('user', 'admin');
+	END IF;
+	IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'privacy') THEN
+		CREATE TYPE privacy AS ENUM ('public', 'private');
+	END IF;
+	IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'item_status') THEN
+		CREATE TYPE item_status AS ENUM ('active', 'archived');
+	END IF;
+END$$;
+
+CREATE TABLE IF NOT EXISTS users (
+	id            uuid DEFAULT gen_random_uuid(),
+	created_at    timestamptz NOT NULL,
+	updated_at    timestamptz NOT NULL,
+	deleted_at    timestamptz,
+	username      varchar(64) NOT NULL,
+	email         varchar(128) NOT NULL,
+	password_hash varchar(255) NOT NULL,
+	role          user_role NOT NULL DEFAULT 'user',
+	PRIMARY KEY (id),
+	CONSTRAINT uni_users_username UNIQUE (username),
+	CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE TABLE IF NOT EXISTS classes (
+	id         uuid DEFAULT gen_random_uuid(),
+	created_at timestamptz NOT NULL,
+	updated_at timestamptz NOT NULL,
+	deleted_at timestamptz,
+	name       varchar(128) NOT NULL,
+	is_deleted boolean NOT NULL DEFAULT false,
+	PRIMARY KEY (id),
+	CONSTRAINT uni_classes_name UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS races (
+	id         uuid DEFAULT gen_random_uuid(),
+	created_at timestamptz NOT NULL,
+	updated_at timestamptz NOT NULL,
+	deleted_at timestamptz,
+	name       varchar(128) NOT NULL,
+	is_deleted boolean NOT NULL DEFAULT false,
+	PRIMARY KEY (id),
+	CONSTRAINT uni_races_name UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS quest_levels (
+	id         uuid DEFAULT gen_random_uuid(),
+	created_at timestamptz NOT NULL,
+	updated_at timestamptz NOT NULL,
+	deleted_at timestamptz,
+	name       varchar(128) NOT NULL,
+	is_deleted boolean NOT NULL DEFAULT false,
+	PRIMARY KEY (id),
+	CONSTRAINT uni_quest_levels_name UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS characters (
+	id          uuid DEFAULT gen_random_uuid(),
+	created_at  timestamptz NOT NULL,
+	updated_at  timestamptz NOT NULL,
+	deleted_at  timestamptz,
+	user_id     uuid NOT NULL,
+	title       varchar(128) NOT NULL,
+	description text NOT NULL,
+	class_id    uuid NOT NULL,
+	race_id     uuid NOT NULL,
+	image_path  jsonb DEFAULT '[]'::jsonb,
+	privacy     privacy NOT NULL DEFAULT 'public',
+	status      item_status NOT NULL DEFAULT 'active',
+	PRIMARY KEY (id),
+	CONSTRAINT fk_characters_class FOREIGN KEY (class_id) REFERENCES classes (id),
+	CONSTRAINT fk_characters_race FOREIGN KEY (race_id) REFERENCES races (id),
+	CONSTRAINT fk_characters_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS quests (
+	id             uuid DEFAULT gen_random_uuid(),
+	created_at     timestamptz NOT NULL,
+	updated_at     timestamptz NOT NULL,
+	deleted_at     timestamptz,
+	user_id        uuid NOT NULL,
+	title          varchar(128) NOT NULL,
+	description    text NOT NULL,
+	quest_level_id uuid NOT NULL,
+	image_path     jsonb DEFAULT '[]'::jsonb,
+	privacy        privacy NOT NULL DEFAULT 'public',
+	status         item_status NOT NULL DEFAULT 'active',
+	PRIMARY KEY (id),
+	CONSTRAINT fk_quests_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT fk_quests_quest_level FOREIGN KEY (quest_level_id) REFERENCES quest_levels (id)
+);
+
+CREATE TABLE IF NOT EXISTS character_images (
+	id           uuid DEFAULT gen_random_uuid(),
+	created_at   timestamptz NOT NULL,
+	updated_at   timestamptz NOT NULL,
+	deleted_at   timestamptz,
+	character_id uuid NOT NULL,
+	path         text NOT NULL,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_characters_images FOREIGN KEY (character_id) REFERENCES characters (id)
+);
+
+CREATE TABLE IF NOT EXISTS quest_images (
+	id         uuid DEFAULT gen_random_uuid(),
+	created_at timestamptz NOT NULL,
+	updated_at timestamptz NOT NULL,
+	deleted_at timestamptz,
+	quest_id   uuid NOT NULL,
+	path       text NOT NULL,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_quests_images FOREIGN KEY (quest_id) REFERENCES quests (id)
+);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id             uuid DEFAULT gen_random_uuid(),
+	created_at     timestamptz NOT NULL,
+	updated_at     timestamptz NOT NULL,
+	deleted_at     timestamptz,
+	user_id        uuid NOT NULL,
+	family_id      uuid NOT NULL,
+	token_hash     varchar(64) NOT NULL,
+	expires_at     timestamptz NOT NULL,
+	revoked_at     timestamptz,
+	replaced_by_id uuid,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_refresh_tokens_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT uni_refresh_tokens_token_hash UNIQUE (token_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	jti        varchar(64),
+	expires_at timestamptz NOT NULL,
+	created_at timestamptz NOT NULL,
+	PRIMARY KEY (jti)
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
@@ -0,0 +1,17 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+	id         uuid DEFAULT gen_random_uuid(),
+	created_at timestamptz NOT NULL,
+	updated_at timestamptz NOT NULL,
+	deleted_at timestamptz,
+	user_id    uuid NOT NULL,
+	purpose    varchar(32) NOT NULL,
+	token_hash varchar(64) NOT NULL,
+	expires_at timestamptz NOT NULL,
+	used_at    timestamptz,
+	PRIMARY KEY (id),
+	CONSTRAINT fk_user_tokens_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT uni_user_tokens_token_hash UNIQUE (token_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON user_tokens (user_id);
@@ -0,0 +1,13 @@
+ALTER TABLE quest_images
+	DROP COLUMN IF EXISTS height,
+	DROP COLUMN IF EXISTS width,
+	DROP COLUMN IF EXISTS size,
+	DROP COLUMN IF EXISTS content_type,
+	DROP COLUMN IF EXISTS original_name;
+
+ALTER TABLE character_images
+	DROP COLUMN IF EXISTS height,
+	DROP COLUMN IF EXISTS width,
+	DROP COLUMN IF EXISTS size,
+	DROP COLUMN IF EXISTS content_type,
+	DROP COLUMN IF EXISTS original_name;
@@ -0,0 +1,13 @@
+ALTER TABLE character_images
+	ADD COLUMN IF NOT EXISTS original_name varchar(255),
+	ADD COLUMN IF NOT EXISTS content_type  varchar(32),
+	ADD COLUMN IF NOT EXISTS size          bigint,
+	ADD COLUMN IF NOT EXISTS width         bigint,
+	ADD COLUMN IF NOT EXISTS height        bigint;
+
+ALTER TABLE quest_images
+	ADD COLUMN IF NOT EXISTS original_name varchar(255),
+	ADD COLUMN IF NOT EXISTS content_type  varchar(32),
+	ADD COLUMN IF NOT EXISTS size          bigint,
+	ADD COLUMN IF NOT EXISTS width         bigint,
+	ADD COLUMN IF NOT EXISTS height        bigint;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_quest_images_quest_id;
+ALTER TABLE quest_images
+	DROP COLUMN IF EXISTS is_cover,
+	DROP COLUMN IF EXISTS position;
+
+DROP INDEX IF EXISTS idx_character_images_character_id;
+ALTER TABLE character_images
+	DROP COLUMN IF EXISTS is_cover,
+	DROP COLUMN IF EXISTS position;
@@ -0,0 +1,9 @@
+ALTER TABLE character_images
+	ADD COLUMN IF NOT EXISTS position bigint NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS is_cover boolean NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS idx_character_images_character_id ON character_images (character_id);
+
+ALTER TABLE quest_images
+	ADD COLUMN IF NOT EXISTS position bigint NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS is_cover boolean NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS idx_quest_images_quest_id ON quest_images (quest_id);
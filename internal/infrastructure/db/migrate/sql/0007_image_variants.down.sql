@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_quest_images_variant_status;
+ALTER TABLE quest_images
+	DROP COLUMN IF EXISTS variants,
+	DROP COLUMN IF EXISTS variant_status;
+
+DROP INDEX IF EXISTS idx_character_images_variant_status;
+ALTER TABLE character_images
+	DROP COLUMN IF EXISTS variants,
+	DROP COLUMN IF EXISTS variant_status;
@@ -0,0 +1,10 @@
+-- existing images start out pending, so the worker makes their variants
+ALTER TABLE character_images
+	ADD COLUMN IF NOT EXISTS variant_status varchar(16) NOT NULL DEFAULT 'pending',
+	ADD COLUMN IF NOT EXISTS variants       jsonb;
+CREATE INDEX IF NOT EXISTS idx_character_images_variant_status ON character_images (variant_status);
+
+ALTER TABLE quest_images
+	ADD COLUMN IF NOT EXISTS variant_status varchar(16) NOT NULL DEFAULT 'pending',
+	ADD COLUMN IF NOT EXISTS variants       jsonb;
+CREATE INDEX IF NOT EXISTS idx_quest_images_variant_status ON quest_images (variant_status);
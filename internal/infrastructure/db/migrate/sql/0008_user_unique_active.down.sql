@@ -0,0 +1,5 @@
+-- fails while a deleted account shares its username or email with a live one
+DROP INDEX IF EXISTS idx_users_email_active;
+DROP INDEX IF EXISTS idx_users_username_active;
+ALTER TABLE users ADD CONSTRAINT uni_users_username UNIQUE (username);
+ALTER TABLE users ADD CONSTRAINT uni_users_email UNIQUE (email);
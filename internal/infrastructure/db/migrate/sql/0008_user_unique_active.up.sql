@@ -0,0 +1,6 @@
+-- usernames and emails only have to be unique among accounts that are not
+-- deleted, so a deleted account does not block registering them again
+ALTER TABLE users DROP CONSTRAINT IF EXISTS uni_users_username;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS uni_users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_active ON users (username) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_active ON users (email) WHERE deleted_at IS NULL;
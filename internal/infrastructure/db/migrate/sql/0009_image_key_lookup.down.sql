@@ -0,0 +1,7 @@
+-- the original directories of legacy paths are not restored; the service
+-- only ever used their base name
+DROP INDEX IF EXISTS idx_quest_images_variants;
+DROP INDEX IF EXISTS idx_quest_images_path;
+
+DROP INDEX IF EXISTS idx_character_images_variants;
+DROP INDEX IF EXISTS idx_character_images_path;
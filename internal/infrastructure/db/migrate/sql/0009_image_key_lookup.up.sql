@@ -0,0 +1,10 @@
+-- images are looked up by blob key on every download: rows from before the
+-- blob store hold the full file path, so keep only its base name, which is
+-- the key, and index the columns the lookup reads
+UPDATE character_images SET path = regexp_replace(path, '^.*[/\\]', '') WHERE path ~ '[/\\]';
+CREATE INDEX IF NOT EXISTS idx_character_images_path ON character_images (path);
+CREATE INDEX IF NOT EXISTS idx_character_images_variants ON character_images USING gin (variants jsonb_path_ops);
+
+UPDATE quest_images SET path = regexp_replace(path, '^.*[/\\]', '') WHERE path ~ '[/\\]';
+CREATE INDEX IF NOT EXISTS idx_quest_images_path ON quest_images (path);
+CREATE INDEX IF NOT EXISTS idx_quest_images_variants ON quest_images USING gin (variants jsonb_path_ops);
@@ -1,9 +1,12 @@
 package database
 
 import (
+	"context"
 	"dungeons-dragon-service/internal/config"
 	"fmt"
+	"log/slog"
 	"sync"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -16,12 +19,23 @@ type postgresDatabase struct {
 var (
 	once       sync.Once
 	dbInstance *postgresDatabase
+	dbErr      error
 )
 
-func NewPostgresDatabase() Database {
+// PoolOptions are the limits of the connection pool.
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// NewPostgresDatabase connects once per process. Failed attempts are retried
+// with backoff until DB_CONNECT_RETRY_WINDOW has been spent waiting, so the
+// service can start before postgres is up; then the last error is returned.
+func NewPostgresDatabase() (Database, error) {
 	once.Do(func() {
-		dsn := fmt.Sprintf(
-			"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
+		dsn := buildDSN(
 			config.GetConfigString("DB_HOST"),
 			config.GetConfigString("DB_USER"),
 			config.GetConfigString("DB_PASSWORD"),
@@ -29,22 +43,112 @@ func NewPostgresDatabase() Database {
 			config.GetConfigInt("DB_PORT"),
 			config.GetConfigString("DB_SSLMODE"),
 			config.GetConfigString("DB_TIMEZONE"),
+			config.GetConfigDuration("DB_CONNECT_TIMEOUT"),
+			config.GetConfigDuration("DB_STATEMENT_TIMEOUT"),
 		)
 
-		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		db, err := retry(config.GetConfigDuration("DB_CONNECT_RETRY_WINDOW"), time.Sleep, func() (*gorm.DB, error) {
+			return gorm.Open(postgres.Open(dsn), &gorm.Config{
+				Logger: newGormLogger(config.GetConfigDuration("DB_SLOW_QUERY_THRESHOLD")),
+			})
+		})
 		if err != nil {
-			panic("failed to connect database")
+			dbErr = fmt.Errorf("connect to database: %w", err)
+			return
+		}
+		if err := configurePool(db, PoolOptions{
+			MaxOpenConns:    config.GetConfigInt("DB_MAX_OPEN_CONNS"),
+			MaxIdleConns:    config.GetConfigInt("DB_MAX_IDLE_CONNS"),
+			ConnMaxLifetime: config.GetConfigDuration("DB_CONN_MAX_LIFETIME"),
+			ConnMaxIdleTime: config.GetConfigDuration("DB_CONN_MAX_IDLE_TIME"),
+		}); err != nil {
+			dbErr = err
+			return
 		}
 
-		//log info about successful connection
-		fmt.Println("⚔️  Successfully connected to the database ⚔️ ")
+		slog.Info("connected to database", "host", config.GetConfigString("DB_HOST"), "name", config.GetConfigString("DB_NAME"))
 
 		dbInstance = &postgresDatabase{Db: db}
 	})
-
-	return dbInstance
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	return dbInstance, nil
 }
 
 func (p *postgresDatabase) ConnectDB() *gorm.DB {
 	return p.Db
 }
+
+func (p *postgresDatabase) Ping(ctx context.Context) error {
+	sqlDB, err := p.Db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// buildDSN adds the timeouts to the connection string. connect_timeout limits
+// a single connection attempt, statement_timeout is set on every session so a
+// runaway query cannot hold a pooled connection forever. Zero leaves either
+// unset.
+func buildDSN(host, user, password, name string, port int, sslmode, timezone string, connectTimeout, statementTimeout time.Duration) string {
+	dsn := fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
+		host, user, password, name, port, sslmode, timezone,
+	)
+	if connectTimeout > 0 {
+		// postgres counts whole seconds
+		dsn += fmt.Sprintf(" connect_timeout=%d", int((connectTimeout+time.Second-1)/time.Second))
+	}
+	if statementTimeout > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", statementTimeout.Milliseconds())
+	}
+	return dsn
+}
+
+func configurePool(db *gorm.DB, opts PoolOptions) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(opts.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(opts.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	return nil
+}
+
+const (
+	firstRetryDelay = 500 * time.Millisecond
+	maxRetryDelay   = 5 * time.Second
+)
+
+// backoff is the delay before retry attempt n (starting at 1): it doubles
+// from firstRetryDelay up to maxRetryDelay.
+func backoff(n int) time.Duration {
+	d := firstRetryDelay
+	for i := 1; i < n && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryDelay)
+}
+
+// retry calls open until it succeeds or the delays between attempts add up to
+// window. Every failure is logged with its cause.
+func retry(window time.Duration, sleep func(time.Duration), open func() (*gorm.DB, error)) (*gorm.DB, error) {
+	var waited time.Duration
+	for attempt := 1; ; attempt++ {
+		db, err := open()
+		if err == nil {
+			return db, nil
+		}
+		if waited >= window {
+			return nil, err
+		}
+		delay := min(backoff(attempt), window-waited)
+		slog.Warn("database not reachable", "attempt", attempt, "error", err, "retry_in", delay.String())
+		sleep(delay)
+		waited += delay
+	}
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestBuildDSN(t *testing.T) {
+	dsn := buildDSN("db", "u", "p", "dd", 5432, "disable", "UTC", 1500*time.Millisecond, 30*time.Second)
+	require.Equal(t, "host=db user=u password=p dbname=dd port=5432 sslmode=disable TimeZone=UTC connect_timeout=2 statement_timeout=30000", dsn)
+
+	dsn = buildDSN("db", "u", "p", "dd", 5432, "disable", "UTC", 0, 0)
+	require.Equal(t, "host=db user=u password=p dbname=dd port=5432 sslmode=disable TimeZone=UTC", dsn)
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, 500*time.Millisecond, backoff(1))
+	require.Equal(t, time.Second, backoff(2))
+	require.Equal(t, 4*time.Second, backoff(4))
+	require.Equal(t, maxRetryDelay, backoff(5))
+	require.Equal(t, maxRetryDelay, backoff(50))
+}
+
+func TestRetry(t *testing.T) {
+	refused := errors.New("connection refused")
+
+	t.Run("until postgres is up", func(t *testing.T) {
+		var slept []time.Duration
+		calls := 0
+		db, err := retry(time.Minute, func(d time.Duration) { slept = append(slept, d) }, func() (*gorm.DB, error) {
+			if calls++; calls < 4 {
+				return nil, refused
+			}
+			return &gorm.DB{}, nil
+		})
+		require.NoError(t, err)
+		require.NotNil(t, db)
+		require.Equal(t, []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second}, slept)
+	})
+
+	t.Run("gives up with the real error", func(t *testing.T) {
+		var waited time.Duration
+		calls := 0
+		_, err := retry(3*time.Second, func(d time.Duration) { waited += d }, func() (*gorm.DB, error) {
+			calls++
+			return nil, refused
+		})
+		require.ErrorIs(t, err, refused)
+		require.Equal(t, 3*time.Second, waited)
+		require.Equal(t, 4, calls)
+	})
+
+	t.Run("no window tries once", func(t *testing.T) {
+		calls := 0
+		_, err := retry(0, func(time.Duration) { t.Fatal("slept") }, func() (*gorm.DB, error) {
+			calls++
+			return nil, refused
+		})
+		require.ErrorIs(t, err, refused)
+		require.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,345 @@
+// Package seed inserts fixture data. Fixtures are YAML or JSON files grouped
+// into profiles by profiles.yaml. A run only inserts rows that are missing,
+// matched by their natural key, so seeding again changes nothing. Rows that
+// were soft deleted count as present and are not brought back.
+package seed
+
+import (
+	"bytes"
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/helper"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"go.yaml.in/yaml/v3"
+	"gorm.io/gorm"
+)
+
+//go:embed fixtures/*.yaml
+var embedded embed.FS
+
+// Embedded returns the fixtures compiled into the binary.
+func Embedded() fs.FS {
+	sub, err := fs.Sub(embedded, "fixtures")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// profiles that may not create accounts with a password written in a file
+var noLiteralPasswords = map[string]bool{"prod": true}
+
+type Fixture struct {
+	Classes     []string           `yaml:"classes" json:"classes"`
+	Races       []string           `yaml:"races" json:"races"`
+	QuestLevels []string           `yaml:"quest_levels" json:"quest_levels"`
+	Users       []UserFixture      `yaml:"users" json:"users"`
+	Characters  []CharacterFixture `yaml:"characters" json:"characters"`
+	Quests      []QuestFixture     `yaml:"quests" json:"quests"`
+}
+
+type UserFixture struct {
+	Username string     `yaml:"username" json:"username"`
+	Email    string     `yaml:"email" json:"email"`
+	Role     model.Role `yaml:"role" json:"role"`
+	// Password is taken as is. Profiles for production refuse it.
+	Password string `yaml:"password" json:"password"`
+	// PasswordEnv names the environment variable holding the password.
+	PasswordEnv string `yaml:"password_env" json:"password_env"`
+	Verified    bool   `yaml:"verified" json:"verified"`
+}
+
+// CharacterFixture refers to its owner by username and to its class and race
+// by name.
+type CharacterFixture struct {
+	Title       string        `yaml:"title" json:"title"`
+	Owner       string        `yaml:"owner" json:"owner"`
+	Class       string        `yaml:"class" json:"class"`
+	Race        string        `yaml:"race" json:"race"`
+	Privacy     model.Privacy `yaml:"privacy" json:"privacy"`
+	Description string        `yaml:"description" json:"description"`
+}
+
+type QuestFixture struct {
+	Title       string        `yaml:"title" json:"title"`
+	Owner       string        `yaml:"owner" json:"owner"`
+	Level       string        `yaml:"level" json:"level"`
+	Privacy     model.Privacy `yaml:"privacy" json:"privacy"`
+	Description string        `yaml:"description" json:"description"`
+}
+
+// Profile is the fixtures of one environment, in load order.
+type Profile struct {
+	Name     string
+	Files    []string
+	Fixtures []Fixture
+}
+
+// LoadProfile reads profiles.yaml from fsys and the fixture files the named
+// profile lists.
+func LoadProfile(fsys fs.FS, name string) (*Profile, error) {
+	var profiles map[string][]string
+	if err := decodeFile(fsys, "profiles.yaml", &profiles); err != nil {
+		return nil, err
+	}
+	files, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown seed profile %q", name)
+	}
+
+	p := &Profile{Name: name, Files: files}
+	for _, file := range files {
+		var f Fixture
+		if err := decodeFile(fsys, file, &f); err != nil {
+			return nil, err
+		}
+		if err := f.validate(!noLiteralPasswords[name]); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		p.Fixtures = append(p.Fixtures, f)
+	}
+	return p, nil
+}
+
+// decodeFile reads YAML or JSON by extension. Unknown fields are errors so
+// typos do not silently drop data.
+func decodeFile(fsys fs.FS, name string, v any) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	switch path.Ext(name) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(v)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(v)
+	default:
+		return fmt.Errorf("%s: fixtures must be .yaml, .yml or .json", name)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func (f *Fixture) validate(allowPasswords bool) error {
+	for _, u := range f.Users {
+		if u.Username == "" || u.Email == "" {
+			return errors.New("users need a username and an email")
+		}
+		if u.Role != "" && u.Role != model.RoleUser && u.Role != model.RoleAdmin {
+			return fmt.Errorf("user %s: invalid role %q", u.Username, u.Role)
+		}
+		switch {
+		case u.Password != "" && u.PasswordEnv != "":
+			return fmt.Errorf("user %s: set either password or password_env", u.Username)
+		case u.Password != "" && !allowPasswords:
+			return fmt.Errorf("user %s: passwords in fixtures are not allowed in this profile, use password_env", u.Username)
+		case u.Password == "" && u.PasswordEnv == "":
+			return fmt.Errorf("user %s: password or password_env is required", u.Username)
+		}
+	}
+	for _, c := range f.Characters {
+		if c.Title == "" || c.Owner == "" || c.Class == "" || c.Race == "" {
+			return errors.New("characters need a title, owner, class and race")
+		}
+		if err := checkPrivacy(c.Privacy); err != nil {
+			return fmt.Errorf("character %s: %w", c.Title, err)
+		}
+	}
+	for _, q := range f.Quests {
+		if q.Title == "" || q.Owner == "" || q.Level == "" {
+			return errors.New("quests need a title, owner and level")
+		}
+		if err := checkPrivacy(q.Privacy); err != nil {
+			return fmt.Errorf("quest %s: %w", q.Title, err)
+		}
+	}
+	return nil
+}
+
+func checkPrivacy(p model.Privacy) error {
+	if p != "" && p != model.PrivacyPublic && p != model.PrivacyPrivate {
+		return fmt.Errorf("invalid privacy %q", p)
+	}
+	return nil
+}
+
+// Result counts what a run inserted and what was already there, by kind.
+type Result struct {
+	Created  map[string]int
+	Existing map[string]int
+}
+
+func (r *Result) count(kind string, created bool) {
+	if created {
+		r.Created[kind]++
+	} else {
+		r.Existing[kind]++
+	}
+}
+
+// Run inserts the profile in one transaction. getenv resolves password_env.
+func Run(db *gorm.DB, p *Profile, getenv func(string) string) (*Result, error) {
+	res := &Result{Created: map[string]int{}, Existing: map[string]int{}}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for i, f := range p.Fixtures {
+			if err := seedFixture(tx, &f, getenv, res); err != nil {
+				return fmt.Errorf("%s: %w", p.Files[i], err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func seedFixture(tx *gorm.DB, f *Fixture, getenv func(string) string, res *Result) error {
+	for _, name := range f.Classes {
+		created, err := ensure(tx, &model.Class{Name: name}, "name = ?", name)
+		if err != nil {
+			return fmt.Errorf("class %s: %w", name, err)
+		}
+		res.count("classes", created)
+	}
+	for _, name := range f.Races {
+		created, err := ensure(tx, &model.Race{Name: name}, "name = ?", name)
+		if err != nil {
+			return fmt.Errorf("race %s: %w", name, err)
+		}
+		res.count("races", created)
+	}
+	for _, name := range f.QuestLevels {
+		created, err := ensure(tx, &model.QuestLevel{Name: name}, "name = ?", name)
+		if err != nil {
+			return fmt.Errorf("quest level %s: %w", name, err)
+		}
+		res.count("quest levels", created)
+	}
+
+	for _, u := range f.Users {
+		created, err := seedUser(tx, u, getenv)
+		if err != nil {
+			return fmt.Errorf("user %s: %w", u.Username, err)
+		}
+		res.count("users", created)
+	}
+
+	for _, c := range f.Characters {
+		owner, err := findID[model.User](tx, "username = ?", c.Owner)
+		if err != nil {
+			return fmt.Errorf("character %s: owner %s: %w", c.Title, c.Owner, err)
+		}
+		class, err := findID[model.Class](tx, "name = ?", c.Class)
+		if err != nil {
+			return fmt.Errorf("character %s: class %s: %w", c.Title, c.Class, err)
+		}
+		race, err := findID[model.Race](tx, "name = ?", c.Race)
+		if err != nil {
+			return fmt.Errorf("character %s: race %s: %w", c.Title, c.Race, err)
+		}
+		row := &model.Character{UserID: owner, Title: c.Title, Description: c.Description, ClassID: class, RaceID: race, Privacy: orPublic(c.Privacy)}
+		created, err := ensure(tx, row, "user_id = ? AND title = ?", owner, c.Title)
+		if err != nil {
+			return fmt.Errorf("character %s: %w", c.Title, err)
+		}
+		res.count("characters", created)
+	}
+
+	for _, q := range f.Quests {
+		owner, err := findID[model.User](tx, "username = ?", q.Owner)
+		if err != nil {
+			return fmt.Errorf("quest %s: owner %s: %w", q.Title, q.Owner, err)
+		}
+		level, err := findID[model.QuestLevel](tx, "name = ?", q.Level)
+		if err != nil {
+			return fmt.Errorf("quest %s: level %s: %w", q.Title, q.Level, err)
+		}
+		row := &model.Quest{UserID: owner, Title: q.Title, Description: q.Description, QuestLevelID: level, Privacy: orPublic(q.Privacy)}
+		created, err := ensure(tx, row, "user_id = ? AND title = ?", owner, q.Title)
+		if err != nil {
+			return fmt.Errorf("quest %s: %w", q.Title, err)
+		}
+		res.count("quests", created)
+	}
+	return nil
+}
+
+// seedUser creates the account unless the username or email is taken. The
+// password of an existing account is never touched.
+func seedUser(tx *gorm.DB, u UserFixture, getenv func(string) string) (bool, error) {
+	var n int64
+	if err := tx.Unscoped().Model(&model.User{}).Where("username = ? OR email = ?", u.Username, u.Email).Count(&n).Error; err != nil {
+		return false, err
+	}
+	if n > 0 {
+		return false, nil
+	}
+
+	password := u.Password
+	if u.PasswordEnv != "" {
+		if password = getenv(u.PasswordEnv); password == "" {
+			return false, fmt.Errorf("%s is not set", u.PasswordEnv)
+		}
+	}
+	salt, err := helper.GenerateSalt(16)
+	if err != nil {
+		return false, err
+	}
+	role := u.Role
+	if role == "" {
+		role = model.RoleUser
+	}
+	user := &model.User{Username: u.Username, Email: u.Email, PasswordHash: helper.HashPasswordArgon2(password, salt), Role: role}
+	if u.Verified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	return true, tx.Create(user).Error
+}
+
+// ensure inserts row unless a row matching the query exists, soft deleted
+// or not. On a match row is loaded from it.
+func ensure[T any](tx *gorm.DB, row *T, query string, args ...any) (bool, error) {
+	err := tx.Unscoped().Where(query, args...).Take(row).Error
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	return true, tx.Create(row).Error
+}
+
+// findID looks up a live row referenced by a fixture.
+func findID[T any](tx *gorm.DB, query string, args ...any) (uuid.UUID, error) {
+	var id uuid.UUID
+	res := tx.Model(new(T)).Where(query, args...).Select("id").Limit(1).Scan(&id)
+	if res.Error != nil {
+		return uuid.Nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return uuid.Nil, errors.New("not found")
+	}
+	return id, nil
+}
+
+func orPublic(p model.Privacy) model.Privacy {
+	if p == "" {
+		return model.PrivacyPublic
+	}
+	return p
+}
@@ -0,0 +1,78 @@
+package seed
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedProfiles(t *testing.T) {
+	prod, err := LoadProfile(Embedded(), "prod")
+	require.NoError(t, err)
+	for _, f := range prod.Fixtures {
+		require.Empty(t, f.Users, "prod must not create accounts")
+		require.NotEmpty(t, f.Classes)
+	}
+
+	for _, name := range []string{"dev", "test"} {
+		p, err := LoadProfile(Embedded(), name)
+		require.NoError(t, err, name)
+		var users, characters, quests int
+		for _, f := range p.Fixtures {
+			users += len(f.Users)
+			characters += len(f.Characters)
+			quests += len(f.Quests)
+			for _, c := range f.Characters {
+				require.NotEmpty(t, c.Description, c.Title)
+			}
+			for _, q := range f.Quests {
+				require.NotEmpty(t, q.Description, q.Title)
+			}
+		}
+		require.NotZero(t, users, name)
+		require.NotZero(t, characters, name)
+		require.NotZero(t, quests, name)
+	}
+
+	_, err = LoadProfile(Embedded(), "staging")
+	require.Error(t, err)
+}
+
+func TestLoadProfile(t *testing.T) {
+	profiles := []byte("prod: [users.yaml]\ndev: [users.yaml]\njson: [data.json]\n")
+	fsys := fstest.MapFS{
+		"profiles.yaml": {Data: profiles},
+		"users.yaml":    {Data: []byte("users:\n  - {username: admin, email: a@example.com, password: password, role: admin}\n")},
+		"data.json":     {Data: []byte(`{"classes": ["Bard"], "quests": [{"title": "Q", "owner": "admin", "level": "Easy", "privacy": "private"}]}`)},
+	}
+
+	_, err := LoadProfile(fsys, "prod")
+	require.ErrorContains(t, err, "password_env")
+
+	p, err := LoadProfile(fsys, "dev")
+	require.NoError(t, err)
+	require.Equal(t, "password", p.Fixtures[0].Users[0].Password)
+
+	p, err = LoadProfile(fsys, "json")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Bard"}, p.Fixtures[0].Classes)
+
+	fsys["users.yaml"] = &fstest.MapFile{Data: []byte("users:\n  - {username: admin, email: a@example.com, password_env: ADMIN_PASSWORD}\n")}
+	_, err = LoadProfile(fsys, "prod")
+	require.NoError(t, err)
+
+	for name, data := range map[string]string{
+		"typo":           "clases: [Bard]\n",
+		"no password":    "users:\n  - {username: u, email: u@example.com}\n",
+		"both passwords": "users:\n  - {username: u, email: u@example.com, password: x, password_env: X}\n",
+		"bad role":       "users:\n  - {username: u, email: u@example.com, password: x, role: root}\n",
+		"bad privacy":    "characters:\n  - {title: C, owner: u, class: Mage, race: Elf, privacy: secret}\n",
+		"missing fields": "characters:\n  - {title: C}\n",
+		"quest no level": "quests:\n  - {title: Q, owner: u}\n",
+	} {
+		fsys["users.yaml"] = &fstest.MapFile{Data: []byte(data)}
+		_, err := LoadProfile(fsys, "dev")
+		require.Error(t, err, name)
+	}
+}
@@ -1,9 +1,11 @@
 package jwt
 
 import (
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type Claims struct {
@@ -12,15 +14,79 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(secret, userID, role string, ttl time.Duration) (string, error) {
+// Options are the registered claims every token is issued with and must
+// carry when it is parsed back.
+type Options struct {
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+// Manager signs and verifies access tokens. Verification is pinned to the
+// algorithm of the key set.
+type Manager struct {
+	keys   *KeySet
+	opts   Options
+	parser *jwt.Parser
+}
+
+func NewManager(keys *KeySet, opts Options) *Manager {
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{keys.method.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(opts.Leeway),
+	}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+	}
+	return &Manager{
+		keys:   keys,
+		opts:   opts,
+		parser: jwt.NewParser(parserOpts...),
+	}
+}
+
+// Keys returns the key set, e.g. to publish it as JWKS.
+func (m *Manager) Keys() *KeySet {
+	return m.keys
+}
+
+func (m *Manager) GenerateToken(userID, role string, ttl time.Duration) (string, error) {
+	now := time.Now()
 	claims := Claims{
 		Sub:  userID,
 		Role: role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			ID:        uuid.NewString(),
+			Issuer:    m.opts.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	if m.opts.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.opts.Audience}
+	}
+	return m.keys.sign(claims)
+}
+
+// ParseToken verifies the signature and the registered claims of a token.
+// exp, nbf and jti are required, iss and aud when they are configured.
+func (m *Manager) ParseToken(tokenStr string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := m.parser.ParseWithClaims(tokenStr, claims, m.keys.verificationKey)
+	if err != nil {
+		return nil, err
+	}
+	if claims.NotBefore == nil {
+		return nil, errors.New("token has no nbf claim")
+	}
+	if claims.ID == "" || claims.Sub == "" {
+		return nil, errors.New("token has no jti or sub claim")
+	}
+	return claims, nil
 }
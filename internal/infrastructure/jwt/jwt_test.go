@@ -0,0 +1,70 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTokenValidatesClaims(t *testing.T) {
+	opts := Options{Issuer: "dnd", Audience: "dnd-api", Leeway: 5 * time.Second}
+	m := NewManager(NewHMACKeySet("secret"), opts)
+
+	token, err := m.GenerateToken("user-1", "user", time.Minute)
+	require.NoError(t, err)
+	claims, err := m.ParseToken(token)
+	require.NoError(t, err)
+	require.Equal(t, "user-1", claims.Sub)
+	require.NotEmpty(t, claims.ID)
+
+	// wrong issuer, wrong audience or wrong secret
+	for _, other := range []*Manager{
+		NewManager(NewHMACKeySet("secret"), Options{Issuer: "other", Audience: "dnd-api"}),
+		NewManager(NewHMACKeySet("secret"), Options{Issuer: "dnd", Audience: "other"}),
+		NewManager(NewHMACKeySet("other"), opts),
+	} {
+		_, err = other.ParseToken(token)
+		require.Error(t, err)
+	}
+
+	// expired beyond the leeway
+	expired, err := m.GenerateToken("user-1", "user", -time.Minute)
+	require.NoError(t, err)
+	_, err = m.ParseToken(expired)
+	require.Error(t, err)
+
+	// expired within the leeway
+	grace, err := m.GenerateToken("user-1", "user", -2*time.Second)
+	require.NoError(t, err)
+	_, err = m.ParseToken(grace)
+	require.NoError(t, err)
+}
+
+func TestParseTokenPinsAlgorithm(t *testing.T) {
+	m := NewManager(NewHMACKeySet("secret"), Options{})
+	claims := Claims{Sub: "user-1", RegisteredClaims: jwt.RegisteredClaims{
+		ID:        "id",
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		NotBefore: jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}}
+
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = m.ParseToken(none)
+	require.Error(t, err)
+
+	hs512, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	_, err = m.ParseToken(hs512)
+	require.Error(t, err)
+
+	// tokens without exp are rejected
+	claims.ExpiresAt = nil
+	noExp, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	_, err = m.ParseToken(noExp)
+	require.Error(t, err)
+}
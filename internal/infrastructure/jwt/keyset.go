@@ -0,0 +1,186 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is one entry of a KeySet. Verification-only keys have no Private key.
+type Key struct {
+	ID      string
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+// KeySet holds the key used for signing and every key tokens may still be
+// verified with. All keys share one algorithm.
+type KeySet struct {
+	method  jwt.SigningMethod
+	signing *Key
+	keys    map[string]*Key
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewHMACKeySet signs and verifies with a shared secret (HS256).
+func NewHMACKeySet(secret string) *KeySet {
+	key := &Key{Private: []byte(secret), Public: []byte(secret)}
+	return &KeySet{method: jwt.SigningMethodHS256, signing: key, keys: map[string]*Key{"": key}}
+}
+
+// LoadKeySet reads every *.pem file in dir; the file name without extension
+// is the key ID. Private keys can sign and verify, public keys only verify,
+// which lets retired keys stay around until their tokens have expired.
+// activeKID selects the private key new tokens are signed with.
+func LoadKeySet(alg, dir, activeKID string) (*KeySet, error) {
+	method := jwt.GetSigningMethod(alg)
+	if method == nil || (method != jwt.SigningMethodRS256 && method != jwt.SigningMethodEdDSA) {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeySet{method: method, keys: map[string]*Key{}}
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := loadPEMKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+		if !keyMatches(method, key.Public) {
+			return nil, fmt.Errorf("key %s does not match algorithm %s", kid, alg)
+		}
+		key.ID = kid
+		ks.keys[kid] = key
+	}
+
+	active, ok := ks.keys[activeKID]
+	if !ok || active.Private == nil {
+		return nil, fmt.Errorf("no private key for active key id %q in %s", activeKID, dir)
+	}
+	ks.signing = active
+	return ks, nil
+}
+
+// JWKS returns the public keys in JSON Web Key Set form. Shared secrets are
+// never published, so an HMAC key set is empty.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA", Kid: key.ID, Use: "sig", Alg: ks.method.Alg(),
+				N: base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP", Kid: key.ID, Use: "sig", Alg: ks.method.Alg(),
+				Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.method, claims)
+	if ks.signing.ID != "" {
+		token.Header["kid"] = ks.signing.ID
+	}
+	return token.SignedString(ks.signing.Private)
+}
+
+func (ks *KeySet) verificationKey(token *jwt.Token) (interface{}, error) {
+	if ks.method == jwt.SigningMethodHS256 {
+		return ks.signing.Public, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key.Public, nil
+}
+
+func keyMatches(method jwt.SigningMethod, pub crypto.PublicKey) bool {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return method == jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		return method == jwt.SigningMethodEdDSA
+	}
+	return false
+}
+
+func loadPEMKey(file string) (*Key, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY", "RSA PRIVATE KEY":
+		var priv any
+		if block.Type == "RSA PRIVATE KEY" {
+			priv, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		} else {
+			priv, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		}
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("private key cannot sign")
+		}
+		return &Key{Private: priv, Public: signer.Public()}, nil
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{Public: pub}, nil
+	case "RSA PUBLIC KEY":
+		pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{Public: pub}, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
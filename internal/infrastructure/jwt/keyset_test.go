@@ -0,0 +1,105 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, dir, kid, typ string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600))
+}
+
+func writePrivateKey(t *testing.T, dir, kid string, key any) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	writePEM(t, dir, kid, "PRIVATE KEY", der)
+}
+
+func TestKeySetRotation(t *testing.T) {
+	dir := t.TempDir()
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "old", oldKey)
+	writePrivateKey(t, dir, "new", newKey)
+
+	keys, err := LoadKeySet("RS256", dir, "old")
+	require.NoError(t, err)
+	oldToken, err := NewManager(keys, Options{}).GenerateToken("user-1", "user", time.Minute)
+	require.NoError(t, err)
+
+	// rotate: sign with the new key, keep only the public half of the old one
+	pub, err := x509.MarshalPKIXPublicKey(&oldKey.PublicKey)
+	require.NoError(t, err)
+	writePEM(t, dir, "old", "PUBLIC KEY", pub)
+	keys, err = LoadKeySet("RS256", dir, "new")
+	require.NoError(t, err)
+	m := NewManager(keys, Options{})
+
+	newToken, err := m.GenerateToken("user-2", "user", time.Minute)
+	require.NoError(t, err)
+	claims, err := m.ParseToken(newToken)
+	require.NoError(t, err)
+	require.Equal(t, "user-2", claims.Sub)
+	claims, err = m.ParseToken(oldToken)
+	require.NoError(t, err)
+	require.Equal(t, "user-1", claims.Sub)
+
+	// a public key cannot be the signing key
+	_, err = LoadKeySet("RS256", dir, "old")
+	require.Error(t, err)
+
+	// once the old key is removed its tokens stop verifying
+	require.NoError(t, os.Remove(filepath.Join(dir, "old.pem")))
+	keys, err = LoadKeySet("RS256", dir, "new")
+	require.NoError(t, err)
+	_, err = NewManager(keys, Options{}).ParseToken(oldToken)
+	require.Error(t, err)
+}
+
+func TestKeySetEdDSAAndJWKS(t *testing.T) {
+	dir := t.TempDir()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "ed1", priv)
+
+	keys, err := LoadKeySet("EdDSA", dir, "ed1")
+	require.NoError(t, err)
+	m := NewManager(keys, Options{})
+	token, err := m.GenerateToken("user-1", "admin", time.Minute)
+	require.NoError(t, err)
+	claims, err := m.ParseToken(token)
+	require.NoError(t, err)
+	require.Equal(t, "admin", claims.Role)
+
+	set := keys.JWKS()
+	require.Len(t, set.Keys, 1)
+	require.Equal(t, "OKP", set.Keys[0].Kty)
+	require.Equal(t, "ed1", set.Keys[0].Kid)
+	require.Equal(t, "EdDSA", set.Keys[0].Alg)
+	require.Equal(t, base64.RawURLEncoding.EncodeToString(pub), set.Keys[0].X)
+
+	// keys of another algorithm are rejected
+	_, err = LoadKeySet("RS256", dir, "ed1")
+	require.Error(t, err)
+	// HS256 tokens are rejected by an asymmetric manager
+	hs, err := NewManager(NewHMACKeySet("secret"), Options{}).GenerateToken("user-1", "user", time.Minute)
+	require.NoError(t, err)
+	_, err = m.ParseToken(hs)
+	require.Error(t, err)
+	require.Empty(t, NewHMACKeySet("secret").JWKS().Keys)
+}
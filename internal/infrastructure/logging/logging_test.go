@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(&buf, "warn")
+	require.NoError(t, err)
+	l.Info("hidden")
+	l.Warn("shown")
+	require.NotContains(t, buf.String(), "hidden")
+	require.Contains(t, buf.String(), `"msg":"shown"`)
+
+	_, err = New(&buf, "verbose")
+	require.Error(t, err)
+}
+
+func TestContextLogger(t *testing.T) {
+	require.Same(t, slog.Default(), FromContext(t.Context()))
+
+	var buf bytes.Buffer
+	l, err := New(&buf, "info")
+	require.NoError(t, err)
+	ctx := With(WithLogger(t.Context(), l), "request_id", "req-1")
+	ctx = With(ctx, "user_id", "user-1")
+	FromContext(ctx).Info("done", Millis("latency_ms", 1500*time.Microsecond))
+
+	var rec map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+	require.Equal(t, "req-1", rec["request_id"])
+	require.Equal(t, "user-1", rec["user_id"])
+	require.Equal(t, 1.5, rec["latency_ms"])
+}
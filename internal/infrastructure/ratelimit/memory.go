@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often expired counters are dropped.
+const sweepInterval = time.Minute
+
+type entry struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// MemoryStore is a process local port.CounterStore. Counters are not shared
+// between instances.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]*entry{}, now: time.Now}
+}
+
+func (s *MemoryStore) Incr(key string, window time.Duration) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+
+	e, ok := s.entries[key]
+	if !ok || !now.Before(e.expiresAt) {
+		e = &entry{expiresAt: now.Add(window)}
+		s.entries[key] = e
+	}
+	e.count++
+	return e.count, e.expiresAt.Sub(now), nil
+}
+
+func (s *MemoryStore) Get(key string) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	e, ok := s.entries[key]
+	if !ok || !now.Before(e.expiresAt) {
+		return 0, 0, nil
+	}
+	return e.count, e.expiresAt.Sub(now), nil
+}
+
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for k, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreWindow(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	count, ttl, err := s.Incr("k", time.Minute)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, count)
+	require.Equal(t, time.Minute, ttl)
+
+	now = now.Add(20 * time.Second)
+	count, ttl, _ = s.Incr("k", time.Minute)
+	require.EqualValues(t, 2, count)
+	require.Equal(t, 40*time.Second, ttl)
+
+	// the window does not slide; it resets after it ran out
+	now = now.Add(40 * time.Second)
+	count, _, _ = s.Get("k")
+	require.Zero(t, count)
+	count, ttl, _ = s.Incr("k", time.Minute)
+	require.EqualValues(t, 1, count)
+	require.Equal(t, time.Minute, ttl)
+
+	require.NoError(t, s.Reset("k"))
+	count, _, _ = s.Get("k")
+	require.Zero(t, count)
+}
+
+func TestMemoryStoreSweepsExpired(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	s.Incr("old", time.Second)
+	now = now.Add(2 * sweepInterval)
+	s.Incr("new", time.Minute)
+	require.NotContains(t, s.entries, "old")
+	require.Contains(t, s.entries, "new")
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"dungeons-dragon-service/internal/config"
+	"dungeons-dragon-service/internal/domain/port"
+)
+
+// NewFromConfig picks the image storage: "s3" talks to an S3 compatible
+// service, "fs" (the default) keeps files under FILE_STORAGE_PATH.
+func NewFromConfig() (port.BlobStore, error) {
+	if config.GetConfigString("STORAGE_DRIVER") == "s3" {
+		return NewS3Store(S3Config{
+			Endpoint:  config.GetConfigString("S3_ENDPOINT"),
+			Region:    config.GetConfigString("S3_REGION"),
+			Bucket:    config.GetConfigString("S3_BUCKET"),
+			AccessKey: config.GetConfigString("S3_ACCESS_KEY"),
+			SecretKey: config.GetConfigString("S3_SECRET_KEY"),
+			PathStyle: config.GetConfigBool("S3_USE_PATH_STYLE"),
+		})
+	}
+	return NewFileSystemStore(config.GetConfigString("FILE_STORAGE_PATH"))
+}
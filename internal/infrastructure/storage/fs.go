@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/domain/port"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// FileSystemStore keeps blobs as files below a root directory. The content
+// type is derived from the key's extension.
+type FileSystemStore struct {
+	root string
+}
+
+func NewFileSystemStore(root string) (*FileSystemStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSystemStore{root: root}, nil
+}
+
+func (s *FileSystemStore) path(key string) (string, string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	return key, filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file first so readers never see partial blobs.
+// Cancelling ctx stops the copy and drops the temporary file.
+func (s *FileSystemStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, ctxReader{ctx, r}); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *FileSystemStore) Get(ctx context.Context, key string) (io.ReadCloser, *port.BlobInfo, error) {
+	key, p, err := s.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, nil, notFound(err)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if st.IsDir() {
+		f.Close()
+		return nil, nil, port.ErrBlobNotFound
+	}
+	return f, fileInfo(key, st), nil
+}
+
+func (s *FileSystemStore) Stat(ctx context.Context, key string) (*port.BlobInfo, error) {
+	key, p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	st, err := os.Stat(p)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if st.IsDir() {
+		return nil, port.ErrBlobNotFound
+	}
+	return fileInfo(key, st), nil
+}
+
+func (s *FileSystemStore) Delete(ctx context.Context, key string) error {
+	_, p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileSystemStore) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "", port.ErrPresignNotSupported
+}
+
+// List walks the root directory. Leftover temporary files of interrupted
+// uploads are listed too.
+func (s *FileSystemStore) List(ctx context.Context, fn func(port.BlobInfo) error) error {
+	return filepath.WalkDir(s.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		st, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			// deleted while walking
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		return fn(*fileInfo(filepath.ToSlash(rel), st))
+	})
+}
+
+// ctxReader fails reads once ctx is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+func fileInfo(key string, st fs.FileInfo) *port.BlobInfo {
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &port.BlobInfo{
+		Key:         key,
+		Size:        st.Size(),
+		ContentType: contentType,
+		ModTime:     st.ModTime(),
+		ETag:        fmt.Sprintf(`"%x-%x"`, st.ModTime().UnixNano(), st.Size()),
+	}
+}
+
+func notFound(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return port.ErrBlobNotFound
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"dungeons-dragon-service/internal/domain/port"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryBlob struct {
+	data []byte
+	info port.BlobInfo
+}
+
+// nopSeekCloser keeps the reader seekable, unlike io.NopCloser.
+type nopSeekCloser struct{ *bytes.Reader }
+
+func (nopSeekCloser) Close() error { return nil }
+
+// MemoryStore keeps blobs in memory, for tests and local experiments.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	blobs map[string]*memoryBlob
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{blobs: map[string]*memoryBlob{}}
+}
+
+func (s *MemoryStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = &memoryBlob{data: data, info: port.BlobInfo{
+		Key:         key,
+		Size:        int64(len(data)),
+		ContentType: contentType,
+		ModTime:     time.Now(),
+		ETag:        fmt.Sprintf(`"%x"`, md5.Sum(data)),
+	}}
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (io.ReadCloser, *port.BlobInfo, error) {
+	b, err := s.blob(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	info := b.info
+	return nopSeekCloser{bytes.NewReader(b.data)}, &info, nil
+}
+
+func (s *MemoryStore) Stat(ctx context.Context, key string) (*port.BlobInfo, error) {
+	b, err := s.blob(key)
+	if err != nil {
+		return nil, err
+	}
+	info := b.info
+	return &info, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blobs, key)
+	return nil
+}
+
+func (s *MemoryStore) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "", port.ErrPresignNotSupported
+}
+
+func (s *MemoryStore) List(ctx context.Context, fn func(port.BlobInfo) error) error {
+	for _, key := range s.Keys() {
+		info, err := s.Stat(ctx, key)
+		if err == port.ErrBlobNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(*info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Keys returns the stored keys in order.
+func (s *MemoryStore) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.blobs))
+	for k := range s.blobs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *MemoryStore) blob(key string) (*memoryBlob, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.blobs[key]
+	if !ok {
+		return nil, port.ErrBlobNotFound
+	}
+	return b, nil
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/domain/port"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type S3Config struct {
+	// Endpoint is the service URL, e.g. https://s3.amazonaws.com or
+	// http://localhost:9000 for MinIO.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket as endpoint/bucket/key instead of
+	// bucket.endpoint/key. MinIO and most S3 compatible servers need it.
+	PathStyle bool
+}
+
+// S3Store keeps blobs in S3 or an S3 compatible server (MinIO, R2, ...)
+// through the minio-go client.
+type S3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") != "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	secure := u.Scheme == "https"
+	// the transport limits dialing, the TLS handshake and the wait for
+	// response headers; there is no overall client timeout, since image
+	// bodies are streamed to clients that may read them slowly
+	transport, err := minio.DefaultTransport(secure)
+	if err != nil {
+		return nil, err
+	}
+	lookup := minio.BucketLookupDNS
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(u.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       secure,
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+		Transport:    transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3Store{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	// the payload is sent unsigned, as it is over TLS anyway; signing it
+	// would mean hashing or chunk-encoding every upload
+	_, err = s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:          contentType,
+		DisableContentSha256: true,
+	})
+	return s3Error(err)
+}
+
+// Get returns an io.ReadSeekCloser; seeking turns into a ranged GET on the
+// next Read.
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, *port.BlobInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, s3Error(err)
+	}
+	st, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, nil, s3Error(err)
+	}
+	return obj, objectInfo(st), nil
+}
+
+func (s *S3Store) Stat(ctx context.Context, key string) (*port.BlobInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	st, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	return objectInfo(st), nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	err = s3Error(s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}))
+	if errors.Is(err, port.ErrBlobNotFound) {
+		return nil
+	}
+	return err
+}
+
+// PresignGet builds a query string signed GET URL. S3 caps expiry at seven
+// days.
+func (s *S3Store) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, expires, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// List pages through the bucket with ListObjectsV2.
+func (s *S3Store) List(ctx context.Context, fn func(port.BlobInfo) error) error {
+	// cancelling stops the listing when fn returns early
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Recursive: true}) {
+		if obj.Err != nil {
+			return fmt.Errorf("s3 list: %w", obj.Err)
+		}
+		if err := fn(*objectInfo(obj)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// s3Error maps missing objects to port.ErrBlobNotFound.
+func s3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	res := minio.ToErrorResponse(err)
+	if res.StatusCode == http.StatusNotFound || res.Code == "NoSuchKey" {
+		return port.ErrBlobNotFound
+	}
+	return err
+}
+
+func objectInfo(obj minio.ObjectInfo) *port.BlobInfo {
+	info := &port.BlobInfo{
+		Key:         obj.Key,
+		Size:        obj.Size,
+		ContentType: obj.ContentType,
+		ModTime:     obj.LastModified,
+	}
+	// minio-go strips the quotes; HTTP validators need them
+	if obj.ETag != "" {
+		info.ETag = `"` + obj.ETag + `"`
+	}
+	return info
+}
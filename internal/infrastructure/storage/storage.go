@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// cleanKey rejects keys that are empty, absolute or escape the store root.
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"dungeons-dragon-service/internal/domain/port"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testBlobStore runs the behaviour every adapter must share.
+func testBlobStore(t *testing.T, s port.BlobStore) {
+	data := []byte("a picture of a dragon")
+	require.NoError(t, s.Put(t.Context(), "123-dragon.png", bytes.NewReader(data), int64(len(data)), "image/png"))
+
+	rc, info, err := s.Get(t.Context(), "123-dragon.png")
+	require.NoError(t, err)
+	got, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	require.Equal(t, data, got)
+	require.Equal(t, "image/png", info.ContentType)
+	require.NotEmpty(t, info.ETag)
+
+	info, err = s.Stat(t.Context(), "123-dragon.png")
+	require.NoError(t, err)
+	require.EqualValues(t, len(data), info.Size)
+
+	// overwriting replaces the content
+	require.NoError(t, s.Put(t.Context(), "123-dragon.png", strings.NewReader("v2"), 2, "image/png"))
+	info, err = s.Stat(t.Context(), "123-dragon.png")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, info.Size)
+
+	require.NoError(t, s.Delete(t.Context(), "123-dragon.png"))
+	_, err = s.Stat(t.Context(), "123-dragon.png")
+	require.ErrorIs(t, err, port.ErrBlobNotFound)
+	_, _, err = s.Get(t.Context(), "123-dragon.png")
+	require.ErrorIs(t, err, port.ErrBlobNotFound)
+	// deleting a missing blob is not an error
+	require.NoError(t, s.Delete(t.Context(), "123-dragon.png"))
+
+	for _, key := range []string{"", "../etc/passwd", "/abs", `a\\b`} {
+		require.ErrorIs(t, s.Put(t.Context(), key, strings.NewReader("x"), 1, ""), ErrInvalidKey, key)
+	}
+
+	for _, key := range []string{"c.png", "a.png", "sub/b.png"} {
+		require.NoError(t, s.Put(t.Context(), key, strings.NewReader(key), int64(len(key)), "image/png"))
+	}
+	var listed []string
+	require.NoError(t, s.List(t.Context(), func(info port.BlobInfo) error {
+		listed = append(listed, info.Key)
+		require.EqualValues(t, len(info.Key), info.Size)
+		require.False(t, info.ModTime.IsZero())
+		return nil
+	}))
+	require.Equal(t, []string{"a.png", "c.png", "sub/b.png"}, listed)
+
+	stop := errors.New("stop")
+	calls := 0
+	require.ErrorIs(t, s.List(t.Context(), func(port.BlobInfo) error { calls++; return stop }), stop)
+	require.Equal(t, 1, calls)
+}
+
+func TestFileSystemStore(t *testing.T) {
+	s, err := NewFileSystemStore(t.TempDir())
+	require.NoError(t, err)
+	testBlobStore(t, s)
+
+	_, err = s.PresignGet(t.Context(), "x", time.Minute)
+	require.ErrorIs(t, err, port.ErrPresignNotSupported)
+}
+
+func TestFileSystemStoreStopsOnCancel(t *testing.T) {
+	s, err := NewFileSystemStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, s.Put(t.Context(), "a.png", strings.NewReader("a"), 1, "image/png"))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	require.ErrorIs(t, s.Put(ctx, "b.png", strings.NewReader("b"), 1, "image/png"), context.Canceled)
+	_, err = s.Stat(t.Context(), "b.png")
+	require.ErrorIs(t, err, port.ErrBlobNotFound)
+	require.ErrorIs(t, s.List(ctx, func(port.BlobInfo) error { return nil }), context.Canceled)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testBlobStore(t, NewMemoryStore())
+}
+
+func TestS3Store(t *testing.T) {
+	fake := newFakeS3("images")
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	s, err := NewS3Store(S3Config{
+		Endpoint:  srv.URL,
+		Bucket:    "images",
+		AccessKey: "AKID",
+		SecretKey: "secret",
+		PathStyle: true,
+	})
+	require.NoError(t, err)
+	testBlobStore(t, s)
+}
+
+func TestS3StoreGetSeeksWithRanges(t *testing.T) {
+	fake := newFakeS3("images")
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	s, err := NewS3Store(S3Config{Endpoint: srv.URL, Bucket: "images", AccessKey: "AKID", SecretKey: "secret", PathStyle: true})
+	require.NoError(t, err)
+	data := []byte("0123456789abcdef")
+	require.NoError(t, s.Put(t.Context(), "a.png", bytes.NewReader(data), int64(len(data)), "image/png"))
+
+	rc, _, err := s.Get(t.Context(), "a.png")
+	require.NoError(t, err)
+	defer rc.Close()
+	rs, ok := rc.(io.ReadSeeker)
+	require.True(t, ok)
+
+	size, err := rs.Seek(0, io.SeekEnd)
+	require.NoError(t, err)
+	require.EqualValues(t, len(data), size)
+	_, err = rs.Seek(10, io.SeekStart)
+	require.NoError(t, err)
+	got, err := io.ReadAll(rs)
+	require.NoError(t, err)
+	require.Equal(t, "abcdef", string(got))
+	require.Equal(t, []string{"bytes=10-"}, fake.ranges)
+}
+
+func TestS3StorePresignGet(t *testing.T) {
+	s, err := NewS3Store(S3Config{
+		Endpoint:  "http://localhost:9000",
+		Bucket:    "images",
+		AccessKey: "AKID",
+		SecretKey: "secret",
+		PathStyle: true,
+	})
+	require.NoError(t, err)
+
+	raw, err := s.PresignGet(t.Context(), "a b.png", time.Hour)
+	require.NoError(t, err)
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	require.Equal(t, "localhost:9000", u.Host)
+	require.Equal(t, "/images/a b.png", u.Path)
+	q := u.Query()
+	require.Equal(t, "AWS4-HMAC-SHA256", q.Get("X-Amz-Algorithm"))
+	require.True(t, strings.HasPrefix(q.Get("X-Amz-Credential"), "AKID/"), q.Get("X-Amz-Credential"))
+	require.Equal(t, "3600", q.Get("X-Amz-Expires"))
+	require.NotEmpty(t, q.Get("X-Amz-Signature"))
+
+	_, err = s.PresignGet(t.Context(), "../secret", time.Hour)
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+// fakeS3 is a tiny path style S3 endpoint for a single bucket. Listings
+// return two keys per page to exercise continuation.
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string]*fakeObject
+	ranges  []string
+}
+
+func newFakeS3(bucket string) *fakeS3 {
+	return &fakeS3{bucket: bucket, objects: map[string]*fakeObject{}}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+		r.Header.Get("X-Amz-Date") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if key == "" && r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2" {
+		f.list(w, r.URL.Query().Get("continuation-token"))
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = &fakeObject{data: data, contentType: r.Header.Get("Content-Type"), modTime: time.Now()}
+		w.Header().Set("ETag", `"`+strconv.Itoa(len(data))+`"`)
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("ETag", `"`+strconv.Itoa(len(obj.data))+`"`)
+		if rng := r.Header.Get("Range"); rng != "" {
+			f.ranges = append(f.ranges, rng)
+		}
+		http.ServeContent(w, r, key, obj.modTime, bytes.NewReader(obj.data))
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, after string) {
+	keys := make([]string, 0, len(f.objects))
+	for k := range f.objects {
+		if k > after {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><ListBucketResult>`)
+	if len(keys) > 2 {
+		keys = keys[:2]
+		fmt.Fprintf(&b, "<IsTruncated>true</IsTruncated><NextContinuationToken>%s</NextContinuationToken>", keys[1])
+	}
+	for _, k := range keys {
+		obj := f.objects[k]
+		fmt.Fprintf(&b, "<Contents><Key>%s</Key><LastModified>%s</LastModified><ETag>&quot;%d&quot;</ETag><Size>%d</Size></Contents>",
+			k, obj.modTime.UTC().Format(time.RFC3339), len(obj.data), len(obj.data))
+	}
+	b.WriteString("</ListBucketResult>")
+	w.Header().Set("Content-Type", "application/xml")
+	io.WriteString(w, b.String())
+}
@@ -1,10 +1,12 @@
 package repositories
 
 import (
+	"context"
 	"dungeons-dragon-service/internal/domain/model"
 	"dungeons-dragon-service/internal/domain/repository"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type characterRepo struct{ db *gorm.DB }
@@ -13,48 +15,92 @@ func NewCharacterRepo(db *gorm.DB) repository.CharacterRepository {
 	return &characterRepo{db: db}
 }
 
-func (r *characterRepo) Create(m *model.Character) (*model.Character, error) {
-	if err := r.db.Create(m).Error; err != nil {
+func (r *characterRepo) Create(ctx context.Context, m *model.Character) (*model.Character, error) {
+	if err := r.db.WithContext(ctx).Create(m).Error; err != nil {
 		return nil, err
 	}
 	return m, nil
 }
-func (r *characterRepo) Update(m *model.Character) (*model.Character, error) {
-	if err := r.db.Save(m).Error; err != nil {
+func (r *characterRepo) Update(ctx context.Context, m *model.Character) (*model.Character, error) {
+	// images and their image_path are owned by the image repository
+	if err := r.db.WithContext(ctx).Omit(clause.Associations, "ImagePath").Save(m).Error; err != nil {
 		return nil, err
 	}
 	return m, nil
 }
-func (r *characterRepo) Delete(id string) error { return r.db.Delete(&model.Character{}, id).Error }
-func (r *characterRepo) FindByID(id string) (*model.Character, error) {
+func (r *characterRepo) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Character{}).Error
+}
+func (r *characterRepo) FindByID(ctx context.Context, id string) (*model.Character, error) {
 	var m model.Character
-	if err := r.db.Where("id = ?", id).First(&m).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Images", imagesByPosition).Where("id = ?", id).First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
 		return nil, err
 	}
 	return &m, nil
 }
-func (r *characterRepo) ListAll() ([]model.Character, error) {
+func (r *characterRepo) ListAll(ctx context.Context) ([]model.Character, error) {
 	var list []model.Character
-	err := r.db.Where("status = ?", model.ItemStatusActive).Order("created_at desc").Find(&list).Error
+	err := r.db.WithContext(ctx).Preload("Images", imagesByPosition).Where("status = ?", model.ItemStatusActive).Order("created_at desc").Find(&list).Error
 	return list, err
 }
-func (r *characterRepo) ListPublic() ([]model.Character, error) {
+func (r *characterRepo) ListPublic(ctx context.Context) ([]model.Character, error) {
 	var list []model.Character
-	err := r.db.Where("privacy = ? AND status = ?", model.PrivacyPublic, model.ItemStatusActive).Order("created_at desc").Find(&list).Error
+	err := r.db.WithContext(ctx).Preload("Images", imagesByPosition).Where("privacy = ? AND status = ?", model.PrivacyPublic, model.ItemStatusActive).Order("created_at desc").Find(&list).Error
 	return list, err
 }
-func (r *characterRepo) ListByUser(userID string) ([]model.Character, error) {
+func (r *characterRepo) ListByUser(ctx context.Context, userID string) ([]model.Character, error) {
 	var list []model.Character
-	err := r.db.Where("user_id = ? AND status = ?", userID, model.ItemStatusActive).Order("created_at desc").Find(&list).Error
+	err := r.db.WithContext(ctx).Preload("Images", imagesByPosition).Where("user_id = ? AND status = ?", userID, model.ItemStatusActive).Order("created_at desc").Find(&list).Error
 	return list, err
 }
-func (r *characterRepo) ArchiveByClassID(classID string) error {
-	return r.db.Model(&model.Character{}).
+func (r *characterRepo) ArchiveByClassID(ctx context.Context, classID string) error {
+	return r.db.WithContext(ctx).Model(&model.Character{}).
 		Where("class_id = ? AND status = ?", classID, model.ItemStatusActive).
 		Update("status", model.ItemStatusArchived).Error
 }
-func (r *characterRepo) ArchiveByRaceID(raceID string) error {
-	return r.db.Model(&model.Character{}).
+func (r *characterRepo) ArchiveByRaceID(ctx context.Context, raceID string) error {
+	return r.db.WithContext(ctx).Model(&model.Character{}).
 		Where("race_id = ? AND status = ?", raceID, model.ItemStatusActive).
 		Update("status", model.ItemStatusArchived).Error
 }
+func (r *characterRepo) ArchiveByUserID(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Model(&model.Character{}).
+		Where("user_id = ? AND status = ?", userID, model.ItemStatusActive).
+		Update("status", model.ItemStatusArchived).Error
+}
+func (r *characterRepo) Search(ctx context.Context, f repository.CharacterFilter, p repository.PageRequest) ([]model.Character, int64, error) {
+	status := f.Status
+	if status == "" {
+		status = model.ItemStatusActive
+	}
+	q := r.db.WithContext(ctx).Model(&model.Character{}).Where("status = ?", status)
+	q = visible(q, f.Visibility)
+	if f.Privacy != "" {
+		q = q.Where("privacy = ?", f.Privacy)
+	}
+	if f.ClassID != "" {
+		q = q.Where("class_id = ?", f.ClassID)
+	}
+	if f.RaceID != "" {
+		q = q.Where("race_id = ?", f.RaceID)
+	}
+	if f.UserID != "" {
+		q = q.Where("user_id = ?", f.UserID)
+	}
+
+	var total int64
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []model.Character
+	if err := paginate(q, p).Preload("Images", imagesByPosition).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	if p.Cursor != nil && p.Cursor.Backward {
+		reverse(list)
+	}
+	return list, total, nil
+}
@@ -1,10 +1,16 @@
 package repositories
 
 import (
+	"context"
 	"dungeons-dragon-service/internal/domain/model"
 	"dungeons-dragon-service/internal/domain/repository"
+	"encoding/json"
+	"time"
 
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type imageRepo struct{ db *gorm.DB }
@@ -13,46 +19,235 @@ func NewImageRepo(db *gorm.DB) repository.ImageRepository {
 	return &imageRepo{db: db}
 }
 
-func (r *imageRepo) GetCharacterImageByID(characterID string) ([]model.CharacterImage, error) {
-	var imgs []model.CharacterImage
-	if err := r.db.Where("character_id = ?", characterID).Find(&imgs).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
+// imagesByPosition orders preloaded images the way they are shown.
+func imagesByPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("position asc, created_at asc")
+}
+
+// imageTable pairs an image table with the table of its owner.
+type imageTable struct{ owner, images, owners, column string }
+
+var (
+	characterImages = imageTable{"character", "character_images", "characters", "character_id"}
+	questImages     = imageTable{"quest", "quest_images", "quests", "quest_id"}
+	imageTables     = []imageTable{characterImages, questImages}
+)
+
+func (r *imageRepo) FindOwnerByKey(ctx context.Context, key string) (*repository.ImageOwnerInfo, error) {
+	db := r.db.WithContext(ctx)
+	variant, _ := json.Marshal([]map[string]string{{"key": key}})
+	for _, t := range imageTables {
+		var info repository.ImageOwnerInfo
+		res := db.Table(t.images+" AS i").
+			Select("o.user_id, o.privacy, o.status").
+			Joins("JOIN "+t.owners+" AS o ON o.id = i."+t.column+" AND o.deleted_at IS NULL").
+			Where("i.deleted_at IS NULL AND (i.path = ? OR i.variants @> ?::jsonb)", key, string(variant)).
+			Limit(1).Scan(&info)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected > 0 {
+			return &info, nil
 		}
+	}
+	return nil, nil
+}
+
+func (r *imageRepo) GetCharacterImageByID(ctx context.Context, characterID string) ([]model.CharacterImage, error) {
+	var imgs []model.CharacterImage
+	if err := imagesByPosition(r.db.WithContext(ctx)).Where("character_id = ?", characterID).Find(&imgs).Error; err != nil {
 		return nil, err
 	}
 	return imgs, nil
 }
 
-func (r *imageRepo) GetQuestImageByID(questID string) ([]model.QuestImage, error) {
+func (r *imageRepo) GetQuestImageByID(ctx context.Context, questID string) ([]model.QuestImage, error) {
 	var imgs []model.QuestImage
-	if err := r.db.Where("quest_id = ?", questID).Find(&imgs).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
-		}
+	if err := imagesByPosition(r.db.WithContext(ctx)).Where("quest_id = ?", questID).Find(&imgs).Error; err != nil {
 		return nil, err
 	}
 	return imgs, nil
 }
 
-func (r *imageRepo) DeleteCharacterImageByID(characterID string) error {
-	return r.db.Where("character_id = ?", characterID).Delete(&model.CharacterImage{}).Error
+func (r *imageRepo) SaveCharacterImages(ctx context.Context, characterID string, expected []string, imgs []model.CharacterImage) ([]model.CharacterImage, error) {
+	return saveImages[model.Character](r.db.WithContext(ctx), characterImages, characterID, expected, imgs, func(img *model.CharacterImage) imageRow {
+		return imageRow{&img.Base, &img.Position, img.Path, img.IsCover}
+	})
 }
 
-func (r *imageRepo) CreateCharacterImage(img *model.CharacterImage) (*model.CharacterImage, error) {
-	if err := r.db.Create(img).Error; err != nil {
-		return nil, err
+func (r *imageRepo) SaveQuestImages(ctx context.Context, questID string, expected []string, imgs []model.QuestImage) ([]model.QuestImage, error) {
+	return saveImages[model.Quest](r.db.WithContext(ctx), questImages, questID, expected, imgs, func(img *model.QuestImage) imageRow {
+		return imageRow{&img.Base, &img.Position, img.Path, img.IsCover}
+	})
+}
+
+func (r *imageRepo) SetCharacterImageVariants(ctx context.Context, imageID string, status model.VariantStatus, variants []model.ImageVariant) (bool, error) {
+	return setImageVariants[model.CharacterImage](r.db.WithContext(ctx), imageID, status, variants)
+}
+
+func (r *imageRepo) SetQuestImageVariants(ctx context.Context, imageID string, status model.VariantStatus, variants []model.ImageVariant) (bool, error) {
+	return setImageVariants[model.QuestImage](r.db.WithContext(ctx), imageID, status, variants)
+}
+
+func (r *imageRepo) PendingCharacterImages(ctx context.Context, limit int) ([]model.CharacterImage, error) {
+	return pendingImages[model.CharacterImage](r.db.WithContext(ctx), limit)
+}
+
+func (r *imageRepo) PendingQuestImages(ctx context.Context, limit int) ([]model.QuestImage, error) {
+	return pendingImages[model.QuestImage](r.db.WithContext(ctx), limit)
+}
+
+func (r *imageRepo) ImageRecords(ctx context.Context) ([]repository.ImageRecord, error) {
+	db := r.db.WithContext(ctx)
+	var records []repository.ImageRecord
+	for _, t := range imageTables {
+		var rows []struct {
+			ID           uuid.UUID
+			Path         string
+			Variants     datatypes.JSONSlice[model.ImageVariant]
+			CreatedAt    time.Time
+			OwnerDeleted bool
+		}
+		err := db.Table(t.images + " AS i").
+			Select("i.id, i.path, i.variants, i.created_at, o.id IS NULL AS owner_deleted").
+			Joins("LEFT JOIN " + t.owners + " AS o ON o.id = i." + t.column + " AND o.deleted_at IS NULL").
+			Where("i.deleted_at IS NULL").
+			Order("i.created_at").
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			records = append(records, repository.ImageRecord{
+				ID:           row.ID,
+				Owner:        t.owner,
+				Path:         row.Path,
+				Variants:     row.Variants,
+				CreatedAt:    row.CreatedAt,
+				OwnerDeleted: row.OwnerDeleted,
+			})
+		}
 	}
-	return img, nil
+	return records, nil
 }
 
-func (r *imageRepo) DeleteQuestImageByID(questID string) error {
-	return r.db.Where("quest_id = ?", questID).Delete(&model.QuestImage{}).Error
+func (r *imageRepo) DeleteImageRecords(ctx context.Context, records []repository.ImageRecord) error {
+	var characterIDs, questIDs []uuid.UUID
+	for _, rec := range records {
+		if rec.Owner == "quest" {
+			questIDs = append(questIDs, rec.ID)
+		} else {
+			characterIDs = append(characterIDs, rec.ID)
+		}
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(characterIDs) > 0 {
+			if err := tx.Where("id IN ?", characterIDs).Delete(&model.CharacterImage{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(questIDs) > 0 {
+			if err := tx.Where("id IN ?", questIDs).Delete(&model.QuestImage{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func (r *imageRepo) CreateQuestImage(img *model.QuestImage) (*model.QuestImage, error) {
-	if err := r.db.Create(img).Error; err != nil {
+// imageRow exposes the fields saveImages needs of a character or quest image.
+type imageRow struct {
+	*model.Base
+	position *int
+	path     string
+	isCover  bool
+}
+
+// saveImages stores imgs as the ordered image list of the owner in table t
+// and returns the rows it removed. O is the owner model and T the image model
+// of t.
+func saveImages[O, T any](db *gorm.DB, t imageTable, ownerID string, expected []string, imgs []T, row func(*T) imageRow) ([]T, error) {
+	var removed []T
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// serialise image changes of one owner on its row
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", ownerID).Take(new(O)).Error; err != nil {
+			return err
+		}
+		var current []T
+		if err := tx.Where(t.column+" = ?", ownerID).Find(&current).Error; err != nil {
+			return err
+		}
+		currentIDs := make([]uuid.UUID, len(current))
+		for i := range current {
+			currentIDs[i] = row(&current[i]).ID
+		}
+		if !sameIDs(currentIDs, expected) {
+			return repository.ErrImagesChanged
+		}
+
+		keep := map[uuid.UUID]bool{}
+		paths := make([]string, 0, len(imgs))
+		for i := range imgs {
+			img := row(&imgs[i])
+			*img.position = i
+			if img.ID == uuid.Nil {
+				if err := tx.Create(&imgs[i]).Error; err != nil {
+					return err
+				}
+			} else if err := tx.Model(new(T)).Where("id = ?", img.ID).
+				Updates(map[string]any{"position": i, "is_cover": img.isCover}).Error; err != nil {
+				return err
+			}
+			keep[img.ID] = true
+			paths = append(paths, img.path)
+		}
+		for i := range current {
+			if id := row(&current[i]).ID; !keep[id] {
+				removed = append(removed, current[i])
+				if err := tx.Where("id = ?", id).Delete(new(T)).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Model(new(O)).Where("id = ?", ownerID).Update("image_path", pathsJSON(paths)).Error
+	})
+	if err != nil {
 		return nil, err
 	}
-	return img, nil
+	return removed, nil
+}
+
+func setImageVariants[T any](db *gorm.DB, imageID string, status model.VariantStatus, variants []model.ImageVariant) (bool, error) {
+	res := db.Model(new(T)).Where("id = ?", imageID).
+		Updates(map[string]any{"variant_status": status, "variants": datatypes.NewJSONSlice(variants)})
+	return res.RowsAffected > 0, res.Error
+}
+
+func pendingImages[T any](db *gorm.DB, limit int) ([]T, error) {
+	var imgs []T
+	err := db.Where("variant_status = ?", model.VariantStatusPending).Order("created_at asc").Limit(limit).Find(&imgs).Error
+	return imgs, err
+}
+
+// sameIDs reports whether ids and expected hold the same set of ids.
+func sameIDs(ids []uuid.UUID, expected []string) bool {
+	if len(ids) != len(expected) {
+		return false
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		seen[id.String()] = true
+	}
+	for _, id := range expected {
+		if !seen[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// pathsJSON builds the legacy image_path column from the ordered paths.
+func pathsJSON(paths []string) datatypes.JSON {
+	b, _ := json.Marshal(paths)
+	return datatypes.JSON(b)
 }
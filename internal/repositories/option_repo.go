@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"dungeons-dragon-service/internal/domain/model"
 	"dungeons-dragon-service/internal/domain/repository"
 
@@ -17,79 +18,83 @@ func NewQuestLevelRepo(db *gorm.DB) repository.QuestLevelRepository {
 	return &questLevelRepo{db}
 }
 
-func (r *classRepo) Create(m *model.Class) (*model.Class, error) {
-	if err := r.db.Create(m).Error; err != nil {
+func (r *classRepo) Create(ctx context.Context, m *model.Class) (*model.Class, error) {
+	if err := r.db.WithContext(ctx).Create(m).Error; err != nil {
 		return nil, err
 	}
 	return m, nil
 }
-func (r *classRepo) Update(m *model.Class) (*model.Class, error) {
-	if err := r.db.Save(m).Error; err != nil {
+func (r *classRepo) Update(ctx context.Context, m *model.Class) (*model.Class, error) {
+	if err := r.db.WithContext(ctx).Save(m).Error; err != nil {
 		return nil, err
 	}
 	return m, nil
 }
-func (r *classRepo) Delete(id string) error { return r.db.Delete(&model.Class{}, id).Error }
-func (r *classRepo) FindByID(id string) (*model.Class, error) {
+func (r *classRepo) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Class{}).Error
+}
+func (r *classRepo) FindByID(ctx context.Context, id string) (*model.Class, error) {
 	var m model.Class
-	if err := r.db.Where("id = ?", id).First(&m).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&m).Error; err != nil {
 		return nil, err
 	}
 	return &m, nil
 }
-func (r *classRepo) List() ([]model.Class, error) {
+func (r *classRepo) List(ctx context.Context) ([]model.Class, error) {
 	var list []model.Class
-	return list, r.db.Order("name asc").Find(&list).Error
+	return list, r.db.WithContext(ctx).Order("name asc").Find(&list).Error
 }
 
-func (r *raceRepo) Create(m *model.Race) (*model.Race, error) {
-	if err := r.db.Create(m).Error; err != nil {
+func (r *raceRepo) Create(ctx context.Context, m *model.Race) (*model.Race, error) {
+	if err := r.db.WithContext(ctx).Create(m).Error; err != nil {
 		return nil, err
 	}
 	return m, nil
 }
-func (r *raceRepo) Update(m *model.Race) (*model.Race, error) {
-	if err := r.db.Save(m).Error; err != nil {
+func (r *raceRepo) Update(ctx context.Context, m *model.Race) (*model.Race, error) {
+	if err := r.db.WithContext(ctx).Save(m).Error; err != nil {
 		return nil, err
 	}
 	return m, nil
 }
-func (r *raceRepo) Delete(id string) error { return r.db.Delete(&model.Race{}, id).Error }
-func (r *raceRepo) FindByID(id string) (*model.Race, error) {
+func (r *raceRepo) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Race{}).Error
+}
+func (r *raceRepo) FindByID(ctx context.Context, id string) (*model.Race, error) {
 	var m model.Race
-	if err := r.db.Where("id = ?", id).First(&m).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&m).Error; err != nil {
 		return nil, err
 	}
 	return &m, nil
 }
-func (r *raceRepo) List() ([]model.Race, error) {
+func (r *raceRepo) List(ctx context.Context) ([]model.Race, error) {
 	var list []model.Race
-	return list, r.db.Order("name asc").Find(&list).Error
+	return list, r.db.WithContext(ctx).Order("name asc").Find(&list).Error
 }
 
-func (r *questLevelRepo) Create(m *model.QuestLevel) (*model.QuestLevel, error) {
-	if err := r.db.Create(m).Error; err != nil {
+func (r *questLevelRepo) Create(ctx context.Context, m *model.QuestLevel) (*model.QuestLevel, error) {
+	if err := r.db.WithContext(ctx).Create(m).Error; err != nil {
 		return nil, err
 	}
 	return m, nil
 }
-func (r *questLevelRepo) Update(m *model.QuestLevel) (*model.QuestLevel, error) {
-	if err := r.db.Save(m).Error; err != nil {
+func (r *questLevelRepo) Update(ctx context.Context, m *model.QuestLevel) (*model.QuestLevel, error) {
+	if err := r.db.WithContext(ctx).Save(m).Error; err != nil {
 		return nil, err
 	}
 	return m, nil
 }
-func (r *questLevelRepo) Delete(id string) error {
-	return r.db.Delete(&model.QuestLevel{}, id).Error
+func (r *questLevelRepo) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.QuestLevel{}).Error
 }
-func (r *questLevelRepo) FindByID(id string) (*model.QuestLevel, error) {
+func (r *questLevelRepo) FindByID(ctx context.Context, id string) (*model.QuestLevel, error) {
 	var m model.QuestLevel
-	if err := r.db.Where("id = ?", id).First(&m).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&m).Error; err != nil {
 		return nil, err
 	}
 	return &m, nil
 }
-func (r *questLevelRepo) List() ([]model.QuestLevel, error) {
+func (r *questLevelRepo) List(ctx context.Context) ([]model.QuestLevel, error) {
 	var list []model.QuestLevel
-	return list, r.db.Order("name asc").Find(&list).Error
+	return list, r.db.WithContext(ctx).Order("name asc").Find(&list).Error
 }
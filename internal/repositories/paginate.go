@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"dungeons-dragon-service/internal/domain/repository"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// sortable columns and the type their cursor value is cast to
+var sortColumns = map[string]string{
+	"created_at": "timestamptz",
+	"updated_at": "timestamptz",
+	"title":      "text",
+	"username":   "text",
+}
+
+// paginate applies ordering, keyset/offset paging and the limit to q.
+// Results of a backward cursor come back in reverse order; see reverse.
+func paginate(q *gorm.DB, p repository.PageRequest) *gorm.DB {
+	col := p.SortBy
+	cast, ok := sortColumns[col]
+	if !ok {
+		col, cast = "created_at", "timestamptz"
+	}
+	desc := p.Desc
+	if p.Cursor != nil && p.Cursor.Backward {
+		desc = !desc
+	}
+
+	dir, op := "asc", ">"
+	if desc {
+		dir, op = "desc", "<"
+	}
+	if p.Cursor != nil {
+		q = q.Where(fmt.Sprintf("(%s, id) %s (?::%s, ?::uuid)", col, op, cast), p.Cursor.Value, p.Cursor.ID)
+	} else if p.Offset > 0 {
+		q = q.Offset(p.Offset)
+	}
+	q = q.Order(col + " " + dir).Order("id " + dir)
+	if p.Limit > 0 {
+		q = q.Limit(p.Limit)
+	}
+	return q
+}
+
+// visible restricts q to the rows described by v.
+func visible(q *gorm.DB, v repository.Visibility) *gorm.DB {
+	if v.All {
+		return q
+	}
+	if v.OwnerID == "" {
+		return q.Where("privacy IN ? AND status IN ?", v.Privacy, v.Status)
+	}
+	return q.Where("(privacy IN ? AND status IN ?) OR user_id = ?", v.Privacy, v.Status, v.OwnerID)
+}
+
+func reverse[T any](list []T) {
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+}
@@ -1,10 +1,12 @@
 package repositories
 
 import (
+	"context"
 	"dungeons-dragon-service/internal/domain/model"
 	"dungeons-dragon-service/internal/domain/repository"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type questRepo struct{ db *gorm.DB }
@@ -13,43 +15,84 @@ func NewQuestRepo(db *gorm.DB) repository.QuestRepository {
 	return &questRepo{db: db}
 }
 
-func (r *questRepo) Create(m *model.Quest) (*model.Quest, error) {
-	if err := r.db.Create(m).Error; err != nil {
+func (r *questRepo) Create(ctx context.Context, m *model.Quest) (*model.Quest, error) {
+	if err := r.db.WithContext(ctx).Create(m).Error; err != nil {
 		return nil, err
 	}
 	return m, nil
 }
-func (r *questRepo) Update(m *model.Quest) (*model.Quest, error) {
-	if err := r.db.Save(m).Error; err != nil {
+func (r *questRepo) Update(ctx context.Context, m *model.Quest) (*model.Quest, error) {
+	// images and their image_path are owned by the image repository
+	if err := r.db.WithContext(ctx).Omit(clause.Associations, "ImagePath").Save(m).Error; err != nil {
 		return nil, err
 	}
 	return m, nil
 }
-func (r *questRepo) Delete(id string) error { return r.db.Delete(&model.Quest{}, id).Error }
-func (r *questRepo) FindByID(id string) (*model.Quest, error) {
+func (r *questRepo) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Quest{}).Error
+}
+func (r *questRepo) FindByID(ctx context.Context, id string) (*model.Quest, error) {
 	var m model.Quest
-	if err := r.db.Where("id = ?", id).First(&m).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Images", imagesByPosition).Where("id = ?", id).First(&m).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
 		return nil, err
 	}
 	return &m, nil
 }
-func (r *questRepo) ListAll() ([]model.Quest, error) {
+func (r *questRepo) ListAll(ctx context.Context) ([]model.Quest, error) {
 	var list []model.Quest
-	err := r.db.Where("status = ?", model.ItemStatusActive).Order("created_at desc").Find(&list).Error
+	err := r.db.WithContext(ctx).Preload("Images", imagesByPosition).Where("status = ?", model.ItemStatusActive).Order("created_at desc").Find(&list).Error
 	return list, err
 }
-func (r *questRepo) ListPublic() ([]model.Quest, error) {
+func (r *questRepo) ListPublic(ctx context.Context) ([]model.Quest, error) {
 	var list []model.Quest
-	err := r.db.Where("privacy = ? AND status = ?", model.PrivacyPublic, model.ItemStatusActive).Order("created_at desc").Find(&list).Error
+	err := r.db.WithContext(ctx).Preload("Images", imagesByPosition).Where("privacy = ? AND status = ?", model.PrivacyPublic, model.ItemStatusActive).Order("created_at desc").Find(&list).Error
 	return list, err
 }
-func (r *questRepo) ListByUser(userID string) ([]model.Quest, error) {
+func (r *questRepo) ListByUser(ctx context.Context, userID string) ([]model.Quest, error) {
 	var list []model.Quest
-	err := r.db.Where("user_id = ? AND status = ?", userID, model.ItemStatusActive).Order("created_at desc").Find(&list).Error
+	err := r.db.WithContext(ctx).Preload("Images", imagesByPosition).Where("user_id = ? AND status = ?", userID, model.ItemStatusActive).Order("created_at desc").Find(&list).Error
 	return list, err
 }
-func (r *questRepo) ArchiveByQuestLevelID(difficultyID string) error {
-	return r.db.Model(&model.Quest{}).
-		Where("difficulty_id = ? AND status = ?", difficultyID, model.ItemStatusActive).
+func (r *questRepo) ArchiveByQuestLevelID(ctx context.Context, questLevelID string) error {
+	return r.db.WithContext(ctx).Model(&model.Quest{}).
+		Where("quest_level_id = ? AND status = ?", questLevelID, model.ItemStatusActive).
+		Update("status", model.ItemStatusArchived).Error
+}
+func (r *questRepo) ArchiveByUserID(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Model(&model.Quest{}).
+		Where("user_id = ? AND status = ?", userID, model.ItemStatusActive).
 		Update("status", model.ItemStatusArchived).Error
 }
+func (r *questRepo) Search(ctx context.Context, f repository.QuestFilter, p repository.PageRequest) ([]model.Quest, int64, error) {
+	status := f.Status
+	if status == "" {
+		status = model.ItemStatusActive
+	}
+	q := r.db.WithContext(ctx).Model(&model.Quest{}).Where("status = ?", status)
+	q = visible(q, f.Visibility)
+	if f.Privacy != "" {
+		q = q.Where("privacy = ?", f.Privacy)
+	}
+	if f.QuestLevelID != "" {
+		q = q.Where("quest_level_id = ?", f.QuestLevelID)
+	}
+	if f.UserID != "" {
+		q = q.Where("user_id = ?", f.UserID)
+	}
+
+	var total int64
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []model.Quest
+	if err := paginate(q, p).Preload("Images", imagesByPosition).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	if p.Cursor != nil && p.Cursor.Backward {
+		reverse(list)
+	}
+	return list, total, nil
+}
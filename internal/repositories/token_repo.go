@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/repository"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type refreshTokenRepo struct{ db *gorm.DB }
+type revokedTokenRepo struct{ db *gorm.DB }
+type userTokenRepo struct{ db *gorm.DB }
+
+func NewRefreshTokenRepo(db *gorm.DB) repository.RefreshTokenRepository {
+	return &refreshTokenRepo{db: db}
+}
+func NewRevokedTokenRepo(db *gorm.DB) repository.RevokedTokenRepository {
+	return &revokedTokenRepo{db: db}
+}
+func NewUserTokenRepo(db *gorm.DB) repository.UserTokenRepository {
+	return &userTokenRepo{db: db}
+}
+
+func (r *refreshTokenRepo) Create(ctx context.Context, t *model.RefreshToken) (*model.RefreshToken, error) {
+	if err := r.db.WithContext(ctx).Create(t).Error; err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *refreshTokenRepo) FindByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	var t model.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&t).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *refreshTokenRepo) Rotate(ctx context.Context, current *model.RefreshToken, next *model.RefreshToken) (bool, error) {
+	rotated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&model.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Updates(map[string]any{"revoked_at": time.Now(), "replaced_by_id": next.ID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			// lost the race against another refresh, keep nothing
+			return gorm.ErrRecordNotFound
+		}
+		rotated = true
+		return nil
+	})
+	if err == gorm.ErrRecordNotFound {
+		return false, nil
+	}
+	return rotated, err
+}
+
+func (r *refreshTokenRepo) RevokeByHash(ctx context.Context, userID string, hash string) error {
+	return r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND token_hash = ? AND revoked_at IS NULL", userID, hash).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *refreshTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *refreshTokenRepo) RevokeAllForUser(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *revokedTokenRepo) Add(ctx context.Context, jti string, expiresAt time.Time) error {
+	db := r.db.WithContext(ctx)
+	// entries are only needed until the token would have expired anyway
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&model.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+}
+
+func (r *revokedTokenRepo) Exists(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *userTokenRepo) Create(ctx context.Context, t *model.UserToken) (*model.UserToken, error) {
+	if err := r.db.WithContext(ctx).Create(t).Error; err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *userTokenRepo) FindByHash(ctx context.Context, hash string) (*model.UserToken, error) {
+	var t model.UserToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&t).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *userTokenRepo) Consume(ctx context.Context, id string) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.UserToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *userTokenRepo) InvalidateForUser(ctx context.Context, userID string, purpose model.TokenPurpose) error {
+	return r.db.WithContext(ctx).Model(&model.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// NewRepositories builds every repository on db.
+func NewRepositories(db *gorm.DB) repository.Repositories {
+	return repository.Repositories{
+		Users:         NewUserRepo(db),
+		Classes:       NewClassRepo(db),
+		Races:         NewRaceRepo(db),
+		QuestLevels:   NewQuestLevelRepo(db),
+		Characters:    NewCharacterRepo(db),
+		Quests:        NewQuestRepo(db),
+		Images:        NewImageRepo(db),
+		RefreshTokens: NewRefreshTokenRepo(db),
+		RevokedTokens: NewRevokedTokenRepo(db),
+		UserTokens:    NewUserTokenRepo(db),
+	}
+}
+
+type txManager struct{ db *gorm.DB }
+
+func NewTxManager(db *gorm.DB) repository.TxManager {
+	return &txManager{db: db}
+}
+
+// WithinTx hands fn repositories built on the transaction. Repository methods
+// that open their own transaction run as savepoints inside it.
+func (m *txManager) WithinTx(ctx context.Context, fn func(repos repository.Repositories) error) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewRepositories(tx))
+	})
+}
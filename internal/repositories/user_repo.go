@@ -1,8 +1,10 @@
 package repositories
 
 import (
+	"context"
 	"dungeons-dragon-service/internal/domain/model"
 	"dungeons-dragon-service/internal/domain/repository"
+	"strings"
 
 	"gorm.io/gorm"
 )
@@ -13,15 +15,15 @@ func NewUserRepo(db *gorm.DB) repository.UserRepository {
 	return &userRepo{db: db}
 }
 
-func (r *userRepo) Create(u *model.User) (*model.User, error) {
-	if err := r.db.Create(u).Error; err != nil {
+func (r *userRepo) Create(ctx context.Context, u *model.User) (*model.User, error) {
+	if err := r.db.WithContext(ctx).Create(u).Error; err != nil {
 		return nil, err
 	}
 	return u, nil
 }
-func (r *userRepo) FindByEmail(email string) (*model.User, error) {
+func (r *userRepo) FindByEmail(ctx context.Context, email string) (*model.User, error) {
 	var u model.User
-	if err := r.db.Where("email = ?", email).First(&u).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&u).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
@@ -30,9 +32,9 @@ func (r *userRepo) FindByEmail(email string) (*model.User, error) {
 	return &u, nil
 }
 
-func (r *userRepo) FindByUsername(username string) (*model.User, error) {
+func (r *userRepo) FindByUsername(ctx context.Context, username string) (*model.User, error) {
 	var u model.User
-	if err := r.db.Where("username = ?", username).First(&u).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&u).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
@@ -40,10 +42,57 @@ func (r *userRepo) FindByUsername(username string) (*model.User, error) {
 	}
 	return &u, nil
 }
-func (r *userRepo) FindByID(id string) (*model.User, error) {
+func (r *userRepo) FindByID(ctx context.Context, id string) (*model.User, error) {
 	var u model.User
-	if err := r.db.Where("id = ?", id).First(&u).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&u).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
 		return nil, err
 	}
 	return &u, nil
 }
+
+func (r *userRepo) Update(ctx context.Context, u *model.User) (*model.User, error) {
+	if err := r.db.WithContext(ctx).Save(u).Error; err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Delete soft deletes the user. Username and email are unique among live
+// rows only, so both can be registered again.
+func (r *userRepo) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.User{}).Error
+}
+
+func (r *userRepo) Search(ctx context.Context, f repository.UserFilter, p repository.PageRequest) ([]model.User, int64, error) {
+	q := r.db.WithContext(ctx).Model(&model.User{})
+	if f.Query != "" {
+		like := "%" + strings.NewReplacer("%", "\\%", "_", "\\_").Replace(strings.ToLower(f.Query)) + "%"
+		q = q.Where("(LOWER(username) LIKE ? OR LOWER(email) LIKE ?)", like, like)
+	}
+	if f.Role != "" {
+		q = q.Where("role = ?", f.Role)
+	}
+	if f.Suspended != nil {
+		if *f.Suspended {
+			q = q.Where("suspended_at IS NOT NULL")
+		} else {
+			q = q.Where("suspended_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []model.User
+	if err := paginate(q, p).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	if p.Cursor != nil && p.Cursor.Backward {
+		reverse(list)
+	}
+	return list, total, nil
+}
@@ -0,0 +1,181 @@
+package usecases
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/port"
+	"dungeons-dragon-service/internal/helper"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockUserTokenRepo struct {
+	m map[string]*model.UserToken
+}
+
+func newMockUserTokenRepo() *mockUserTokenRepo {
+	return &mockUserTokenRepo{m: map[string]*model.UserToken{}}
+}
+
+func (m *mockUserTokenRepo) Create(_ context.Context, t *model.UserToken) (*model.UserToken, error) {
+	m.m[t.TokenHash] = t
+	return t, nil
+}
+
+func (m *mockUserTokenRepo) FindByHash(_ context.Context, hash string) (*model.UserToken, error) {
+	return m.m[hash], nil
+}
+
+func (m *mockUserTokenRepo) Consume(_ context.Context, id string) (bool, error) {
+	for _, t := range m.m {
+		if t.ID.String() == id && t.UsedAt == nil {
+			now := time.Now()
+			t.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockUserTokenRepo) InvalidateForUser(_ context.Context, userID string, purpose model.TokenPurpose) error {
+	now := time.Now()
+	for _, t := range m.m {
+		if t.UserID.String() == userID && t.Purpose == purpose && t.UsedAt == nil {
+			t.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+type mockMailer struct {
+	sent []port.Message
+	err  error
+	// gate, when set, holds every Send until it is closed
+	gate chan struct{}
+}
+
+func (m *mockMailer) Send(_ context.Context, msg port.Message) error {
+	if m.gate != nil {
+		<-m.gate
+	}
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var mailedToken = regexp.MustCompile(`token=(\S+)`)
+
+// lastToken extracts the token from the link in the last mail sent.
+func (m *mockMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	require.NotEmpty(t, m.sent)
+	match := mailedToken.FindStringSubmatch(m.sent[len(m.sent)-1].Body)
+	require.NotNil(t, match)
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return token
+}
+
+func TestRegisterSendsVerificationEmail(t *testing.T) {
+	f := newAuthHarness()
+	_, err := f.auth.Register(t.Context(), "hero", "not-an-email", "secret1")
+	require.EqualError(t, err, "invalid email or password")
+
+	_, err = f.auth.Register(t.Context(), "hero", "Hero@Example.com", "secret1")
+	require.NoError(t, err)
+	require.Len(t, f.mailer.sent, 1)
+	require.Equal(t, "hero@example.com", f.mailer.sent[0].To)
+	first := f.mailer.lastToken(t)
+	user, _ := f.users.FindByUsername(t.Context(), "hero")
+
+	// resending invalidates the first link
+	require.NoError(t, f.account.SendVerification(t.Context(), user.ID.String()))
+	second := f.mailer.lastToken(t)
+	require.EqualError(t, f.account.VerifyEmail(t.Context(), first), "invalid or expired token")
+
+	require.NoError(t, f.account.VerifyEmail(t.Context(), second))
+	require.NotNil(t, user.EmailVerifiedAt)
+	require.EqualError(t, f.account.VerifyEmail(t.Context(), second), "invalid or expired token")
+	require.EqualError(t, f.account.SendVerification(t.Context(), user.ID.String()), "email already verified")
+}
+
+func TestPasswordResetFlow(t *testing.T) {
+	f := newAuthHarness()
+	login, err := f.auth.Register(t.Context(), "hero", "hero@example.com", "secret1")
+	require.NoError(t, err)
+	verification := f.mailer.lastToken(t)
+
+	// unknown addresses succeed silently
+	sent := len(f.mailer.sent)
+	require.NoError(t, f.account.ForgotPassword(t.Context(), "nobody@example.com"))
+	f.waitForMail()
+	require.Len(t, f.mailer.sent, sent)
+
+	require.NoError(t, f.account.ForgotPassword(t.Context(), "HERO@example.com"))
+	f.waitForMail()
+	reset := f.mailer.lastToken(t)
+
+	// tokens are bound to their purpose
+	require.EqualError(t, f.account.ResetPassword(t.Context(), verification, "secret2"), "invalid or expired token")
+	require.NoError(t, f.account.ResetPassword(t.Context(), reset, "secret2"))
+	require.EqualError(t, f.account.ResetPassword(t.Context(), reset, "secret3"), "invalid or expired token")
+
+	user, _ := f.users.FindByUsername(t.Context(), "hero")
+	require.True(t, helper.VerifyPasswordArgon2("secret2", user.PasswordHash))
+	require.NotNil(t, user.EmailVerifiedAt)
+	_, err = f.auth.Refresh(t.Context(), login.RefreshToken)
+	require.Error(t, err)
+}
+
+func TestPasswordResetTokenExpires(t *testing.T) {
+	f := newAuthHarness()
+	_, err := f.auth.Register(t.Context(), "hero", "hero@example.com", "secret1")
+	require.NoError(t, err)
+	require.NoError(t, f.account.ForgotPassword(t.Context(), "hero@example.com"))
+	f.waitForMail()
+	reset := f.mailer.lastToken(t)
+
+	f.userTokens.m[hashToken(reset)].ExpiresAt = time.Now().Add(-time.Second)
+	require.EqualError(t, f.account.ResetPassword(t.Context(), reset, "secret2"), "invalid or expired token")
+}
+
+func TestForgotPasswordHidesMailerFailures(t *testing.T) {
+	f := newAuthHarness()
+	_, err := f.auth.Register(t.Context(), "hero", "hero@example.com", "secret1")
+	require.NoError(t, err)
+
+	// known and unknown addresses get the same answer when mail is down
+	f.mailer.err = errors.New("smtp: connection refused")
+	require.NoError(t, f.account.ForgotPassword(t.Context(), "hero@example.com"))
+	require.NoError(t, f.account.ForgotPassword(t.Context(), "nobody@example.com"))
+	f.waitForMail()
+	require.Empty(t, f.mailer.sent[1:])
+
+	// an explicit resend still reports the failure
+	user, _ := f.users.FindByUsername(t.Context(), "hero")
+	require.EqualError(t, f.account.SendVerification(t.Context(), user.ID.String()), "failed to send email")
+}
+
+func TestForgotPasswordAnswersBeforeMailing(t *testing.T) {
+	f := newAuthHarness()
+	_, err := f.auth.Register(t.Context(), "hero", "hero@example.com", "secret1")
+	require.NoError(t, err)
+
+	// the answer does not wait for the mail, so known addresses are not
+	// slower than unknown ones
+	f.mailer.gate = make(chan struct{})
+	require.NoError(t, f.account.ForgotPassword(t.Context(), "hero@example.com"))
+	require.Len(t, f.mailer.sent, 1)
+
+	close(f.mailer.gate)
+	f.waitForMail()
+	require.Len(t, f.mailer.sent, 2)
+	require.Equal(t, "Reset your password", f.mailer.sent[1].Subject)
+}
@@ -0,0 +1,237 @@
+package usecases
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/config"
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/port"
+	"dungeons-dragon-service/internal/domain/repository"
+	"dungeons-dragon-service/internal/helper"
+	"dungeons-dragon-service/internal/http/custom"
+	"dungeons-dragon-service/internal/infrastructure/logging"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultEmailVerificationTTL = 24 * time.Hour
+	defaultPasswordResetTTL     = time.Hour
+	// passwordResetTimeout bounds the work ForgotPassword does after it
+	// has answered
+	passwordResetTimeout = time.Minute
+)
+
+// AccountUseCase covers the flows that go through the user's mailbox.
+type AccountUseCase interface {
+	SendVerification(ctx context.Context, userID string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+}
+
+type accountUseCase struct {
+	users           repository.UserRepository
+	userTokens      repository.UserTokenRepository
+	refreshTokens   repository.RefreshTokenRepository
+	mailer          port.Mailer
+	verifyURL       string
+	resetURL        string
+	verificationTTL time.Duration
+	resetTTL        time.Duration
+	// pending tracks password reset mails still being sent
+	pending sync.WaitGroup
+}
+
+func NewAccountUseCase(users repository.UserRepository, userTokens repository.UserTokenRepository, refreshTokens repository.RefreshTokenRepository, mailer port.Mailer) AccountUseCase {
+	u := &accountUseCase{
+		users:           users,
+		userTokens:      userTokens,
+		refreshTokens:   refreshTokens,
+		mailer:          mailer,
+		verifyURL:       config.GetConfigString("EMAIL_VERIFICATION_URL"),
+		resetURL:        config.GetConfigString("PASSWORD_RESET_URL"),
+		verificationTTL: config.GetConfigDuration("EMAIL_VERIFICATION_TTL"),
+		resetTTL:        config.GetConfigDuration("PASSWORD_RESET_TTL"),
+	}
+	if u.verificationTTL <= 0 {
+		u.verificationTTL = defaultEmailVerificationTTL
+	}
+	if u.resetTTL <= 0 {
+		u.resetTTL = defaultPasswordResetTTL
+	}
+	return u
+}
+
+// SendVerification mails a new verification link; older links stop working.
+func (u *accountUseCase) SendVerification(ctx context.Context, userID string) error {
+	user, err := u.users.FindByID(ctx, userID)
+	if err != nil {
+		return custom.NewUnexpectedError("failed to get user")
+	}
+	if user == nil {
+		return custom.NewNotFoundError("user not found")
+	}
+	if user.EmailVerifiedAt != nil {
+		return custom.NewBadRequestError("email already verified")
+	}
+	token, err := u.issueToken(ctx, user, model.TokenPurposeEmailVerification, u.verificationTTL)
+	if err != nil {
+		return err
+	}
+	return u.send(ctx, user.Email, "Verify your email address", fmt.Sprintf(
+		"Hi %s,\n\nconfirm your email address by opening the link below. It is valid for %s.\n\n%s\n",
+		user.Username, u.verificationTTL, withToken(u.verifyURL, token)))
+}
+
+func (u *accountUseCase) VerifyEmail(ctx context.Context, token string) error {
+	user, err := u.consumeToken(ctx, token, model.TokenPurposeEmailVerification)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if _, err := u.users.Update(ctx, user); err != nil {
+			return custom.NewUnexpectedError("failed to verify email")
+		}
+	}
+	return nil
+}
+
+// ForgotPassword mails a reset link if the email belongs to an active
+// account. It succeeds either way so accounts cannot be discovered with it:
+// the lookup, the token and the mail happen in the background, so neither
+// the answer nor its timing depends on the address. Failures are logged.
+func (u *accountUseCase) ForgotPassword(ctx context.Context, email string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), passwordResetTimeout)
+	u.pending.Add(1)
+	go func() {
+		defer u.pending.Done()
+		defer cancel()
+		u.sendPasswordReset(ctx, email)
+	}()
+	return nil
+}
+
+func (u *accountUseCase) sendPasswordReset(ctx context.Context, email string) {
+	user, err := u.users.FindByEmail(ctx, strings.TrimSpace(strings.ToLower(email)))
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to look up user for password reset", "error", err)
+		return
+	}
+	if user == nil || user.SuspendedAt != nil {
+		return
+	}
+	token, err := u.issueToken(ctx, user, model.TokenPurposePasswordReset, u.resetTTL)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to issue password reset token", "user_id", user.ID, "error", err)
+		return
+	}
+	_ = u.send(ctx, user.Email, "Reset your password", fmt.Sprintf(
+		"Hi %s,\n\nsomeone asked to reset the password of your account. Open the link below to choose a new one;\nit is valid for %s. If it was not you, ignore this email.\n\n%s\n",
+		user.Username, u.resetTTL, withToken(u.resetURL, token)))
+}
+
+// ResetPassword sets a new password with a mailed token and signs the user
+// out everywhere. Receiving the mail also proves the address, so the email
+// counts as verified afterwards.
+func (u *accountUseCase) ResetPassword(ctx context.Context, token, password string) error {
+	if len(password) < 6 {
+		return custom.NewBadRequestError("password must be at least 6 characters")
+	}
+	user, err := u.consumeToken(ctx, token, model.TokenPurposePasswordReset)
+	if err != nil {
+		return err
+	}
+	salt, err := helper.GenerateSalt(16)
+	if err != nil {
+		return custom.NewUnexpectedError("failed to generate salt")
+	}
+	user.PasswordHash = helper.HashPasswordArgon2(password, salt)
+	user.PasswordResetRequired = false
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if _, err := u.users.Update(ctx, user); err != nil {
+		return custom.NewUnexpectedError("failed to update password")
+	}
+	if err := u.refreshTokens.RevokeAllForUser(ctx, user.ID.String()); err != nil {
+		return custom.NewUnexpectedError("failed to revoke sessions")
+	}
+	return nil
+}
+
+func (u *accountUseCase) issueToken(ctx context.Context, user *model.User, purpose model.TokenPurpose, ttl time.Duration) (string, error) {
+	if err := u.userTokens.InvalidateForUser(ctx, user.ID.String(), purpose); err != nil {
+		return "", custom.NewUnexpectedError("failed to invalidate old tokens")
+	}
+	raw, err := newOpaqueToken()
+	if err != nil {
+		return "", custom.NewUnexpectedError("failed to generate token")
+	}
+	m := &model.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	m.ID = uuid.New()
+	if _, err := u.userTokens.Create(ctx, m); err != nil {
+		return "", custom.NewUnexpectedError("failed to store token")
+	}
+	return raw, nil
+}
+
+// consumeToken uses up a mailed token and returns its user. Unknown, used,
+// expired and wrong purpose tokens all fail the same way.
+func (u *accountUseCase) consumeToken(ctx context.Context, token string, purpose model.TokenPurpose) (*model.User, error) {
+	invalid := custom.NewBadRequestError("invalid or expired token")
+	if token == "" {
+		return nil, invalid
+	}
+	t, err := u.userTokens.FindByHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, custom.NewUnexpectedError("failed to look up token")
+	}
+	if t == nil || t.Purpose != purpose || t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+		return nil, invalid
+	}
+	consumed, err := u.userTokens.Consume(ctx, t.ID.String())
+	if err != nil {
+		return nil, custom.NewUnexpectedError("failed to use token")
+	}
+	if !consumed {
+		return nil, invalid
+	}
+	user, err := u.users.FindByID(ctx, t.UserID.String())
+	if err != nil {
+		return nil, custom.NewUnexpectedError("failed to get user")
+	}
+	if user == nil {
+		return nil, invalid
+	}
+	return user, nil
+}
+
+// send mails body and logs why a mail could not be sent.
+func (u *accountUseCase) send(ctx context.Context, to, subject, body string) error {
+	if err := u.mailer.Send(ctx, port.Message{To: to, Subject: subject, Body: body}); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to send email", "subject", subject, "error", err)
+		return custom.NewUnexpectedError("failed to send email")
+	}
+	return nil
+}
+
+func withToken(link, token string) string {
+	sep := "?"
+	if strings.Contains(link, "?") {
+		sep = "&"
+	}
+	return link + sep + "token=" + url.QueryEscape(token)
+}
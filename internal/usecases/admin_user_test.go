@@ -0,0 +1,102 @@
+package usecases
+
+import (
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/dto"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminRoleChangeAppliesToExistingSessions(t *testing.T) {
+	f := newAuthHarness()
+	adminID := f.register(t, "boss")
+	userID := f.register(t, "hero")
+
+	session, err := f.auth.ResolveSession(t.Context(), userID, uuid.NewString())
+	require.NoError(t, err)
+	require.Equal(t, model.RoleUser, session.Role)
+
+	_, err = f.admin.SetRole(t.Context(), adminID, userID, model.RoleAdmin)
+	require.NoError(t, err)
+	session, err = f.auth.ResolveSession(t.Context(), userID, uuid.NewString())
+	require.NoError(t, err)
+	require.Equal(t, model.RoleAdmin, session.Role)
+
+	_, err = f.admin.SetRole(t.Context(), adminID, adminID, model.RoleUser)
+	require.EqualError(t, err, "cannot change your own role")
+	_, err = f.admin.SetRole(t.Context(), adminID, uuid.NewString(), model.RoleUser)
+	require.EqualError(t, err, "user not found")
+}
+
+func TestAdminSuspendBlocksLoginAndSessions(t *testing.T) {
+	f := newAuthHarness()
+	adminID := f.register(t, "boss")
+	userID := f.register(t, "hero")
+	login, err := f.auth.Login(t.Context(), "hero", "secret1")
+	require.NoError(t, err)
+
+	res, err := f.admin.Suspend(t.Context(), adminID, userID)
+	require.NoError(t, err)
+	require.NotNil(t, res.SuspendedAt)
+
+	_, err = f.auth.ResolveSession(t.Context(), userID, uuid.NewString())
+	require.EqualError(t, err, "account suspended")
+	_, err = f.auth.Login(t.Context(), "hero", "secret1")
+	require.EqualError(t, err, "account suspended")
+	_, err = f.auth.Refresh(t.Context(), login.RefreshToken)
+	require.Error(t, err)
+
+	_, err = f.admin.Unsuspend(t.Context(), userID)
+	require.NoError(t, err)
+	_, err = f.auth.Login(t.Context(), "hero", "secret1")
+	require.NoError(t, err)
+}
+
+func TestAdminForcePasswordReset(t *testing.T) {
+	f := newAuthHarness()
+	adminID := f.register(t, "boss")
+	userID := f.register(t, "hero")
+
+	res, err := f.admin.ForcePasswordReset(t.Context(), adminID, userID)
+	require.NoError(t, err)
+	_, err = f.auth.Login(t.Context(), "hero", "secret1")
+	require.Error(t, err)
+	_, err = f.auth.Login(t.Context(), "hero", res.TemporaryPassword)
+	require.NoError(t, err)
+	session, err := f.auth.ResolveSession(t.Context(), userID, uuid.NewString())
+	require.NoError(t, err)
+	require.True(t, session.PasswordResetRequired)
+
+	require.NoError(t, f.profile.ChangePassword(t.Context(), userID, "", time.Time{}, res.TemporaryPassword, "secret2"))
+	session, err = f.auth.ResolveSession(t.Context(), userID, uuid.NewString())
+	require.NoError(t, err)
+	require.False(t, session.PasswordResetRequired)
+}
+
+func TestAdminUserListFilters(t *testing.T) {
+	f := newAuthHarness()
+	adminID := f.register(t, "boss")
+	f.register(t, "hero")
+	mageID := f.register(t, "mage")
+	_, err := f.admin.SetRole(t.Context(), adminID, mageID, model.RoleAdmin)
+	require.NoError(t, err)
+	_, err = f.admin.Suspend(t.Context(), adminID, mageID)
+	require.NoError(t, err)
+
+	list, paginate, err := f.admin.List(t.Context(), &dto.UserListQuery{Q: "HER"})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, "hero", list[0].Username)
+	require.EqualValues(t, 1, paginate.Total)
+
+	list, _, err = f.admin.List(t.Context(), &dto.UserListQuery{Role: model.RoleAdmin, Suspended: "true"})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, mageID, list[0].ID)
+
+	_, _, err = f.admin.List(t.Context(), &dto.UserListQuery{ListQuery: dto.ListQuery{Sort: "title"}})
+	require.EqualError(t, err, "invalid sort field")
+}
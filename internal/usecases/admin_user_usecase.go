@@ -0,0 +1,177 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/repository"
+	"dungeons-dragon-service/internal/dto"
+	"dungeons-dragon-service/internal/helper"
+	"dungeons-dragon-service/internal/http/custom"
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+type AdminUserUseCase interface {
+	List(ctx context.Context, q *dto.UserListQuery) ([]dto.AdminUserResponse, *dto.PaginateResponse, error)
+	Get(ctx context.Context, id string) (*dto.AdminUserResponse, error)
+	SetRole(ctx context.Context, adminID, id string, role model.Role) (*dto.AdminUserResponse, error)
+	Suspend(ctx context.Context, adminID, id string) (*dto.AdminUserResponse, error)
+	Unsuspend(ctx context.Context, id string) (*dto.AdminUserResponse, error)
+	ForcePasswordReset(ctx context.Context, adminID, id string) (*dto.PasswordResetResponse, error)
+}
+
+type adminUserUseCase struct {
+	users  repository.UserRepository
+	tokens repository.RefreshTokenRepository
+}
+
+func NewAdminUserUseCase(users repository.UserRepository, tokens repository.RefreshTokenRepository) AdminUserUseCase {
+	return &adminUserUseCase{users: users, tokens: tokens}
+}
+
+func ResponseAdminUser(user *model.User) dto.AdminUserResponse {
+	return dto.AdminUserResponse{
+		ID:                    user.ID.String(),
+		Username:              user.Username,
+		Email:                 user.Email,
+		Role:                  string(user.Role),
+		SuspendedAt:           user.SuspendedAt,
+		PasswordResetRequired: user.PasswordResetRequired,
+		CreatedAt:             user.CreatedAt,
+	}
+}
+
+func (u *adminUserUseCase) List(ctx context.Context, q *dto.UserListQuery) ([]dto.AdminUserResponse, *dto.PaginateResponse, error) {
+	pq, err := newPageQuery(q.ListQuery, userSortFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter := repository.UserFilter{Query: strings.TrimSpace(q.Q), Role: q.Role}
+	if q.Suspended != "" {
+		suspended := q.Suspended == "true"
+		filter.Suspended = &suspended
+	}
+	list, total, err := u.users.Search(ctx, filter, pq.req)
+	if err != nil {
+		return nil, nil, custom.NewUnexpectedError("failed to list users")
+	}
+	list, paginate := paginateResult(pq, list, total, func(m model.User) (string, string) {
+		return sortValue(pq.req.SortBy, m.Username, m.CreatedAt, m.UpdatedAt), m.ID.String()
+	})
+	res := make([]dto.AdminUserResponse, len(list))
+	for i := range list {
+		res[i] = ResponseAdminUser(&list[i])
+	}
+	return res, paginate, nil
+}
+
+func (u *adminUserUseCase) Get(ctx context.Context, id string) (*dto.AdminUserResponse, error) {
+	user, err := u.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	res := ResponseAdminUser(user)
+	return &res, nil
+}
+
+// SetRole promotes or demotes a user. The new role applies to existing access
+// tokens right away because sessions are resolved from the database.
+func (u *adminUserUseCase) SetRole(ctx context.Context, adminID, id string, role model.Role) (*dto.AdminUserResponse, error) {
+	if role != model.RoleUser && role != model.RoleAdmin {
+		return nil, custom.NewBadRequestError("invalid role")
+	}
+	if adminID == id {
+		return nil, custom.NewBadRequestError("cannot change your own role")
+	}
+	user, err := u.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return u.save(ctx, user)
+}
+
+// Suspend blocks the user from logging in and from using existing tokens.
+func (u *adminUserUseCase) Suspend(ctx context.Context, adminID, id string) (*dto.AdminUserResponse, error) {
+	if adminID == id {
+		return nil, custom.NewBadRequestError("cannot suspend yourself")
+	}
+	user, err := u.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.SuspendedAt == nil {
+		now := time.Now()
+		user.SuspendedAt = &now
+	}
+	res, err := u.save(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.tokens.RevokeAllForUser(ctx, id); err != nil {
+		return nil, custom.NewUnexpectedError("failed to revoke sessions")
+	}
+	return res, nil
+}
+
+func (u *adminUserUseCase) Unsuspend(ctx context.Context, id string) (*dto.AdminUserResponse, error) {
+	user, err := u.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user.SuspendedAt = nil
+	return u.save(ctx, user)
+}
+
+// ForcePasswordReset replaces the password with a random temporary one and
+// signs the user out. Until the password is changed the account can only be
+// used to change it.
+func (u *adminUserUseCase) ForcePasswordReset(ctx context.Context, adminID, id string) (*dto.PasswordResetResponse, error) {
+	if adminID == id {
+		return nil, custom.NewBadRequestError("cannot force a password reset on yourself")
+	}
+	user, err := u.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return nil, custom.NewUnexpectedError("failed to generate password")
+	}
+	password := base64.RawURLEncoding.EncodeToString(b)
+	salt, err := helper.GenerateSalt(16)
+	if err != nil {
+		return nil, custom.NewUnexpectedError("failed to generate salt")
+	}
+	user.PasswordHash = helper.HashPasswordArgon2(password, salt)
+	user.PasswordResetRequired = true
+	if _, err := u.save(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := u.tokens.RevokeAllForUser(ctx, id); err != nil {
+		return nil, custom.NewUnexpectedError("failed to revoke sessions")
+	}
+	return &dto.PasswordResetResponse{TemporaryPassword: password}, nil
+}
+
+func (u *adminUserUseCase) findUser(ctx context.Context, id string) (*model.User, error) {
+	user, err := u.users.FindByID(ctx, id)
+	if err != nil {
+		return nil, custom.NewUnexpectedError("failed to get user")
+	}
+	if user == nil {
+		return nil, custom.NewNotFoundError("user not found")
+	}
+	return user, nil
+}
+
+func (u *adminUserUseCase) save(ctx context.Context, user *model.User) (*dto.AdminUserResponse, error) {
+	if _, err := u.users.Update(ctx, user); err != nil {
+		return nil, custom.NewUnexpectedError("failed to update user")
+	}
+	res := ResponseAdminUser(user)
+	return &res, nil
+}
@@ -0,0 +1,330 @@
+package usecases
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/repository"
+	"dungeons-dragon-service/internal/http/custom"
+	"dungeons-dragon-service/internal/infrastructure/jwt"
+	"dungeons-dragon-service/internal/infrastructure/ratelimit"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type mockUserRepo struct {
+	m map[string]*model.User
+}
+
+func newMockUserRepo() *mockUserRepo {
+	return &mockUserRepo{m: map[string]*model.User{}}
+}
+
+func (m *mockUserRepo) Create(_ context.Context, u *model.User) (*model.User, error) {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	if err := m.unique(u); err != nil {
+		return nil, err
+	}
+	m.m[u.ID.String()] = u
+	return u, nil
+}
+
+// unique mirrors the unique indexes on username and email, which only cover
+// rows that are not deleted.
+func (m *mockUserRepo) unique(u *model.User) error {
+	for id, other := range m.m {
+		if id != u.ID.String() && !other.DeletedAt.Valid && (other.Username == u.Username || other.Email == u.Email) {
+			return errors.New("duplicate key value violates unique constraint")
+		}
+	}
+	return nil
+}
+
+func (m *mockUserRepo) FindByEmail(_ context.Context, email string) (*model.User, error) {
+	for _, u := range m.m {
+		if u.Email == email && !u.DeletedAt.Valid {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockUserRepo) FindByUsername(_ context.Context, username string) (*model.User, error) {
+	for _, u := range m.m {
+		if u.Username == username && !u.DeletedAt.Valid {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockUserRepo) FindByID(_ context.Context, id string) (*model.User, error) {
+	if u := m.m[id]; u != nil && !u.DeletedAt.Valid {
+		return u, nil
+	}
+	return nil, nil
+}
+
+func (m *mockUserRepo) Update(_ context.Context, u *model.User) (*model.User, error) {
+	if err := m.unique(u); err != nil {
+		return nil, err
+	}
+	m.m[u.ID.String()] = u
+	return u, nil
+}
+
+// Delete soft deletes like the real repository; the row stays behind.
+func (m *mockUserRepo) Delete(_ context.Context, id string) error {
+	if u := m.m[id]; u != nil {
+		deleted := *u
+		deleted.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		m.m[id] = &deleted
+	}
+	return nil
+}
+
+func (m *mockUserRepo) Search(_ context.Context, f repository.UserFilter, p repository.PageRequest) ([]model.User, int64, error) {
+	var res []model.User
+	for _, u := range m.m {
+		q := strings.ToLower(f.Query)
+		if u.DeletedAt.Valid ||
+			(q != "" && !strings.Contains(strings.ToLower(u.Username), q) && !strings.Contains(strings.ToLower(u.Email), q)) ||
+			(f.Role != "" && u.Role != f.Role) ||
+			(f.Suspended != nil && *f.Suspended != (u.SuspendedAt != nil)) {
+			continue
+		}
+		res = append(res, *u)
+	}
+	return pageSlice(res, p), int64(len(res)), nil
+}
+
+type mockRefreshTokenRepo struct {
+	m map[string]*model.RefreshToken
+}
+
+func newMockRefreshTokenRepo() *mockRefreshTokenRepo {
+	return &mockRefreshTokenRepo{m: map[string]*model.RefreshToken{}}
+}
+
+func (m *mockRefreshTokenRepo) Create(_ context.Context, t *model.RefreshToken) (*model.RefreshToken, error) {
+	m.m[t.TokenHash] = t
+	return t, nil
+}
+
+func (m *mockRefreshTokenRepo) FindByHash(_ context.Context, hash string) (*model.RefreshToken, error) {
+	return m.m[hash], nil
+}
+
+func (m *mockRefreshTokenRepo) Rotate(_ context.Context, current *model.RefreshToken, next *model.RefreshToken) (bool, error) {
+	if current.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	current.RevokedAt = &now
+	current.ReplacedByID = &next.ID
+	m.m[next.TokenHash] = next
+	return true, nil
+}
+
+func (m *mockRefreshTokenRepo) revokeWhere(match func(*model.RefreshToken) bool) error {
+	now := time.Now()
+	for _, t := range m.m {
+		if t.RevokedAt == nil && match(t) {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *mockRefreshTokenRepo) RevokeByHash(_ context.Context, userID string, hash string) error {
+	return m.revokeWhere(func(t *model.RefreshToken) bool { return t.UserID.String() == userID && t.TokenHash == hash })
+}
+
+func (m *mockRefreshTokenRepo) RevokeFamily(_ context.Context, familyID string) error {
+	return m.revokeWhere(func(t *model.RefreshToken) bool { return t.FamilyID.String() == familyID })
+}
+
+func (m *mockRefreshTokenRepo) RevokeAllForUser(_ context.Context, userID string) error {
+	return m.revokeWhere(func(t *model.RefreshToken) bool { return t.UserID.String() == userID })
+}
+
+type mockRevokedTokenRepo struct {
+	m map[string]time.Time
+}
+
+func (m *mockRevokedTokenRepo) Add(_ context.Context, jti string, expiresAt time.Time) error {
+	m.m[jti] = expiresAt
+	return nil
+}
+
+func (m *mockRevokedTokenRepo) Exists(_ context.Context, jti string) (bool, error) {
+	_, ok := m.m[jti]
+	return ok, nil
+}
+
+// authHarness wires the auth, account, admin and user usecases to one set of
+// in-memory repositories, so a test can act through one usecase and check
+// the effect through another.
+type authHarness struct {
+	auth    AuthUseCase
+	account AccountUseCase
+	admin   AdminUserUseCase
+	profile UserUseCase
+
+	users      *mockUserRepo
+	tokens     *mockRefreshTokenRepo
+	revoked    *mockRevokedTokenRepo
+	userTokens *mockUserTokenRepo
+	chars      *mockCharRepo
+	quests     *mockQuestRepo
+	mailer     *mockMailer
+	attempts   *ratelimit.MemoryStore
+}
+
+func newAuthHarness() *authHarness {
+	h := &authHarness{
+		users:      newMockUserRepo(),
+		tokens:     newMockRefreshTokenRepo(),
+		revoked:    &mockRevokedTokenRepo{m: map[string]time.Time{}},
+		userTokens: newMockUserTokenRepo(),
+		chars:      newMockCharRepo(),
+		quests:     &mockQuestRepo{quests: map[string]*model.Quest{}},
+		mailer:     &mockMailer{},
+		attempts:   ratelimit.NewMemoryStore(),
+	}
+	h.account = NewAccountUseCase(h.users, h.userTokens, h.tokens, h.mailer)
+	h.auth = NewAuthUsecase(h.users, h.tokens, h.revoked, jwt.NewManager(jwt.NewHMACKeySet("test-secret"), jwt.Options{}), h.account, h.attempts)
+	h.admin = NewAdminUserUseCase(h.users, h.tokens)
+	h.profile = NewUserUseCase(h.users, h.tokens, h.revoked, &memTx{repos: repository.Repositories{
+		Users: h.users, Characters: h.chars, Quests: h.quests, RefreshTokens: h.tokens, RevokedTokens: h.revoked,
+	}})
+	return h
+}
+
+// waitForMail waits for the password reset mails sent in the background.
+func (h *authHarness) waitForMail() {
+	h.account.(*accountUseCase).pending.Wait()
+}
+
+// register signs up username with a derived email and the password "secret1"
+// and returns the new user's ID.
+func (h *authHarness) register(t *testing.T, username string) string {
+	t.Helper()
+	_, err := h.auth.Register(t.Context(), username, username+"@example.com", "secret1")
+	require.NoError(t, err)
+	u, _ := h.users.FindByUsername(t.Context(), username)
+	return u.ID.String()
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	h := newAuthHarness()
+	uc, tokens := h.auth, h.tokens
+
+	login, err := uc.Register(t.Context(), "hero", "hero@example.com", "secret1")
+	require.NoError(t, err)
+	require.NotEmpty(t, login.RefreshToken)
+
+	rotated, err := uc.Refresh(t.Context(), login.RefreshToken)
+	require.NoError(t, err)
+	require.NotEqual(t, login.RefreshToken, rotated.RefreshToken)
+
+	// the first token was rotated away: replaying it revokes the whole family
+	_, err = uc.Refresh(t.Context(), login.RefreshToken)
+	require.EqualError(t, err, "refresh token reuse detected")
+
+	_, err = uc.Refresh(t.Context(), rotated.RefreshToken)
+	require.Error(t, err)
+	for _, tok := range tokens.m {
+		require.NotNil(t, tok.RevokedAt)
+	}
+}
+
+func TestRefreshRejectsUnknownAndExpired(t *testing.T) {
+	h := newAuthHarness()
+	uc, tokens := h.auth, h.tokens
+
+	_, err := uc.Refresh(t.Context(), "does-not-exist")
+	require.EqualError(t, err, "invalid refresh token")
+
+	login, err := uc.Register(t.Context(), "hero", "hero@example.com", "secret1")
+	require.NoError(t, err)
+	tokens.m[hashToken(login.RefreshToken)].ExpiresAt = time.Now().Add(-time.Minute)
+	_, err = uc.Refresh(t.Context(), login.RefreshToken)
+	require.EqualError(t, err, "refresh token expired")
+}
+
+func TestLogoutRevokesTokens(t *testing.T) {
+	h := newAuthHarness()
+	uc, tokens := h.auth, h.tokens
+
+	first, err := uc.Register(t.Context(), "hero", "hero@example.com", "secret1")
+	require.NoError(t, err)
+	second, err := uc.Login(t.Context(), "hero", "secret1")
+	require.NoError(t, err)
+	userID := tokens.m[hashToken(first.RefreshToken)].UserID.String()
+
+	jti := uuid.NewString()
+	require.NoError(t, uc.Logout(t.Context(), userID, jti, time.Now().Add(time.Minute), first.RefreshToken))
+	_, err = uc.ResolveSession(t.Context(), userID, jti)
+	require.EqualError(t, err, "token revoked")
+
+	_, err = uc.Refresh(t.Context(), first.RefreshToken)
+	require.Error(t, err)
+	// the other session is untouched by a single logout
+	second, err = uc.Refresh(t.Context(), second.RefreshToken)
+	require.NoError(t, err)
+
+	require.NoError(t, uc.LogoutAll(t.Context(), userID, uuid.NewString(), time.Now().Add(time.Minute)))
+	_, err = uc.Refresh(t.Context(), second.RefreshToken)
+	require.Error(t, err)
+}
+
+func TestLoginIsUniformAndLocksOut(t *testing.T) {
+	h := newAuthHarness()
+	uc, attempts := h.auth, h.attempts
+	_, err := uc.Register(t.Context(), "hero", "hero@example.com", "secret1")
+	require.NoError(t, err)
+
+	// unknown user and wrong password look the same
+	_, unknownErr := uc.Login(t.Context(), "nobody", "secret1")
+	_, wrongErr := uc.Login(t.Context(), "hero", "wrong")
+	require.Equal(t, unknownErr, wrongErr)
+	var appErr *custom.AppError
+	require.ErrorAs(t, wrongErr, &appErr)
+	require.Equal(t, http.StatusUnauthorized, appErr.Code)
+
+	for i := 0; i < 3; i++ {
+		_, err = uc.Login(t.Context(), "hero", "wrong")
+		require.EqualError(t, err, "invalid username or password")
+	}
+	// the fifth failure locks the account, even for the right password
+	_, err = uc.Login(t.Context(), "hero", "wrong")
+	require.ErrorAs(t, err, &appErr)
+	require.Equal(t, http.StatusTooManyRequests, appErr.Code)
+	require.Equal(t, time.Minute, appErr.RetryAfter)
+	_, err = uc.Login(t.Context(), "HERO", "secret1")
+	require.ErrorAs(t, err, &appErr)
+	require.Equal(t, http.StatusTooManyRequests, appErr.Code)
+
+	// once the lock expires, every further failure doubles it
+	_, lockKey := loginKeys("hero")
+	require.NoError(t, attempts.Reset(lockKey))
+	_, err = uc.Login(t.Context(), "hero", "wrong")
+	require.ErrorAs(t, err, &appErr)
+	require.Equal(t, 2*time.Minute, appErr.RetryAfter)
+
+	// a successful login clears the failures
+	require.NoError(t, attempts.Reset(lockKey))
+	_, err = uc.Login(t.Context(), "hero", "secret1")
+	require.NoError(t, err)
+	_, err = uc.Login(t.Context(), "hero", "wrong")
+	require.EqualError(t, err, "invalid username or password")
+}
@@ -1,37 +1,120 @@
 package usecases
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"dungeons-dragon-service/internal/config"
 	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/port"
 	"dungeons-dragon-service/internal/domain/repository"
 	"dungeons-dragon-service/internal/dto"
 	"dungeons-dragon-service/internal/helper"
 	"dungeons-dragon-service/internal/http/custom"
 	"dungeons-dragon-service/internal/infrastructure/jwt"
+	"encoding/base64"
+	"encoding/hex"
+	"net/mail"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+	defaultLoginMaxFailures   = 5
+	defaultLoginFailureWindow = time.Hour
+	defaultLoginLockout       = time.Minute
+	defaultLoginLockoutMax    = time.Hour
+)
+
+// loginLockout configures progressive lockout: after MaxFailures failed
+// logins within Window the account is locked for Lockout, doubling with
+// every further failure up to LockoutMax.
+type loginLockout struct {
+	MaxFailures int64
+	Window      time.Duration
+	Lockout     time.Duration
+	LockoutMax  time.Duration
+}
+
 type AuthUseCase interface {
-	Register(username, email, password string) (*dto.LoginResponse, error)
-	Login(username, password string) (*dto.LoginResponse, error)
+	Register(ctx context.Context, username, email, password string) (*dto.LoginResponse, error)
+	Login(ctx context.Context, username, password string) (*dto.LoginResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (*dto.LoginResponse, error)
+	Logout(ctx context.Context, userID, jti string, expiresAt time.Time, refreshToken string) error
+	LogoutAll(ctx context.Context, userID, jti string, expiresAt time.Time) error
+	ResolveSession(ctx context.Context, userID, jti string) (*model.Session, error)
 }
 
 type authUseCase struct {
-	users     repository.UserRepository
-	jwtSecret string
+	sessionResolver
+	account    AccountUseCase
+	attempts   port.CounterStore
+	lockout    loginLockout
+	dummyHash  string
+	users      repository.UserRepository
+	tokens     repository.RefreshTokenRepository
+	revoked    repository.RevokedTokenRepository
+	jwt        *jwt.Manager
+	accessTTL  time.Duration
+	refreshTTL time.Duration
 }
 
-func NewAuthUsecase(users repository.UserRepository, jwtSecret string) AuthUseCase {
-	return &authUseCase{users: users, jwtSecret: jwtSecret}
+func NewAuthUsecase(users repository.UserRepository, tokens repository.RefreshTokenRepository, revoked repository.RevokedTokenRepository, jwtManager *jwt.Manager, account AccountUseCase, attempts port.CounterStore) AuthUseCase {
+	u := &authUseCase{
+		sessionResolver: sessionResolver{users: users, revoked: revoked},
+		account:         account,
+		attempts:        attempts,
+		lockout: loginLockout{
+			MaxFailures: config.GetConfigInt64("LOGIN_MAX_FAILURES"),
+			Window:      config.GetConfigDuration("LOGIN_FAILURE_WINDOW"),
+			Lockout:     config.GetConfigDuration("LOGIN_LOCKOUT"),
+			LockoutMax:  config.GetConfigDuration("LOGIN_LOCKOUT_MAX"),
+		},
+		users:      users,
+		tokens:     tokens,
+		revoked:    revoked,
+		jwt:        jwtManager,
+		accessTTL:  config.GetConfigDuration("ACCESS_TOKEN_TTL"),
+		refreshTTL: config.GetConfigDuration("REFRESH_TOKEN_TTL"),
+	}
+	if u.accessTTL <= 0 {
+		u.accessTTL = defaultAccessTokenTTL
+	}
+	if u.refreshTTL <= 0 {
+		u.refreshTTL = defaultRefreshTokenTTL
+	}
+	if u.lockout.MaxFailures <= 0 {
+		u.lockout.MaxFailures = defaultLoginMaxFailures
+	}
+	if u.lockout.Window <= 0 {
+		u.lockout.Window = defaultLoginFailureWindow
+	}
+	if u.lockout.Lockout <= 0 {
+		u.lockout.Lockout = defaultLoginLockout
+	}
+	if u.lockout.LockoutMax < u.lockout.Lockout {
+		u.lockout.LockoutMax = max(defaultLoginLockoutMax, u.lockout.Lockout)
+	}
+	// verified against when the username does not exist, so unknown users
+	// take as long to reject as wrong passwords
+	if salt, err := helper.GenerateSalt(16); err == nil {
+		u.dummyHash = helper.HashPasswordArgon2(uuid.NewString(), salt)
+	}
+	return u
 }
 
-func (u *authUseCase) Register(username, email, password string) (*dto.LoginResponse, error) {
+func (u *authUseCase) Register(ctx context.Context, username, email, password string) (*dto.LoginResponse, error) {
 	email = strings.TrimSpace(strings.ToLower(email))
-	if email == "" || len(password) < 6 {
+	if !validEmail(email) || len(password) < 6 {
 		return nil, custom.NewBadRequestError("invalid email or password")
 	}
 	// Check if user already exists
-	existingUser, err := u.users.FindByEmail(email)
+	existingUser, err := u.users.FindByEmail(ctx, email)
 	if err != nil {
 		return nil, custom.NewUnexpectedError("failed to check if email exists")
 	}
@@ -39,7 +122,7 @@ func (u *authUseCase) Register(username, email, password string) (*dto.LoginResp
 		return nil, custom.NewConflictError("email already exists")
 	}
 	// check username
-	existingUser, err = u.users.FindByUsername(username)
+	existingUser, err = u.users.FindByUsername(ctx, username)
 	if err != nil {
 		return nil, custom.NewUnexpectedError("failed to check if username exists")
 	}
@@ -52,7 +135,7 @@ func (u *authUseCase) Register(username, email, password string) (*dto.LoginResp
 		return nil, custom.NewUnexpectedError("failed to generate salt")
 	}
 	hash := helper.HashPasswordArgon2(password, salt)
-	user, err := u.users.Create(&model.User{
+	user, err := u.users.Create(ctx, &model.User{
 		Username:     username,
 		Email:        email,
 		PasswordHash: hash,
@@ -61,25 +144,204 @@ func (u *authUseCase) Register(username, email, password string) (*dto.LoginResp
 	if err != nil {
 		return nil, custom.NewUnexpectedError("failed to create user")
 	}
-	token, err := jwt.GenerateToken(u.jwtSecret, user.ID.String(), string(model.RoleUser), 24*time.Hour)
+	// a failed mail does not fail the registration; the user can ask for a
+	// new link with /auth/verify-email/resend
+	_ = u.account.SendVerification(ctx, user.ID.String())
+	return u.issueTokens(ctx, user, uuid.New())
+}
+
+// Login answers unknown usernames and wrong passwords with the same 401.
+// Repeated failures lock the username out, whether the account exists or not.
+func (u *authUseCase) Login(ctx context.Context, username, password string) (*dto.LoginResponse, error) {
+	failKey, lockKey := loginKeys(username)
+	if locked, retryAfter, err := u.attempts.Get(lockKey); err == nil && locked > 0 {
+		return nil, custom.NewTooManyRequestsError("too many failed login attempts", retryAfter)
+	}
+
+	user, err := u.users.FindByUsername(ctx, username)
 	if err != nil {
-		return nil, custom.NewUnexpectedError("failed to generate token")
+		return nil, custom.NewUnexpectedError("failed to look up user")
 	}
-	return &dto.LoginResponse{Token: token}, nil
+	hash := u.dummyHash
+	if user != nil {
+		hash = user.PasswordHash
+	}
+	// Use Argon2 password verification
+	if !helper.VerifyPasswordArgon2(password, hash) || user == nil {
+		return nil, u.loginFailed(failKey, lockKey)
+	}
+	u.attempts.Reset(failKey)
+	if user.SuspendedAt != nil {
+		return nil, custom.NewForbiddenError("account suspended")
+	}
+	return u.issueTokens(ctx, user, uuid.New())
+}
+
+// loginFailed counts a failed login and locks the username once the limit is
+// reached. The lockout doubles with every failure past the limit.
+func (u *authUseCase) loginFailed(failKey, lockKey string) error {
+	invalid := custom.NewUnauthorizedError("invalid username or password")
+	failures, _, err := u.attempts.Incr(failKey, u.lockout.Window)
+	if err != nil || failures < u.lockout.MaxFailures {
+		return invalid
+	}
+	lockout := u.lockout.Lockout
+	for i := u.lockout.MaxFailures; i < failures && lockout < u.lockout.LockoutMax; i++ {
+		lockout *= 2
+	}
+	lockout = min(lockout, u.lockout.LockoutMax)
+	u.attempts.Incr(lockKey, lockout)
+	return custom.NewTooManyRequestsError("too many failed login attempts", lockout)
+}
+
+func loginKeys(username string) (string, string) {
+	name := strings.ToLower(strings.TrimSpace(username))
+	return "login:fail:" + name, "login:lock:" + name
 }
 
-func (u *authUseCase) Login(username, password string) (*dto.LoginResponse, error) {
-	user, err := u.users.FindByUsername(username)
+// Refresh exchanges a refresh token for a new token pair. Presenting a token
+// that was already rotated is treated as theft and revokes its whole family.
+func (u *authUseCase) Refresh(ctx context.Context, refreshToken string) (*dto.LoginResponse, error) {
+	current, err := u.tokens.FindByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, custom.NewUnexpectedError("failed to look up refresh token")
+	}
+	if current == nil {
+		return nil, custom.NewUnauthorizedError("invalid refresh token")
+	}
+	if current.RevokedAt != nil {
+		if err := u.tokens.RevokeFamily(ctx, current.FamilyID.String()); err != nil {
+			return nil, custom.NewUnexpectedError("failed to revoke refresh tokens")
+		}
+		return nil, custom.NewUnauthorizedError("refresh token reuse detected")
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, custom.NewUnauthorizedError("refresh token expired")
+	}
+	user, err := u.users.FindByID(ctx, current.UserID.String())
 	if err != nil || user == nil {
-		return nil, custom.NewNotFoundError("user not found")
+		return nil, custom.NewUnauthorizedError("invalid refresh token")
 	}
-	// Use Argon2 password verification
-	if !helper.VerifyPasswordArgon2(password, user.PasswordHash) {
-		return nil, custom.NewUnauthorizedError("invalid credentials")
+	if user.SuspendedAt != nil {
+		return nil, custom.NewForbiddenError("account suspended")
+	}
+
+	raw, next, err := u.newRefreshToken(user, current.FamilyID)
+	if err != nil {
+		return nil, err
 	}
-	token, err := jwt.GenerateToken(u.jwtSecret, user.ID.String(), string(user.Role), 24*time.Hour)
+	rotated, err := u.tokens.Rotate(ctx, current, next)
+	if err != nil {
+		return nil, custom.NewUnexpectedError("failed to rotate refresh token")
+	}
+	if !rotated {
+		// a concurrent request already used this token
+		if err := u.tokens.RevokeFamily(ctx, current.FamilyID.String()); err != nil {
+			return nil, custom.NewUnexpectedError("failed to revoke refresh tokens")
+		}
+		return nil, custom.NewUnauthorizedError("refresh token reuse detected")
+	}
+	return u.tokenResponse(user, raw)
+}
+
+// Logout revokes the current access token and, if given, the refresh token of
+// this session.
+func (u *authUseCase) Logout(ctx context.Context, userID, jti string, expiresAt time.Time, refreshToken string) error {
+	if refreshToken != "" {
+		if err := u.tokens.RevokeByHash(ctx, userID, hashToken(refreshToken)); err != nil {
+			return custom.NewUnexpectedError("failed to revoke refresh token")
+		}
+	}
+	return u.revokeAccessToken(ctx, jti, expiresAt)
+}
+
+// LogoutAll revokes every refresh token of the user and the current access
+// token. Other access tokens stay valid until their short TTL runs out.
+func (u *authUseCase) LogoutAll(ctx context.Context, userID, jti string, expiresAt time.Time) error {
+	return revokeSessions(ctx, u.tokens, u.revoked, userID, jti, expiresAt)
+}
+
+func (u *authUseCase) revokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return revokeAccessToken(ctx, u.revoked, jti, expiresAt)
+}
+
+// revokeSessions revokes every refresh token of the user and the access token
+// of the current request.
+func revokeSessions(ctx context.Context, tokens repository.RefreshTokenRepository, revoked repository.RevokedTokenRepository, userID, jti string, expiresAt time.Time) error {
+	if err := tokens.RevokeAllForUser(ctx, userID); err != nil {
+		return custom.NewUnexpectedError("failed to revoke sessions")
+	}
+	return revokeAccessToken(ctx, revoked, jti, expiresAt)
+}
+
+func revokeAccessToken(ctx context.Context, revoked repository.RevokedTokenRepository, jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return nil
+	}
+	if err := revoked.Add(ctx, jti, expiresAt); err != nil {
+		return custom.NewUnexpectedError("failed to revoke access token")
+	}
+	return nil
+}
+
+// issueTokens starts a new refresh token family for a fresh login.
+func (u *authUseCase) issueTokens(ctx context.Context, user *model.User, family uuid.UUID) (*dto.LoginResponse, error) {
+	raw, refresh, err := u.newRefreshToken(user, family)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.tokens.Create(ctx, refresh); err != nil {
+		return nil, custom.NewUnexpectedError("failed to store refresh token")
+	}
+	return u.tokenResponse(user, raw)
+}
+
+func (u *authUseCase) tokenResponse(user *model.User, refreshToken string) (*dto.LoginResponse, error) {
+	token, err := u.jwt.GenerateToken(user.ID.String(), string(user.Role), u.accessTTL)
 	if err != nil {
 		return nil, custom.NewUnexpectedError("failed to generate token")
 	}
-	return &dto.LoginResponse{Token: token}, nil
+	return &dto.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(u.accessTTL.Seconds()),
+	}, nil
+}
+
+func (u *authUseCase) newRefreshToken(user *model.User, family uuid.UUID) (string, *model.RefreshToken, error) {
+	raw, err := newOpaqueToken()
+	if err != nil {
+		return "", nil, custom.NewUnexpectedError("failed to generate refresh token")
+	}
+	m := &model.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  family,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(u.refreshTTL),
+	}
+	m.ID = uuid.New()
+	return raw, m, nil
+}
+
+// newOpaqueToken returns 32 random bytes, base64url encoded.
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is how refresh and mailed tokens are stored; they are random enough that a
+// plain SHA-256 is sufficient.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// validEmail accepts a bare address like "name@example.com".
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
 }
@@ -1,13 +1,17 @@
 package usecases
 
 import (
+	"context"
 	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/repository"
 	"dungeons-dragon-service/internal/dto"
 	"dungeons-dragon-service/internal/helper"
+	"dungeons-dragon-service/internal/infrastructure/storage"
 	"errors"
 	"mime/multipart"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -19,12 +23,12 @@ func newMockCharRepo() *mockCharRepo {
 	return &mockCharRepo{m: map[string]*model.Character{}}
 }
 
-func (m *mockCharRepo) Create(c *model.Character) (*model.Character, error) {
+func (m *mockCharRepo) Create(_ context.Context, c *model.Character) (*model.Character, error) {
 	m.m[c.ID.String()] = c
 	return c, nil
 }
 
-func (m *mockCharRepo) Update(c *model.Character) (*model.Character, error) {
+func (m *mockCharRepo) Update(_ context.Context, c *model.Character) (*model.Character, error) {
 	if _, ok := m.m[c.ID.String()]; !ok {
 		return nil, errors.New("not found")
 	}
@@ -32,7 +36,7 @@ func (m *mockCharRepo) Update(c *model.Character) (*model.Character, error) {
 	return c, nil
 }
 
-func (m *mockCharRepo) Delete(id string) error {
+func (m *mockCharRepo) Delete(_ context.Context, id string) error {
 	if _, ok := m.m[id]; !ok {
 		return errors.New("not found")
 	}
@@ -40,15 +44,11 @@ func (m *mockCharRepo) Delete(id string) error {
 	return nil
 }
 
-func (m *mockCharRepo) FindByID(id string) (*model.Character, error) {
-	c, ok := m.m[id]
-	if !ok {
-		return nil, errors.New("not found")
-	}
-	return c, nil
+func (m *mockCharRepo) FindByID(_ context.Context, id string) (*model.Character, error) {
+	return m.m[id], nil
 }
 
-func (m *mockCharRepo) ListAll() ([]model.Character, error) {
+func (m *mockCharRepo) ListAll(_ context.Context) ([]model.Character, error) {
 	var chars []model.Character
 	for _, c := range m.m {
 		chars = append(chars, *c)
@@ -56,7 +56,7 @@ func (m *mockCharRepo) ListAll() ([]model.Character, error) {
 	return chars, nil
 }
 
-func (m *mockCharRepo) ListPublic() ([]model.Character, error) {
+func (m *mockCharRepo) ListPublic(_ context.Context) ([]model.Character, error) {
 	var chars []model.Character
 	for _, c := range m.m {
 		if c.Privacy == model.PrivacyPublic {
@@ -66,7 +66,7 @@ func (m *mockCharRepo) ListPublic() ([]model.Character, error) {
 	return chars, nil
 }
 
-func (m *mockCharRepo) ListByUser(userID string) ([]model.Character, error) {
+func (m *mockCharRepo) ListByUser(_ context.Context, userID string) ([]model.Character, error) {
 	var chars []model.Character
 	for _, c := range m.m {
 		if c.UserID == helper.ParseUUIDOrNil(userID) {
@@ -76,7 +76,27 @@ func (m *mockCharRepo) ListByUser(userID string) ([]model.Character, error) {
 	return chars, nil
 }
 
-func (m *mockCharRepo) ArchiveByClassID(classID string) error {
+func (m *mockCharRepo) Search(_ context.Context, f repository.CharacterFilter, p repository.PageRequest) ([]model.Character, int64, error) {
+	status := f.Status
+	if status == "" {
+		status = model.ItemStatusActive
+	}
+	var chars []model.Character
+	for _, c := range m.m {
+		if c.Status != status ||
+			!visibleTo(f.Visibility, c.UserID, c.Privacy, c.Status) ||
+			(f.Privacy != "" && c.Privacy != f.Privacy) ||
+			(f.ClassID != "" && c.ClassID.String() != f.ClassID) ||
+			(f.RaceID != "" && c.RaceID.String() != f.RaceID) ||
+			(f.UserID != "" && c.UserID.String() != f.UserID) {
+			continue
+		}
+		chars = append(chars, *c)
+	}
+	return pageSlice(chars, p), int64(len(chars)), nil
+}
+
+func (m *mockCharRepo) ArchiveByClassID(_ context.Context, classID string) error {
 	found := false
 	for _, c := range m.m {
 		if c.ClassID == helper.ParseUUIDOrNil(classID) {
@@ -90,7 +110,7 @@ func (m *mockCharRepo) ArchiveByClassID(classID string) error {
 	return nil
 }
 
-func (m *mockCharRepo) ArchiveByRaceID(raceID string) error {
+func (m *mockCharRepo) ArchiveByRaceID(_ context.Context, raceID string) error {
 	found := false
 	for _, c := range m.m {
 		if c.RaceID == helper.ParseUUIDOrNil(raceID) {
@@ -104,16 +124,25 @@ func (m *mockCharRepo) ArchiveByRaceID(raceID string) error {
 	return nil
 }
 
+func (m *mockCharRepo) ArchiveByUserID(_ context.Context, userID string) error {
+	for _, c := range m.m {
+		if c.UserID == helper.ParseUUIDOrNil(userID) {
+			c.Status = model.ItemStatusArchived
+		}
+	}
+	return nil
+}
+
 type mockClassRepo struct {
 	m map[string]*model.Class
 }
 
-func (m *mockClassRepo) Create(c *model.Class) (*model.Class, error) {
+func (m *mockClassRepo) Create(_ context.Context, c *model.Class) (*model.Class, error) {
 	m.m[c.ID.String()] = c
 	return c, nil
 }
 
-func (m *mockClassRepo) Update(c *model.Class) (*model.Class, error) {
+func (m *mockClassRepo) Update(_ context.Context, c *model.Class) (*model.Class, error) {
 	if _, ok := m.m[c.ID.String()]; !ok {
 		return nil, errors.New("not found")
 	}
@@ -121,7 +150,7 @@ func (m *mockClassRepo) Update(c *model.Class) (*model.Class, error) {
 	return c, nil
 }
 
-func (m *mockClassRepo) Delete(id string) error {
+func (m *mockClassRepo) Delete(_ context.Context, id string) error {
 	if _, ok := m.m[id]; !ok {
 		return errors.New("not found")
 	}
@@ -129,7 +158,7 @@ func (m *mockClassRepo) Delete(id string) error {
 	return nil
 }
 
-func (m *mockClassRepo) FindByID(id string) (*model.Class, error) {
+func (m *mockClassRepo) FindByID(_ context.Context, id string) (*model.Class, error) {
 	c, ok := m.m[id]
 	if !ok {
 		return nil, errors.New("not found")
@@ -137,7 +166,7 @@ func (m *mockClassRepo) FindByID(id string) (*model.Class, error) {
 	return c, nil
 }
 
-func (m *mockClassRepo) List() ([]model.Class, error) {
+func (m *mockClassRepo) List(_ context.Context) ([]model.Class, error) {
 	var classes []model.Class
 	for _, c := range m.m {
 		classes = append(classes, *c)
@@ -149,12 +178,12 @@ type mockRaceRepo struct {
 	m map[string]*model.Race
 }
 
-func (m *mockRaceRepo) Create(r *model.Race) (*model.Race, error) {
+func (m *mockRaceRepo) Create(_ context.Context, r *model.Race) (*model.Race, error) {
 	m.m[r.ID.String()] = r
 	return r, nil
 }
 
-func (m *mockRaceRepo) Update(r *model.Race) (*model.Race, error) {
+func (m *mockRaceRepo) Update(_ context.Context, r *model.Race) (*model.Race, error) {
 	if _, ok := m.m[r.ID.String()]; !ok {
 		return nil, errors.New("not found")
 	}
@@ -162,7 +191,7 @@ func (m *mockRaceRepo) Update(r *model.Race) (*model.Race, error) {
 	return r, nil
 }
 
-func (m *mockRaceRepo) Delete(id string) error {
+func (m *mockRaceRepo) Delete(_ context.Context, id string) error {
 	if _, ok := m.m[id]; !ok {
 		return errors.New("not found")
 	}
@@ -170,7 +199,7 @@ func (m *mockRaceRepo) Delete(id string) error {
 	return nil
 }
 
-func (m *mockRaceRepo) FindByID(id string) (*model.Race, error) {
+func (m *mockRaceRepo) FindByID(_ context.Context, id string) (*model.Race, error) {
 	r, ok := m.m[id]
 	if !ok {
 		return nil, errors.New("not found")
@@ -178,7 +207,7 @@ func (m *mockRaceRepo) FindByID(id string) (*model.Race, error) {
 	return r, nil
 }
 
-func (m *mockRaceRepo) List() ([]model.Race, error) {
+func (m *mockRaceRepo) List(_ context.Context) ([]model.Race, error) {
 	var races []model.Race
 	for _, r := range m.m {
 		races = append(races, *r)
@@ -192,19 +221,19 @@ func TestCharacterCreateValidation(t *testing.T) {
 	raceRepo := mockRaceRepo{m: map[string]*model.Race{"4fa768c3-79a2-4362-845b-5b869784d7c7": {Name: "Elf"}}}
 	uc := NewCharacterUsecase(charRepo, &classRepo, &raceRepo)
 
-	imageUc := NewImageUsecase(nil, charRepo, nil)
+	imageUc := NewImageUsecase(nil, charRepo, nil, storage.NewMemoryStore(), nil)
 	//test image upload
 	var img []*multipart.FileHeader
 	//set image to 11
 	for i := 0; i < 11; i++ {
 		img = append(img, &multipart.FileHeader{Filename: "a.jpg"})
 	}
-	err := imageUc.UploadCharacterImage("1680b136-8862-4ea4-9d80-b2a6a7e71988", "72aa7e28-47d7-4625-bc2d-9790e78c9025", img)
+	_, err := imageUc.ReplaceImages(t.Context(), "1680b136-8862-4ea4-9d80-b2a6a7e71988", ImageOwnerCharacter, "72aa7e28-47d7-4625-bc2d-9790e78c9025", img)
 	require.Error(t, err)
 
 	// Too long description
 	long := make([]rune, 5001)
-	_, err = uc.Create("1680b136-8862-4ea4-9d80-b2a6a7e71988", &dto.CreateCharacterInput{
+	_, err = uc.Create(t.Context(), "1680b136-8862-4ea4-9d80-b2a6a7e71988", &dto.CreateCharacterInput{
 		Title:       "Hero",
 		Description: string(long),
 		ClassID:     "f6d28968-b689-4c50-b4cc-03ab84b47039",
@@ -213,7 +242,7 @@ func TestCharacterCreateValidation(t *testing.T) {
 	require.Error(t, err)
 
 	// Missing class
-	_, err = uc.Create("1680b136-8862-4ea4-9d80-b2a6a7e71988", &dto.CreateCharacterInput{
+	_, err = uc.Create(t.Context(), "1680b136-8862-4ea4-9d80-b2a6a7e71988", &dto.CreateCharacterInput{
 		Title:       "Hero",
 		Description: "ok",
 		RaceID:      "4fa768c3-79a2-4362-845b-5b869784d7c7",
@@ -221,7 +250,7 @@ func TestCharacterCreateValidation(t *testing.T) {
 	require.Error(t, err)
 
 	// Success
-	char, err := uc.Create("1680b136-8862-4ea4-9d80-b2a6a7e71988", &dto.CreateCharacterInput{
+	char, err := uc.Create(t.Context(), "1680b136-8862-4ea4-9d80-b2a6a7e71988", &dto.CreateCharacterInput{
 		Title:       "Hero",
 		Description: "ok",
 		ClassID:     "f6d28968-b689-4c50-b4cc-03ab84b47039",
@@ -238,7 +267,7 @@ func TestCharacterUpdateOwnershipAndArchive(t *testing.T) {
 	uc := NewCharacterUsecase(charRepo, &classRepo, &raceRepo)
 
 	// Create a character
-	char, _ := uc.Create("00ec53c1-276b-4d9f-944c-637e75475650", &dto.CreateCharacterInput{
+	char, _ := uc.Create(t.Context(), "00ec53c1-276b-4d9f-944c-637e75475650", &dto.CreateCharacterInput{
 		Title:       "Hero",
 		Description: "ok",
 		ClassID:     "f6d28968-b689-4c50-b4cc-03ab84b47039",
@@ -246,18 +275,104 @@ func TestCharacterUpdateOwnershipAndArchive(t *testing.T) {
 	})
 
 	// Forbidden update
-	err := uc.Update("1680b136-8862-4ea4-9d80-b2a6a7e71988", char.ID, &dto.UpdateCharacterInput{
+	err := uc.Update(t.Context(), "1680b136-8862-4ea4-9d80-b2a6a7e71988", char.ID, &dto.UpdateCharacterInput{
 		Title: strPtr("X"),
 	})
 	require.Error(t, err)
 
 	// Archive via option delete then attempt update
-	_ = charRepo.ArchiveByClassID("f6d28968-b689-4c50-b4cc-03ab84b47039")
-	err = uc.Update("00ec53c1-276b-4d9f-944c-637e75475650", char.ID, &dto.UpdateCharacterInput{
+	_ = charRepo.ArchiveByClassID(t.Context(), "f6d28968-b689-4c50-b4cc-03ab84b47039")
+	err = uc.Update(t.Context(), "00ec53c1-276b-4d9f-944c-637e75475650", char.ID, &dto.UpdateCharacterInput{
 		Title: strPtr("X"),
 	})
 	require.Error(t, err)
 
 }
 
+func TestCharacterListPaginationAndFilters(t *testing.T) {
+	charRepo := newMockCharRepo()
+	classID := uuid.New()
+	otherClassID := uuid.New()
+	for i := 0; i < 5; i++ {
+		c := &model.Character{Title: "Hero", ClassID: classID, Privacy: model.PrivacyPublic, Status: model.ItemStatusActive}
+		c.ID = uuid.New()
+		charRepo.m[c.ID.String()] = c
+	}
+	private := &model.Character{Title: "Secret", ClassID: classID, Privacy: model.PrivacyPrivate, Status: model.ItemStatusActive}
+	private.ID = uuid.New()
+	charRepo.m[private.ID.String()] = private
+	other := &model.Character{Title: "Other", ClassID: otherClassID, Privacy: model.PrivacyPublic, Status: model.ItemStatusActive}
+	other.ID = uuid.New()
+	charRepo.m[other.ID.String()] = other
+
+	uc := NewCharacterUsecase(charRepo, nil, nil)
+
+	list, page, err := uc.ListForUser(t.Context(), Viewer{}, &dto.CharacterListQuery{
+		ListQuery: dto.ListQuery{Page: 1, Limit: 2},
+		ClassID:   classID.String(),
+	})
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, int64(5), page.Total)
+	require.Equal(t, 3, page.TotalPages)
+	require.NotEmpty(t, page.NextCursor)
+	require.Empty(t, page.PrevCursor)
+
+	list, page, err = uc.ListForUser(t.Context(), Viewer{}, &dto.CharacterListQuery{
+		ListQuery: dto.ListQuery{Page: 3, Limit: 2},
+		ClassID:   classID.String(),
+	})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Empty(t, page.NextCursor)
+	require.NotEmpty(t, page.PrevCursor)
+
+	// unknown sort fields are rejected
+	_, _, err = uc.ListForUser(t.Context(), Viewer{Role: model.RoleAdmin}, &dto.CharacterListQuery{ListQuery: dto.ListQuery{Sort: "password_hash"}})
+	require.Error(t, err)
+
+	// cursors are opaque and bound to their sort
+	_, _, err = uc.ListForUser(t.Context(), Viewer{Role: model.RoleAdmin}, &dto.CharacterListQuery{ListQuery: dto.ListQuery{Cursor: "not-a-cursor"}})
+	require.Error(t, err)
+}
+
+func TestCharacterGetHidesPrivateFromOthers(t *testing.T) {
+	charRepo := newMockCharRepo()
+	classRepo := mockClassRepo{m: map[string]*model.Class{}}
+	raceRepo := mockRaceRepo{m: map[string]*model.Race{}}
+	class := &model.Class{Name: "Warrior"}
+	class.ID = uuid.New()
+	classRepo.m[class.ID.String()] = class
+	owner := uuid.New()
+	c := &model.Character{Title: "Secret", UserID: owner, ClassID: class.ID, Privacy: model.PrivacyPrivate, Status: model.ItemStatusActive}
+	c.ID = uuid.New()
+	charRepo.m[c.ID.String()] = c
+	uc := NewCharacterUsecase(charRepo, &classRepo, &raceRepo)
+
+	// hidden items look exactly like missing ones
+	_, err := uc.Get(t.Context(), Viewer{}, c.ID.String())
+	require.EqualError(t, err, "character not found")
+	_, err = uc.Get(t.Context(), Viewer{UserID: uuid.NewString(), Role: model.RoleUser}, c.ID.String())
+	require.EqualError(t, err, "character not found")
+
+	res, err := uc.Get(t.Context(), Viewer{UserID: owner.String(), Role: model.RoleUser}, c.ID.String())
+	require.NoError(t, err)
+	require.Equal(t, "Warrior", res.Class)
+
+	_, err = uc.Get(t.Context(), Viewer{UserID: uuid.NewString(), Role: model.RoleAdmin}, c.ID.String())
+	require.NoError(t, err)
+}
+
+// pageSlice applies offset and limit of a page request to an in-memory list.
+func pageSlice[T any](list []T, p repository.PageRequest) []T {
+	if p.Offset >= len(list) {
+		return nil
+	}
+	list = list[p.Offset:]
+	if p.Limit > 0 && p.Limit < len(list) {
+		list = list[:p.Limit]
+	}
+	return list
+}
+
 func strPtr(s string) *string { return &s }
@@ -1,20 +1,22 @@
 package usecases
 
 import (
+	"context"
 	"dungeons-dragon-service/internal/domain/model"
 	"dungeons-dragon-service/internal/domain/repository"
 	"dungeons-dragon-service/internal/dto"
 	"dungeons-dragon-service/internal/helper"
 	"dungeons-dragon-service/internal/http/custom"
-	"encoding/json"
 )
 
 type CharacterUseCase interface {
-	ListPublic() ([]dto.CharacterResponse, error)
-	ListForUser(authenticated bool) ([]dto.CharacterResponse, error)
-	Create(userID string, in *dto.CreateCharacterInput) (*dto.CharacterResponse, error)
-	Update(userID string, id string, in *dto.UpdateCharacterInput) error
-	Delete(userID string, id string) error
+	ListPublic(ctx context.Context) ([]dto.CharacterResponse, error)
+	ListForUser(ctx context.Context, viewer Viewer, q *dto.CharacterListQuery) ([]dto.CharacterResponse, *dto.PaginateResponse, error)
+	ListByOwner(ctx context.Context, userID string) ([]dto.CharacterResponse, error)
+	Get(ctx context.Context, viewer Viewer, id string) (*dto.CharacterResponse, error)
+	Create(ctx context.Context, userID string, in *dto.CreateCharacterInput) (*dto.CharacterResponse, error)
+	Update(ctx context.Context, userID string, id string, in *dto.UpdateCharacterInput) error
+	Delete(ctx context.Context, userID string, id string) error
 }
 
 type characterUseCase struct {
@@ -30,15 +32,6 @@ func NewCharacterUsecase(c repository.CharacterRepository, cl repository.ClassRe
 func ResponseCharacters(c []model.Character) []dto.CharacterResponse {
 	res := make([]dto.CharacterResponse, len(c))
 	for i, char := range c {
-		//unmarshal images
-		images := []string{}
-		urls := []string{}
-		if err := json.Unmarshal(char.ImagePath, &images); err == nil {
-			for _, img := range images {
-				url := helper.GetImageURL(img)
-				urls = append(urls, url)
-			}
-		}
 		res[i] = dto.CharacterResponse{
 			ID:          char.ID.String(),
 			Title:       char.Title,
@@ -48,45 +41,79 @@ func ResponseCharacters(c []model.Character) []dto.CharacterResponse {
 			UserID:      char.UserID.String(),
 			Privacy:     char.Privacy,
 			Status:      string(char.Status),
-			Images:      urls,
+			Images:      ResponseCharacterImages(char.Images, isPrivateItem(char.Privacy, char.Status)),
 		}
 	}
 	return res
 }
 
-func (u *characterUseCase) ListPublic() ([]dto.CharacterResponse, error) {
-	list, err := u.characters.ListPublic()
+func (u *characterUseCase) ListPublic(ctx context.Context) ([]dto.CharacterResponse, error) {
+	list, err := u.characters.ListPublic(ctx)
 	if err != nil {
 		return nil, custom.NewUnexpectedError("failed to list characters")
 	}
 	return ResponseCharacters(list), nil
 }
 
-func (u *characterUseCase) ListForUser(authenticated bool) ([]dto.CharacterResponse, error) {
-	if authenticated {
-		list, err := u.characters.ListAll()
-		if err != nil {
-			return nil, custom.NewUnexpectedError("failed to list characters")
-		}
-		return ResponseCharacters(list), nil
+func (u *characterUseCase) ListForUser(ctx context.Context, viewer Viewer, q *dto.CharacterListQuery) ([]dto.CharacterResponse, *dto.PaginateResponse, error) {
+	pq, err := newPageQuery(q.ListQuery, listSortFields)
+	if err != nil {
+		return nil, nil, err
 	}
-	list, err := u.characters.ListPublic()
+	filter := repository.CharacterFilter{
+		ClassID:    q.ClassID,
+		RaceID:     q.RaceID,
+		UserID:     q.Owner,
+		Privacy:    q.Privacy,
+		Status:     q.Status,
+		Visibility: viewer.visibility(),
+	}
+	list, total, err := u.characters.Search(ctx, filter, pq.req)
+	if err != nil {
+		return nil, nil, custom.NewUnexpectedError("failed to list characters")
+	}
+	list, paginate := paginateResult(pq, list, total, func(c model.Character) (string, string) {
+		return sortValue(pq.req.SortBy, c.Title, c.CreatedAt, c.UpdatedAt), c.ID.String()
+	})
+	return ResponseCharacters(list), paginate, nil
+}
+
+// ListByOwner returns all active characters of a user, private ones included.
+func (u *characterUseCase) ListByOwner(ctx context.Context, userID string) ([]dto.CharacterResponse, error) {
+	list, err := u.characters.ListByUser(ctx, userID)
 	if err != nil {
 		return nil, custom.NewUnexpectedError("failed to list characters")
 	}
 	return ResponseCharacters(list), nil
 }
 
-func (u *characterUseCase) Create(userID string, in *dto.CreateCharacterInput) (*dto.CharacterResponse, error) {
+// Get returns a single character. Characters the viewer may not see are
+// reported as not found so their existence is not leaked.
+func (u *characterUseCase) Get(ctx context.Context, viewer Viewer, id string) (*dto.CharacterResponse, error) {
+	m, err := u.characters.FindByID(ctx, id)
+	if err != nil || m == nil || !viewer.canView(m.UserID, m.Privacy, m.Status) {
+		return nil, custom.NewNotFoundError("character not found")
+	}
+	response := ResponseCharacters([]model.Character{*m})[0]
+	if class, err := u.classes.FindByID(ctx, m.ClassID.String()); err == nil {
+		response.Class = class.Name
+	}
+	if race, err := u.races.FindByID(ctx, m.RaceID.String()); err == nil {
+		response.Race = race.Name
+	}
+	return &response, nil
+}
+
+func (u *characterUseCase) Create(ctx context.Context, userID string, in *dto.CreateCharacterInput) (*dto.CharacterResponse, error) {
 	// Validate description and images
 	if err := helper.ValidateDescription(in.Description); err != nil {
 		return nil, custom.NewBadRequestError("invalid description")
 	}
 	// Validate class & race existence
-	if _, err := u.classes.FindByID(in.ClassID); err != nil {
+	if _, err := u.classes.FindByID(ctx, in.ClassID); err != nil {
 		return nil, custom.NewNotFoundError("class not found")
 	}
-	if _, err := u.races.FindByID(in.RaceID); err != nil {
+	if _, err := u.races.FindByID(ctx, in.RaceID); err != nil {
 		return nil, custom.NewNotFoundError("race not found")
 	}
 
@@ -101,16 +128,16 @@ func (u *characterUseCase) Create(userID string, in *dto.CreateCharacterInput) (
 		// Images:      []byte("[]"),
 		Status: model.ItemStatusActive,
 	}
-	if _, err := u.characters.Create(m); err != nil {
+	if _, err := u.characters.Create(ctx, m); err != nil {
 		return nil, custom.NewUnexpectedError("failed to create character")
 	}
 	response := ResponseCharacters([]model.Character{*m})[0]
 	return &response, nil
 }
 
-func (u *characterUseCase) Update(userID string, id string, in *dto.UpdateCharacterInput) error {
-	m, err := u.characters.FindByID(id)
-	if err != nil {
+func (u *characterUseCase) Update(ctx context.Context, userID string, id string, in *dto.UpdateCharacterInput) error {
+	m, err := u.characters.FindByID(ctx, id)
+	if err != nil || m == nil {
 		return custom.NewNotFoundError("character not found")
 	}
 	if m.UserID != helper.ParseUUIDOrNil(userID) {
@@ -130,13 +157,13 @@ func (u *characterUseCase) Update(userID string, id string, in *dto.UpdateCharac
 		m.Description = *in.Description
 	}
 	if in.ClassID != nil {
-		if _, err := u.classes.FindByID(*in.ClassID); err != nil {
+		if _, err := u.classes.FindByID(ctx, *in.ClassID); err != nil {
 			return custom.NewNotFoundError("class not found")
 		}
 		m.ClassID = helper.ParseUUIDOrNil(*in.ClassID)
 	}
 	if in.RaceID != nil {
-		if _, err := u.races.FindByID(*in.RaceID); err != nil {
+		if _, err := u.races.FindByID(ctx, *in.RaceID); err != nil {
 			return custom.NewNotFoundError("race not found")
 		}
 		m.RaceID = helper.ParseUUIDOrNil(*in.RaceID)
@@ -144,19 +171,19 @@ func (u *characterUseCase) Update(userID string, id string, in *dto.UpdateCharac
 	if in.Privacy != nil {
 		m.Privacy = *in.Privacy
 	}
-	if _, err := u.characters.Update(m); err != nil {
+	if _, err := u.characters.Update(ctx, m); err != nil {
 		return custom.NewUnexpectedError("failed to update character")
 	}
 	return nil
 }
 
-func (u *characterUseCase) Delete(userID string, id string) error {
-	m, err := u.characters.FindByID(id)
-	if err != nil {
+func (u *characterUseCase) Delete(ctx context.Context, userID string, id string) error {
+	m, err := u.characters.FindByID(ctx, id)
+	if err != nil || m == nil {
 		return custom.NewNotFoundError("character not found")
 	}
 	if m.UserID != helper.ParseUUIDOrNil(userID) {
 		return custom.NewForbiddenError("forbidden")
 	}
-	return u.characters.Delete(id)
+	return u.characters.Delete(ctx, id)
 }
@@ -0,0 +1,159 @@
+package usecases
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/domain/port"
+	"dungeons-dragon-service/internal/domain/repository"
+	"dungeons-dragon-service/internal/infrastructure/logging"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ImageGCOptions controls a garbage collection run.
+type ImageGCOptions struct {
+	// Apply deletes what was found. Without it the run only reports.
+	Apply bool
+	// Grace leaves blobs and rows younger than this alone, so uploads that
+	// are still being committed are not mistaken for garbage.
+	Grace time.Duration
+}
+
+// Reasons an image row is reported as dangling.
+const (
+	DanglingOwnerDeleted = "owner deleted"
+	DanglingBlobMissing  = "blob missing"
+)
+
+// DanglingImage is an image row that does not point at a usable image.
+type DanglingImage struct {
+	Owner   ImageOwner
+	ImageID string
+	Key     string
+	Reason  string
+}
+
+// ImageGCReport is the outcome of one run. Orphans are blobs no live image
+// row references.
+type ImageGCReport struct {
+	DryRun       bool
+	Scanned      int
+	Orphans      []port.BlobInfo
+	OrphanBytes  int64
+	Dangling     []DanglingImage
+	DeletedBlobs int
+	DeletedRows  int
+}
+
+func (r *ImageGCReport) Summary() string {
+	mode := "applied"
+	if r.DryRun {
+		mode = "dry run"
+	}
+	return fmt.Sprintf("%s: scanned %d blobs, %d orphans (%d bytes), %d dangling rows, deleted %d blobs and %d rows",
+		mode, r.Scanned, len(r.Orphans), r.OrphanBytes, len(r.Dangling), r.DeletedBlobs, r.DeletedRows)
+}
+
+// ImageGC reconciles the blob store with the image tables. Blobs left by
+// failed uploads, replaced images and deleted characters or quests are
+// removed, as are the image rows of deleted items. Rows of live items whose
+// blob is missing are only reported.
+type ImageGC struct {
+	images repository.ImageRepository
+	store  port.BlobStore
+	now    func() time.Time
+}
+
+func NewImageGC(images repository.ImageRepository, store port.BlobStore) *ImageGC {
+	return &ImageGC{images: images, store: store, now: time.Now}
+}
+
+func (g *ImageGC) Run(ctx context.Context, opts ImageGCOptions) (*ImageGCReport, error) {
+	cutoff := g.now().Add(-opts.Grace)
+	report := &ImageGCReport{DryRun: !opts.Apply}
+
+	// storage is listed before the rows are loaded: a blob uploaded in
+	// between is not listed, and a row committed in between still counts
+	var blobs []port.BlobInfo
+	stored := map[string]bool{}
+	err := g.store.List(ctx, func(info port.BlobInfo) error {
+		blobs = append(blobs, info)
+		stored[info.Key] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list blobs: %w", err)
+	}
+	report.Scanned = len(blobs)
+	records, err := g.images.ImageRecords(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load image rows: %w", err)
+	}
+
+	referenced := map[string]bool{}
+	var deletedOwners []repository.ImageRecord
+	for _, rec := range records {
+		key := imageKey(rec.Path)
+		if rec.OwnerDeleted {
+			report.Dangling = append(report.Dangling, DanglingImage{Owner: ImageOwner(rec.Owner), ImageID: rec.ID.String(), Key: key, Reason: DanglingOwnerDeleted})
+			deletedOwners = append(deletedOwners, rec)
+			continue
+		}
+		referenced[key] = true
+		for _, v := range rec.Variants {
+			referenced[v.Key] = true
+		}
+		if !stored[key] && rec.CreatedAt.Before(cutoff) {
+			// the listing may be stale, ask the store once more
+			if _, err := g.store.Stat(ctx, key); errors.Is(err, port.ErrBlobNotFound) {
+				report.Dangling = append(report.Dangling, DanglingImage{Owner: ImageOwner(rec.Owner), ImageID: rec.ID.String(), Key: key, Reason: DanglingBlobMissing})
+			}
+		}
+	}
+	for _, b := range blobs {
+		if referenced[b.Key] || b.ModTime.After(cutoff) {
+			continue
+		}
+		report.Orphans = append(report.Orphans, b)
+		report.OrphanBytes += b.Size
+	}
+	if !opts.Apply {
+		return report, nil
+	}
+
+	// rows go first so no row points at a deleted blob if this fails
+	if len(deletedOwners) > 0 {
+		if err := g.images.DeleteImageRecords(ctx, deletedOwners); err != nil {
+			return report, fmt.Errorf("delete image rows: %w", err)
+		}
+		report.DeletedRows = len(deletedOwners)
+	}
+	for _, b := range report.Orphans {
+		if err := g.store.Delete(ctx, b.Key); err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "failed to delete orphan image", "key", b.Key, "error", err)
+			continue
+		}
+		report.DeletedBlobs++
+	}
+	return report, nil
+}
+
+// Schedule runs the collector every interval until ctx is cancelled and logs
+// the summary of each run.
+func (g *ImageGC) Schedule(ctx context.Context, interval time.Duration, opts ImageGCOptions) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		report, err := g.Run(ctx, opts)
+		if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "image gc failed", "error", err)
+			continue
+		}
+		logging.FromContext(ctx).InfoContext(ctx, "image gc", "summary", report.Summary())
+	}
+}
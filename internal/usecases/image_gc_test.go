@@ -0,0 +1,75 @@
+package usecases
+
+import (
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/infrastructure/storage"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageGC(t *testing.T) {
+	images := newMockImageRepo()
+	store := storage.NewMemoryStore()
+	put := func(key string) {
+		require.NoError(t, store.Put(t.Context(), key, strings.NewReader(key), int64(len(key)), "image/png"))
+	}
+	old := time.Now().Add(-48 * time.Hour)
+
+	live, gone := uuid.NewString(), uuid.NewString()
+	images.characters[live] = []model.CharacterImage{
+		// legacy rows hold the full path
+		{Base: model.Base{ID: uuid.New(), CreatedAt: old}, Path: "uploads/kept.png", Variants: []model.ImageVariant{{Name: "thumb", Key: "kept_thumb.jpg"}}},
+		{Base: model.Base{ID: uuid.New(), CreatedAt: old}, Path: "lost.png"},
+		// still within the grace period, its blob may not be listed yet
+		{Base: model.Base{ID: uuid.New(), CreatedAt: time.Now().Add(time.Hour)}, Path: "fresh.png"},
+	}
+	images.quests[gone] = []model.QuestImage{{Base: model.Base{ID: uuid.New(), CreatedAt: old}, Path: "deleted.png"}}
+	images.deleted[gone] = true
+	for _, key := range []string{"kept.png", "kept_thumb.jpg", "deleted.png", "stray.png", "failed.png"} {
+		put(key)
+	}
+
+	gc := NewImageGC(images, store)
+	// run an hour ahead so the blobs stored above are past the grace period
+	gc.now = func() time.Time { return time.Now().Add(time.Hour) }
+	opts := ImageGCOptions{Grace: time.Hour / 2}
+
+	report, err := gc.Run(t.Context(), opts)
+	require.NoError(t, err)
+	require.True(t, report.DryRun)
+	require.Equal(t, 5, report.Scanned)
+	var orphans []string
+	for _, b := range report.Orphans {
+		orphans = append(orphans, b.Key)
+	}
+	require.ElementsMatch(t, []string{"deleted.png", "stray.png", "failed.png"}, orphans)
+	require.Len(t, report.Dangling, 2)
+	reasons := map[string]string{}
+	for _, d := range report.Dangling {
+		reasons[d.Key] = d.Reason
+	}
+	require.Equal(t, map[string]string{"lost.png": DanglingBlobMissing, "deleted.png": DanglingOwnerDeleted}, reasons)
+	// a dry run changes nothing
+	require.Len(t, store.Keys(), 5)
+	require.Len(t, images.quests[gone], 1)
+
+	// fresh blobs are protected by the grace period
+	gc.now = time.Now
+	report, err = gc.Run(t.Context(), ImageGCOptions{Grace: time.Minute})
+	require.NoError(t, err)
+	require.Empty(t, report.Orphans)
+
+	gc.now = func() time.Time { return time.Now().Add(time.Hour) }
+	opts.Apply = true
+	report, err = gc.Run(t.Context(), opts)
+	require.NoError(t, err)
+	require.Equal(t, 3, report.DeletedBlobs)
+	require.Equal(t, 1, report.DeletedRows)
+	require.Equal(t, []string{"kept.png", "kept_thumb.jpg"}, store.Keys())
+	require.Empty(t, images.quests[gone])
+	require.Len(t, images.characters[live], 3)
+}
@@ -0,0 +1,507 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/repository"
+	"dungeons-dragon-service/internal/dto"
+	"dungeons-dragon-service/internal/http/custom"
+	"dungeons-dragon-service/internal/infrastructure/storage"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type mockImageRepo struct {
+	characters map[string][]model.CharacterImage
+	quests     map[string][]model.QuestImage
+	// owners holds the owner info of a character or quest by its id
+	owners map[string]repository.ImageOwnerInfo
+	// deleted marks characters and quests that were deleted
+	deleted  map[string]bool
+	failSave bool
+}
+
+func newMockImageRepo() *mockImageRepo {
+	return &mockImageRepo{characters: map[string][]model.CharacterImage{}, quests: map[string][]model.QuestImage{}, owners: map[string]repository.ImageOwnerInfo{}, deleted: map[string]bool{}}
+}
+
+func (m *mockImageRepo) FindOwnerByKey(_ context.Context, key string) (*repository.ImageOwnerInfo, error) {
+	matches := func(path string, variants []model.ImageVariant) bool {
+		if imageKey(path) == key {
+			return true
+		}
+		for _, v := range variants {
+			if v.Key == key {
+				return true
+			}
+		}
+		return false
+	}
+	for id, imgs := range m.characters {
+		for _, img := range imgs {
+			if matches(img.Path, img.Variants) {
+				info := m.owners[id]
+				return &info, nil
+			}
+		}
+	}
+	for id, imgs := range m.quests {
+		for _, img := range imgs {
+			if matches(img.Path, img.Variants) {
+				info := m.owners[id]
+				return &info, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockImageRepo) GetCharacterImageByID(_ context.Context, characterID string) ([]model.CharacterImage, error) {
+	return m.characters[characterID], nil
+}
+
+func (m *mockImageRepo) GetQuestImageByID(_ context.Context, questID string) ([]model.QuestImage, error) {
+	return m.quests[questID], nil
+}
+
+func (m *mockImageRepo) SaveCharacterImages(_ context.Context, characterID string, expected []string, imgs []model.CharacterImage) ([]model.CharacterImage, error) {
+	if m.failSave {
+		return nil, errors.New("tx failed")
+	}
+	current := m.characters[characterID]
+	if len(current) != len(expected) {
+		return nil, repository.ErrImagesChanged
+	}
+	keep := map[uuid.UUID]bool{}
+	for i := range imgs {
+		if imgs[i].ID == uuid.Nil {
+			imgs[i].ID = uuid.New()
+		}
+		imgs[i].Position = i
+		keep[imgs[i].ID] = true
+	}
+	var removed []model.CharacterImage
+	for _, img := range current {
+		if !keep[img.ID] {
+			removed = append(removed, img)
+		}
+	}
+	m.characters[characterID] = append([]model.CharacterImage{}, imgs...)
+	return removed, nil
+}
+
+func (m *mockImageRepo) SaveQuestImages(_ context.Context, questID string, expected []string, imgs []model.QuestImage) ([]model.QuestImage, error) {
+	if m.failSave {
+		return nil, errors.New("tx failed")
+	}
+	current := m.quests[questID]
+	if len(current) != len(expected) {
+		return nil, repository.ErrImagesChanged
+	}
+	keep := map[uuid.UUID]bool{}
+	for i := range imgs {
+		if imgs[i].ID == uuid.Nil {
+			imgs[i].ID = uuid.New()
+		}
+		imgs[i].Position = i
+		keep[imgs[i].ID] = true
+	}
+	var removed []model.QuestImage
+	for _, img := range current {
+		if !keep[img.ID] {
+			removed = append(removed, img)
+		}
+	}
+	m.quests[questID] = append([]model.QuestImage{}, imgs...)
+	return removed, nil
+}
+
+func (m *mockImageRepo) SetCharacterImageVariants(_ context.Context, imageID string, status model.VariantStatus, variants []model.ImageVariant) (bool, error) {
+	for _, imgs := range m.characters {
+		for i := range imgs {
+			if imgs[i].ID.String() == imageID {
+				imgs[i].VariantStatus = status
+				imgs[i].Variants = variants
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (m *mockImageRepo) SetQuestImageVariants(_ context.Context, imageID string, status model.VariantStatus, variants []model.ImageVariant) (bool, error) {
+	for _, imgs := range m.quests {
+		for i := range imgs {
+			if imgs[i].ID.String() == imageID {
+				imgs[i].VariantStatus = status
+				imgs[i].Variants = variants
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (m *mockImageRepo) PendingCharacterImages(_ context.Context, limit int) ([]model.CharacterImage, error) {
+	var pending []model.CharacterImage
+	for _, imgs := range m.characters {
+		for _, img := range imgs {
+			if img.VariantStatus == model.VariantStatusPending && len(pending) < limit {
+				pending = append(pending, img)
+			}
+		}
+	}
+	return pending, nil
+}
+
+func (m *mockImageRepo) PendingQuestImages(_ context.Context, limit int) ([]model.QuestImage, error) {
+	var pending []model.QuestImage
+	for _, imgs := range m.quests {
+		for _, img := range imgs {
+			if img.VariantStatus == model.VariantStatusPending && len(pending) < limit {
+				pending = append(pending, img)
+			}
+		}
+	}
+	return pending, nil
+}
+
+func (m *mockImageRepo) ImageRecords(_ context.Context) ([]repository.ImageRecord, error) {
+	var records []repository.ImageRecord
+	for id, imgs := range m.characters {
+		for _, img := range imgs {
+			records = append(records, repository.ImageRecord{ID: img.ID, Owner: "character", Path: img.Path, Variants: img.Variants, CreatedAt: img.CreatedAt, OwnerDeleted: m.deleted[id]})
+		}
+	}
+	for id, imgs := range m.quests {
+		for _, img := range imgs {
+			records = append(records, repository.ImageRecord{ID: img.ID, Owner: "quest", Path: img.Path, Variants: img.Variants, CreatedAt: img.CreatedAt, OwnerDeleted: m.deleted[id]})
+		}
+	}
+	return records, nil
+}
+
+func (m *mockImageRepo) DeleteImageRecords(_ context.Context, records []repository.ImageRecord) error {
+	gone := map[uuid.UUID]bool{}
+	for _, rec := range records {
+		gone[rec.ID] = true
+	}
+	for id, imgs := range m.characters {
+		var keep []model.CharacterImage
+		for _, img := range imgs {
+			if !gone[img.ID] {
+				keep = append(keep, img)
+			}
+		}
+		m.characters[id] = keep
+	}
+	for id, imgs := range m.quests {
+		var keep []model.QuestImage
+		for _, img := range imgs {
+			if !gone[img.ID] {
+				keep = append(keep, img)
+			}
+		}
+		m.quests[id] = keep
+	}
+	return nil
+}
+
+// uploadFiles runs files through a real multipart round trip so the
+// headers can be opened like in a request.
+func uploadFiles(t *testing.T, files map[string][]byte) []*multipart.FileHeader {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, data := range files {
+		part, err := w.CreateFormFile("images", name)
+		require.NoError(t, err)
+		_, err = part.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	return form.File["images"]
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))))
+	return buf.Bytes()
+}
+
+// pngHeader is a PNG that claims the given size but carries no pixels.
+func pngHeader(w, h uint32) []byte {
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], w)
+	binary.BigEndian.PutUint32(ihdr[8:], h)
+	ihdr[12], ihdr[13] = 8, 6 // 8 bit RGBA
+	out := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0d")
+	out = append(out, ihdr...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(ihdr))
+}
+
+func statusOf(err error) int {
+	if appErr, ok := err.(*custom.AppError); ok {
+		return appErr.Code
+	}
+	return 0
+}
+
+func newImageFixture(t *testing.T) (*imageUseCase, *mockImageRepo, *storage.MemoryStore, *model.Character) {
+	chars := newMockCharRepo()
+	owner := uuid.New()
+	char := &model.Character{Base: model.Base{ID: uuid.New()}, UserID: owner, Title: "Hero", Privacy: model.PrivacyPublic, Status: model.ItemStatusActive}
+	chars.m[char.ID.String()] = char
+
+	images := newMockImageRepo()
+	images.owners[char.ID.String()] = repository.ImageOwnerInfo{UserID: owner, Privacy: char.Privacy, Status: char.Status}
+	store := storage.NewMemoryStore()
+	uc := NewImageUsecase(images, chars, nil, store, nil).(*imageUseCase)
+	return uc, images, store, char
+}
+
+func TestUploadImageSniffsTypeAndStoresMetadata(t *testing.T) {
+	uc, images, store, char := newImageFixture(t)
+
+	files := uploadFiles(t, map[string][]byte{`C:\photos\hero.gif`: testPNG(t, 3, 2)})
+	_, err := uc.ReplaceImages(t.Context(), char.UserID.String(), ImageOwnerCharacter, char.ID.String(), files)
+	require.NoError(t, err)
+
+	saved := images.characters[char.ID.String()]
+	require.Len(t, saved, 1)
+	img := saved[0]
+	require.True(t, strings.HasSuffix(img.Path, ".png"), img.Path)
+	_, err = uuid.Parse(strings.TrimSuffix(img.Path, ".png"))
+	require.NoError(t, err)
+	require.Equal(t, "hero.gif", img.OriginalName)
+	require.Equal(t, "image/png", img.ContentType)
+	require.Equal(t, 3, img.Width)
+	require.Equal(t, 2, img.Height)
+
+	file, err := uc.Open(t.Context(), Viewer{}, img.Path, "", "")
+	require.NoError(t, err)
+	defer file.Body.Close()
+	data, _ := io.ReadAll(file.Body)
+	require.EqualValues(t, len(data), img.Size)
+	require.Equal(t, "image/png", file.Info.ContentType)
+	require.True(t, file.Public)
+
+	_, err = store.Stat(t.Context(), img.Path)
+	require.NoError(t, err)
+}
+
+func TestUploadImageRejectsBadContent(t *testing.T) {
+	uc, images, _, char := newImageFixture(t)
+	uid, id := char.UserID.String(), char.ID.String()
+
+	// extension and declared type do not matter, the bytes do
+	_, err := uc.ReplaceImages(t.Context(), uid, ImageOwnerCharacter, id, uploadFiles(t, map[string][]byte{"evil.png": []byte("<svg onload=alert(1)>")}))
+	require.Equal(t, http.StatusUnsupportedMediaType, statusOf(err))
+
+	// a valid signature with a broken header
+	_, err = uc.ReplaceImages(t.Context(), uid, ImageOwnerCharacter, id, uploadFiles(t, map[string][]byte{"a.png": []byte("\x89PNG\r\n\x1a\nbroken")}))
+	require.Equal(t, http.StatusBadRequest, statusOf(err))
+
+	// decompression bomb: tiny file, huge canvas
+	_, err = uc.ReplaceImages(t.Context(), uid, ImageOwnerCharacter, id, uploadFiles(t, map[string][]byte{"bomb.png": pngHeader(50_000, 50_000)}))
+	require.Equal(t, http.StatusBadRequest, statusOf(err))
+	require.Contains(t, err.Error(), "dimensions")
+
+	uc.limits.MaxFileSize = 64
+	_, err = uc.ReplaceImages(t.Context(), uid, ImageOwnerCharacter, id, uploadFiles(t, map[string][]byte{"big.png": testPNG(t, 64, 64)}))
+	require.Equal(t, http.StatusRequestEntityTooLarge, statusOf(err))
+
+	uc.limits.MaxFileSize = 1 << 20
+	uc.limits.MaxUploadSize = 100
+	_, err = uc.ReplaceImages(t.Context(), uid, ImageOwnerCharacter, id, uploadFiles(t, map[string][]byte{
+		"a.png": testPNG(t, 2, 2),
+		"b.png": testPNG(t, 2, 2),
+	}))
+	require.Equal(t, http.StatusRequestEntityTooLarge, statusOf(err))
+
+	require.Empty(t, images.characters[id])
+}
+
+func TestMaxBytesReaderStopsStreaming(t *testing.T) {
+	r := &maxBytesReader{r: strings.NewReader("0123456789"), n: 4}
+	_, err := io.ReadAll(r)
+	require.ErrorIs(t, err, errImageTooLarge)
+	require.True(t, r.exceeded)
+}
+
+func TestUploadImageReplacesAfterCommit(t *testing.T) {
+	uc, images, store, char := newImageFixture(t)
+	uid, id := char.UserID.String(), char.ID.String()
+
+	_, err := uc.ReplaceImages(t.Context(), uid, ImageOwnerCharacter, id, uploadFiles(t, map[string][]byte{"a.png": testPNG(t, 2, 2)}))
+	require.NoError(t, err)
+	first := images.characters[id][0].Path
+
+	_, err = uc.ReplaceImages(t.Context(), uid, ImageOwnerCharacter, id, uploadFiles(t, map[string][]byte{"b.png": testPNG(t, 2, 2)}))
+	require.NoError(t, err)
+	second := images.characters[id][0].Path
+	require.NotEqual(t, first, second)
+	require.Equal(t, []string{second}, store.Keys())
+
+	// the transaction fails: the old image stays, the new blob is removed
+	images.failSave = true
+	_, err = uc.ReplaceImages(t.Context(), uid, ImageOwnerCharacter, id, uploadFiles(t, map[string][]byte{"c.png": testPNG(t, 2, 2)}))
+	require.Equal(t, http.StatusInternalServerError, statusOf(err))
+	require.Equal(t, second, images.characters[id][0].Path)
+	require.Equal(t, []string{second}, store.Keys())
+}
+
+func TestUploadImageCleansUpPartialUpload(t *testing.T) {
+	uc, images, store, char := newImageFixture(t)
+
+	files := uploadFiles(t, map[string][]byte{"a.png": testPNG(t, 2, 2)})
+	files = append(files, uploadFiles(t, map[string][]byte{"b.txt": []byte("not an image")})...)
+	_, err := uc.ReplaceImages(t.Context(), char.UserID.String(), ImageOwnerCharacter, char.ID.String(), files)
+	require.Equal(t, http.StatusUnsupportedMediaType, statusOf(err))
+	require.Empty(t, images.characters[char.ID.String()])
+	require.Empty(t, store.Keys())
+}
+
+func imageIDs(images []dto.ImageResponse) []string {
+	ids := make([]string, len(images))
+	for i, img := range images {
+		ids[i] = img.ID
+	}
+	return ids
+}
+
+func TestManageImages(t *testing.T) {
+	uc, _, store, char := newImageFixture(t)
+	uid, id := char.UserID.String(), char.ID.String()
+	add := func(n int) ([]dto.ImageResponse, error) {
+		var files []*multipart.FileHeader
+		for i := 0; i < n; i++ {
+			files = append(files, uploadFiles(t, map[string][]byte{"a.png": testPNG(t, 4, 3)})...)
+		}
+		return uc.AddImages(t.Context(), uid, ImageOwnerCharacter, id, files)
+	}
+
+	images, err := add(2)
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	require.True(t, images[0].IsCover)
+	require.Equal(t, 4, images[0].Width)
+	require.Equal(t, 3, images[0].Height)
+
+	// appending keeps existing images and the cover
+	images, err = add(1)
+	require.NoError(t, err)
+	require.Len(t, images, 3)
+	require.True(t, images[0].IsCover)
+	a, b, c := images[0].ID, images[1].ID, images[2].ID
+
+	// the cap counts existing images
+	_, err = add(8)
+	require.Equal(t, http.StatusBadRequest, statusOf(err))
+	require.Len(t, store.Keys(), 3)
+
+	images, err = uc.SetCover(t.Context(), uid, ImageOwnerCharacter, id, c)
+	require.NoError(t, err)
+	require.False(t, images[0].IsCover)
+	require.True(t, images[2].IsCover)
+
+	images, err = uc.ReorderImages(t.Context(), uid, ImageOwnerCharacter, id, []string{c, a, b})
+	require.NoError(t, err)
+	require.Equal(t, []string{c, a, b}, imageIDs(images))
+	require.Equal(t, 2, images[2].Position)
+	require.True(t, images[0].IsCover)
+
+	_, err = uc.ReorderImages(t.Context(), uid, ImageOwnerCharacter, id, []string{c, a, a})
+	require.Equal(t, http.StatusBadRequest, statusOf(err))
+	_, err = uc.ReorderImages(t.Context(), uid, ImageOwnerCharacter, id, []string{c, a})
+	require.Equal(t, http.StatusBadRequest, statusOf(err))
+
+	// deleting the cover promotes the next image and removes the blob
+	images, err = uc.DeleteImage(t.Context(), uid, ImageOwnerCharacter, id, c)
+	require.NoError(t, err)
+	require.Equal(t, []string{a, b}, imageIDs(images))
+	require.True(t, images[0].IsCover)
+	require.Len(t, store.Keys(), 2)
+
+	_, err = uc.DeleteImage(t.Context(), uid, ImageOwnerCharacter, id, c)
+	require.Equal(t, http.StatusNotFound, statusOf(err))
+	_, err = uc.SetCover(t.Context(), uuid.NewString(), ImageOwnerCharacter, id, a)
+	require.Equal(t, http.StatusForbidden, statusOf(err))
+}
+
+func TestChangeImagesHidesItemsTheUserCannotSee(t *testing.T) {
+	uc, _, _, char := newImageFixture(t)
+	id := char.ID.String()
+
+	char.Privacy = model.PrivacyPrivate
+	_, err := uc.SetCover(t.Context(), uuid.NewString(), ImageOwnerCharacter, id, uuid.NewString())
+	require.Equal(t, http.StatusNotFound, statusOf(err))
+
+	char.Privacy, char.Status = model.PrivacyPublic, model.ItemStatusArchived
+	_, err = uc.SetCover(t.Context(), uuid.NewString(), ImageOwnerCharacter, id, uuid.NewString())
+	require.Equal(t, http.StatusNotFound, statusOf(err))
+	_, err = uc.SetCover(t.Context(), char.UserID.String(), ImageOwnerCharacter, id, uuid.NewString())
+	require.Equal(t, http.StatusBadRequest, statusOf(err))
+
+	_, err = uc.SetCover(t.Context(), char.UserID.String(), ImageOwnerCharacter, uuid.NewString(), uuid.NewString())
+	require.Equal(t, http.StatusNotFound, statusOf(err))
+}
+
+func TestOpenImageChecksAccess(t *testing.T) {
+	uc, images, store, char := newImageFixture(t)
+	uid, id := char.UserID.String(), char.ID.String()
+	_, err := uc.ReplaceImages(t.Context(), uid, ImageOwnerCharacter, id, uploadFiles(t, map[string][]byte{"a.png": testPNG(t, 2, 2)}))
+	require.NoError(t, err)
+	key := images.characters[id][0].Path
+
+	// blobs no image row references are never served
+	require.NoError(t, store.Put(t.Context(), "stray.png", bytes.NewReader(testPNG(t, 1, 1)), -1, "image/png"))
+	_, err = uc.Open(t.Context(), Viewer{}, "stray.png", "", "")
+	require.Equal(t, http.StatusNotFound, statusOf(err))
+
+	char.Privacy = model.PrivacyPrivate
+	images.owners[id] = repository.ImageOwnerInfo{UserID: char.UserID, Privacy: char.Privacy, Status: char.Status}
+
+	_, err = uc.Open(t.Context(), Viewer{}, key, "", "")
+	require.Equal(t, http.StatusNotFound, statusOf(err))
+	_, err = uc.Open(t.Context(), Viewer{UserID: uuid.NewString()}, key, "", "")
+	require.Equal(t, http.StatusNotFound, statusOf(err))
+
+	file, err := uc.Open(t.Context(), Viewer{UserID: uid}, key, "", "")
+	require.NoError(t, err)
+	file.Body.Close()
+	require.False(t, file.Public)
+	file, err = uc.Open(t.Context(), Viewer{UserID: uuid.NewString(), Role: model.RoleAdmin}, key, "", "")
+	require.NoError(t, err)
+	file.Body.Close()
+
+	// the URLs handed out for a private item carry a signature
+	list, err := uc.AddImages(t.Context(), uid, ImageOwnerCharacter, id, uploadFiles(t, map[string][]byte{"b.png": testPNG(t, 2, 2)}))
+	require.NoError(t, err)
+	u, err := url.Parse(list[0].URL)
+	require.NoError(t, err)
+	require.NotEmpty(t, u.Query().Get("sig"))
+	file, err = uc.Open(t.Context(), Viewer{}, path.Base(u.Path), u.Query().Get("exp"), u.Query().Get("sig"))
+	require.NoError(t, err)
+	file.Body.Close()
+
+	// a signature only covers its own key
+	_, err = uc.Open(t.Context(), Viewer{}, imageKey(images.characters[id][1].Path), u.Query().Get("exp"), u.Query().Get("sig"))
+	require.Equal(t, http.StatusNotFound, statusOf(err))
+}
@@ -1,174 +1,591 @@
 package usecases
 
 import (
+	"context"
 	"dungeons-dragon-service/internal/config"
 	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/port"
 	"dungeons-dragon-service/internal/domain/repository"
+	"dungeons-dragon-service/internal/dto"
 	"dungeons-dragon-service/internal/helper"
 	"dungeons-dragon-service/internal/http/custom"
-	"encoding/json"
+	"dungeons-dragon-service/internal/infrastructure/logging"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"mime/multipart"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
-	"gorm.io/datatypes"
+	"github.com/google/uuid"
 )
 
+const (
+	defaultMaxFileSize    = 10 << 20
+	defaultMaxUploadSize  = 50 << 20
+	defaultMaxImageDim    = 10_000
+	defaultMaxImagePixels = 40_000_000
+)
+
+// ImageOwner names what an image belongs to.
+type ImageOwner string
+
+const (
+	ImageOwnerCharacter ImageOwner = "character"
+	ImageOwnerQuest     ImageOwner = "quest"
+)
+
+// ImageUseCase manages the ordered image list of a character or quest. Every
+// change returns the resulting list.
 type ImageUseCase interface {
-	UploadCharacterImage(uid string, characterID string, images []*multipart.FileHeader) error
-	UploadQuestImage(uid string, questID string, images []*multipart.FileHeader) error
+	// AddImages appends images up to the per item cap.
+	AddImages(ctx context.Context, uid string, owner ImageOwner, ownerID string, images []*multipart.FileHeader) ([]dto.ImageResponse, error)
+	// ReplaceImages swaps all images for the uploaded ones.
+	ReplaceImages(ctx context.Context, uid string, owner ImageOwner, ownerID string, images []*multipart.FileHeader) ([]dto.ImageResponse, error)
+	DeleteImage(ctx context.Context, uid string, owner ImageOwner, ownerID string, imageID string) ([]dto.ImageResponse, error)
+	// ReorderImages takes every image id once, in the new order.
+	ReorderImages(ctx context.Context, uid string, owner ImageOwner, ownerID string, imageIDs []string) ([]dto.ImageResponse, error)
+	SetCover(ctx context.Context, uid string, owner ImageOwner, ownerID string, imageID string) ([]dto.ImageResponse, error)
+	// Open returns a stored image the viewer may see. exp and sig are the
+	// query values of a signed URL and may be empty.
+	Open(ctx context.Context, viewer Viewer, filename, exp, sig string) (*ImageFile, error)
+	// MaxUploadSize is the byte limit for all images of one request.
+	MaxUploadSize() int64
+}
+
+// imageLimits bounds what an upload may contain. MaxDimension and MaxPixels
+// are checked against the image header so decompression bombs are rejected
+// before anything decodes them.
+type imageLimits struct {
+	MaxFileSize   int64
+	MaxUploadSize int64
+	MaxDimension  int
+	MaxPixels     int64
 }
 
 type imageUseCase struct {
 	images     repository.ImageRepository
 	characters repository.CharacterRepository
 	quests     repository.QuestRepository
+	store      port.BlobStore
+	variants   VariantQueue
+	limits     imageLimits
 }
 
-func NewImageUsecase(images repository.ImageRepository, characters repository.CharacterRepository, quests repository.QuestRepository) ImageUseCase {
-	return &imageUseCase{images: images, characters: characters, quests: quests}
+// NewImageUsecase wires the image operations. variants may be nil, in which
+// case new images stay pending until a variant worker picks them up.
+func NewImageUsecase(images repository.ImageRepository, characters repository.CharacterRepository, quests repository.QuestRepository, store port.BlobStore, variants VariantQueue) ImageUseCase {
+	u := &imageUseCase{
+		images:     images,
+		characters: characters,
+		quests:     quests,
+		store:      store,
+		variants:   variants,
+		limits: imageLimits{
+			MaxFileSize:   config.GetConfigInt64("MAX_FILE_SIZE"),
+			MaxUploadSize: config.GetConfigInt64("MAX_UPLOAD_SIZE"),
+			MaxDimension:  config.GetConfigInt("MAX_IMAGE_DIMENSION"),
+			MaxPixels:     config.GetConfigInt64("MAX_IMAGE_PIXELS"),
+		},
+	}
+	if u.limits.MaxFileSize <= 0 {
+		u.limits.MaxFileSize = defaultMaxFileSize
+	}
+	if u.limits.MaxUploadSize <= 0 {
+		u.limits.MaxUploadSize = defaultMaxUploadSize
+	}
+	if u.limits.MaxDimension <= 0 {
+		u.limits.MaxDimension = defaultMaxImageDim
+	}
+	if u.limits.MaxPixels <= 0 {
+		u.limits.MaxPixels = defaultMaxImagePixels
+	}
+	return u
+}
+
+func (u *imageUseCase) MaxUploadSize() int64 {
+	return u.limits.MaxUploadSize
+}
+
+// ImageFile is an image ready to be served. Public images belong to a public,
+// active item and may be cached briefly by shared caches.
+type ImageFile struct {
+	Body   io.ReadCloser
+	Info   *port.BlobInfo
+	Public bool
 }
 
-func (u *imageUseCase) UploadCharacterImage(userID string, characterID string, images []*multipart.FileHeader) error {
-	character, err := u.characters.FindByID(characterID)
+// Open serves only keys referenced by an image row. Images of private or
+// inactive items need an owner or admin viewer or a valid signature; anyone
+// else gets not found so the key does not leak that the image exists.
+func (u *imageUseCase) Open(ctx context.Context, viewer Viewer, filename, exp, sig string) (*ImageFile, error) {
+	owner, err := u.images.FindOwnerByKey(ctx, filename)
 	if err != nil {
-		return err
+		return nil, custom.NewUnexpectedError("failed to load image")
+	}
+	if owner == nil {
+		return nil, custom.NewNotFoundError("image not found")
 	}
-	if character == nil {
-		return custom.NewNotFoundError("character not found")
+	public := owner.Privacy == model.PrivacyPublic && owner.Status == model.ItemStatusActive
+	if !public && !viewer.canView(owner.UserID, owner.Privacy, owner.Status) && !helper.VerifyImageSignature(filename, exp, sig, time.Now()) {
+		return nil, custom.NewNotFoundError("image not found")
 	}
-	if character.UserID.String() != userID {
-		return custom.NewUnauthorizedError("you do not have permission to upload images for this character")
+	rc, info, err := u.store.Get(ctx, filename)
+	if errors.Is(err, port.ErrBlobNotFound) {
+		return nil, custom.NewNotFoundError("image not found")
 	}
-	if character.Status == model.ItemStatusArchived {
-		return custom.NewBadRequestError("cannot upload images to an archived character")
+	if err != nil {
+		return nil, custom.NewUnexpectedError("failed to load image")
 	}
+	return &ImageFile{Body: rc, Info: info, Public: public}, nil
+}
 
-	paths := make([]string, 0, len(images))
-	if err := helper.ValidateImages(len(images)); err == nil {
-		for _, img := range images {
-			imageName := fmt.Sprintf("%d-%s", time.Now().UnixNano()%1_000_000, filepath.Base(img.Filename))
-			imagePath := filepath.Join(config.GetConfigString("FILE_STORAGE_PATH"), imageName)
+// storedImage is an upload that made it into the blob store.
+type storedImage struct {
+	Key  string
+	Meta model.ImageMeta
+}
 
-			if err := helper.SaveUploadedFile(img, imagePath); err != nil {
-				custom.PanicException(custom.NewUnexpectedError("failed to save image"))
-			}
-			paths = append(paths, imagePath)
+// checkUpload validates the declared sizes before any file is read.
+func (u *imageUseCase) checkUpload(images []*multipart.FileHeader) error {
+	var total int64
+	for _, img := range images {
+		if img.Size > u.limits.MaxFileSize {
+			return custom.NewPayloadTooLargeError(fmt.Sprintf("each image must be at most %d bytes", u.limits.MaxFileSize))
 		}
-	} else {
-		return err
+		total += img.Size
+	}
+	if total > u.limits.MaxUploadSize {
+		return custom.NewPayloadTooLargeError(fmt.Sprintf("images must be at most %d bytes in total", u.limits.MaxUploadSize))
 	}
+	return nil
+}
 
-	characterImages, err := u.images.GetCharacterImageByID(characterID)
+// saveImage checks the content of img and uploads it under a random key.
+// The type comes from the magic bytes, never from the client; the client
+// file name is only kept as metadata.
+func (u *imageUseCase) saveImage(ctx context.Context, img *multipart.FileHeader) (*storedImage, error) {
+	src, err := img.Open()
 	if err != nil {
-		return err
-	}
-	if characterImages != nil {
-		//loop for remove old images
-		// Delete existing image
-		for _, img := range characterImages {
-			if err := helper.DeleteFileIfExists(img.Path); err != nil {
-				log.Println("failed to delete old character image:", err)
-			}
-		}
-		if err := u.images.DeleteCharacterImageByID(characterID); err != nil {
-			return custom.NewUnexpectedError("failed to delete existing character image")
+		return nil, custom.NewUnexpectedError("failed to read image")
+	}
+	defer src.Close()
+
+	head := make([]byte, helper.ImageSniffLen)
+	n, _ := io.ReadFull(src, head)
+	contentType, ok := helper.DetectImageType(head[:n])
+	if !ok {
+		return nil, custom.NewUnsupportedMediaTypeError("unsupported image type, use jpeg, png, gif or webp")
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, custom.NewUnexpectedError("failed to read image")
+	}
+	width, height, err := helper.DecodeImageSize(io.LimitReader(src, u.limits.MaxFileSize), contentType)
+	if err != nil || width <= 0 || height <= 0 {
+		return nil, custom.NewBadRequestError("invalid image")
+	}
+	if width > u.limits.MaxDimension || height > u.limits.MaxDimension || int64(width)*int64(height) > u.limits.MaxPixels {
+		return nil, custom.NewBadRequestError(fmt.Sprintf("image dimensions must be at most %dx%d and %d pixels", u.limits.MaxDimension, u.limits.MaxDimension, u.limits.MaxPixels))
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, custom.NewUnexpectedError("failed to read image")
+	}
+	key := uuid.NewString() + helper.ImageExtension(contentType)
+	body := &maxBytesReader{r: src, n: u.limits.MaxFileSize}
+	if err := u.store.Put(ctx, key, body, img.Size, contentType); err != nil {
+		if body.exceeded {
+			return nil, custom.NewPayloadTooLargeError(fmt.Sprintf("each image must be at most %d bytes", u.limits.MaxFileSize))
 		}
+		return nil, custom.NewUnexpectedError("failed to save image")
 	}
+	return &storedImage{Key: key, Meta: model.ImageMeta{
+		OriginalName: originalName(img.Filename),
+		ContentType:  contentType,
+		Size:         body.read,
+		Width:        width,
+		Height:       height,
+	}}, nil
+}
+
+// originalName trims the client file name to something safe to store.
+func originalName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	if r := []rune(name); len(r) > 255 {
+		name = string(r[:255])
+	}
+	return name
+}
 
-	// Create new image
-	var imagePaths []string
-	for _, path := range paths {
-		charImg, err := u.images.CreateCharacterImage(&model.CharacterImage{
-			CharacterID: character.ID,
-			Path:        path,
-		})
+var errImageTooLarge = errors.New("image too large")
+
+// maxBytesReader fails once more than n bytes were read, so the size limit
+// holds even if the declared part size was wrong.
+type maxBytesReader struct {
+	r        io.Reader
+	n        int64
+	read     int64
+	exceeded bool
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	m.read += int64(n)
+	if m.read > m.n {
+		m.exceeded = true
+		return n, errImageTooLarge
+	}
+	return n, err
+}
+
+// storeUploads validates and stores all images. If one fails, the ones
+// already written are removed again.
+func (u *imageUseCase) storeUploads(ctx context.Context, images []*multipart.FileHeader) ([]*storedImage, error) {
+	if err := u.checkUpload(images); err != nil {
+		return nil, err
+	}
+	stored := make([]*storedImage, 0, len(images))
+	for _, img := range images {
+		saved, err := u.saveImage(ctx, img)
 		if err != nil {
-			return custom.NewUnexpectedError("failed to create character image")
+			u.discardUploads(ctx, stored)
+			return nil, err
 		}
-		imagePaths = append(imagePaths, charImg.Path)
+		stored = append(stored, saved)
 	}
-	// Update character images as string slice
-	imageBytes, err := json.Marshal(imagePaths)
-	if err != nil {
-		return custom.NewUnexpectedError("failed to marshal character images")
+	return stored, nil
+}
+
+func (u *imageUseCase) discardUploads(ctx context.Context, stored []*storedImage) {
+	for _, s := range stored {
+		u.deleteImage(ctx, s.Key)
 	}
-	character.ImagePath = datatypes.JSON(imageBytes)
-	if _, err := u.characters.Update(character); err != nil {
-		return custom.NewUnexpectedError("failed to update character images")
+}
+
+// imageKey returns the blob key of an image path. Rows written before the
+// blob store held the full file path; the base name is the key.
+func imageKey(p string) string {
+	return path.Base(filepath.ToSlash(p))
+}
+
+// deleteImage removes a stored image. Failures only leave an orphan blob
+// behind, so they are logged and not returned. It cleans up after failed
+// or finished requests, so it runs even when ctx was cancelled.
+func (u *imageUseCase) deleteImage(ctx context.Context, p string) {
+	if err := u.store.Delete(context.WithoutCancel(ctx), imageKey(p)); err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "failed to delete image", "path", p, "error", err)
 	}
+}
 
-	return nil
+// imageItem is what the image operations need of a character or quest image.
+type imageItem struct {
+	ID            uuid.UUID
+	Path          string
+	Position      int
+	IsCover       bool
+	VariantStatus model.VariantStatus
+	Variants      []model.ImageVariant
+	Meta          model.ImageMeta
 }
 
-func (u *imageUseCase) UploadQuestImage(userID string, questID string, images []*multipart.FileHeader) error {
-	quest, err := u.quests.FindByID(questID)
-	if err != nil {
-		return err
+// imageList is the current image list of one character or quest, with the
+// function that stores a changed list.
+type imageList struct {
+	owner ImageOwner
+	items []imageItem
+	// private lists get signed image URLs
+	private bool
+	save    func(ctx context.Context, expected []string, items []imageItem) (saved []imageItem, removed []imageItem, err error)
+}
+
+func (l *imageList) ids() []string {
+	ids := make([]string, len(l.items))
+	for i, it := range l.items {
+		ids[i] = it.ID.String()
 	}
-	if quest == nil {
-		return custom.NewNotFoundError("quest not found")
+	return ids
+}
+
+func (l *imageList) index(imageID string) int {
+	for i, it := range l.items {
+		if it.ID.String() == imageID {
+			return i
+		}
 	}
-	if quest.UserID.String() != userID {
-		return custom.NewUnauthorizedError("you do not have permission to upload images for this quest")
+	return -1
+}
+
+func characterImageItems(imgs []model.CharacterImage) []imageItem {
+	items := make([]imageItem, len(imgs))
+	for i, img := range imgs {
+		items[i] = imageItem{ID: img.ID, Path: img.Path, Position: img.Position, IsCover: img.IsCover, VariantStatus: img.VariantStatus, Variants: img.Variants, Meta: img.ImageMeta}
 	}
-	if quest.Status == model.ItemStatusArchived {
-		return custom.NewBadRequestError("cannot upload images to an archived quest")
+	return items
+}
+
+func questImageItems(imgs []model.QuestImage) []imageItem {
+	items := make([]imageItem, len(imgs))
+	for i, img := range imgs {
+		items[i] = imageItem{ID: img.ID, Path: img.Path, Position: img.Position, IsCover: img.IsCover, VariantStatus: img.VariantStatus, Variants: img.Variants, Meta: img.ImageMeta}
 	}
-	paths := make([]string, 0, len(images))
-	if err := helper.ValidateImages(len(images)); err == nil {
-		for _, img := range images {
-			imageName := fmt.Sprintf("%d-%s", time.Now().UnixNano()%1_000_000, filepath.Base(img.Filename))
-			imagePath := filepath.Join(config.GetConfigString("FILE_STORAGE_PATH"), imageName)
+	return items
+}
 
-			if err := helper.SaveUploadedFile(img, imagePath); err != nil {
-				custom.PanicException(custom.NewUnexpectedError("failed to save image"))
+// loadImages checks that userID may change the images of the owner and
+// returns its current image list. Items the user may not see are reported as
+// not found, like Get does; visible items of someone else are forbidden.
+func (u *imageUseCase) loadImages(ctx context.Context, userID string, owner ImageOwner, ownerID string) (*imageList, error) {
+	viewer := Viewer{UserID: userID}
+	switch owner {
+	case ImageOwnerCharacter:
+		character, err := u.characters.FindByID(ctx, ownerID)
+		if err != nil {
+			return nil, custom.NewUnexpectedError("failed to load character")
+		}
+		if character == nil || !viewer.canView(character.UserID, character.Privacy, character.Status) {
+			return nil, custom.NewNotFoundError("character not found")
+		}
+		if !viewer.owns(character.UserID) {
+			return nil, custom.NewForbiddenError("forbidden")
+		}
+		if character.Status == model.ItemStatusArchived {
+			return nil, custom.NewBadRequestError("cannot change images of an archived character")
+		}
+		imgs, err := u.images.GetCharacterImageByID(ctx, ownerID)
+		if err != nil {
+			return nil, custom.NewUnexpectedError("failed to load character images")
+		}
+		return &imageList{owner: owner, items: characterImageItems(imgs), private: isPrivateItem(character.Privacy, character.Status), save: func(ctx context.Context, expected []string, items []imageItem) ([]imageItem, []imageItem, error) {
+			rows := make([]model.CharacterImage, len(items))
+			for i, it := range items {
+				rows[i] = model.CharacterImage{Base: model.Base{ID: it.ID}, CharacterID: character.ID, Path: it.Path, IsCover: it.IsCover, VariantStatus: it.VariantStatus, Variants: it.Variants, ImageMeta: it.Meta}
 			}
-			paths = append(paths, imagePath)
+			removed, err := u.images.SaveCharacterImages(ctx, ownerID, expected, rows)
+			if err != nil {
+				return nil, nil, err
+			}
+			return characterImageItems(rows), characterImageItems(removed), nil
+		}}, nil
+	case ImageOwnerQuest:
+		quest, err := u.quests.FindByID(ctx, ownerID)
+		if err != nil {
+			return nil, custom.NewUnexpectedError("failed to load quest")
+		}
+		if quest == nil || !viewer.canView(quest.UserID, quest.Privacy, quest.Status) {
+			return nil, custom.NewNotFoundError("quest not found")
+		}
+		if !viewer.owns(quest.UserID) {
+			return nil, custom.NewForbiddenError("forbidden")
 		}
-	} else {
-		return err
+		if quest.Status == model.ItemStatusArchived {
+			return nil, custom.NewBadRequestError("cannot change images of an archived quest")
+		}
+		imgs, err := u.images.GetQuestImageByID(ctx, ownerID)
+		if err != nil {
+			return nil, custom.NewUnexpectedError("failed to load quest images")
+		}
+		return &imageList{owner: owner, items: questImageItems(imgs), private: isPrivateItem(quest.Privacy, quest.Status), save: func(ctx context.Context, expected []string, items []imageItem) ([]imageItem, []imageItem, error) {
+			rows := make([]model.QuestImage, len(items))
+			for i, it := range items {
+				rows[i] = model.QuestImage{Base: model.Base{ID: it.ID}, QuestID: quest.ID, Path: it.Path, IsCover: it.IsCover, VariantStatus: it.VariantStatus, Variants: it.Variants, ImageMeta: it.Meta}
+			}
+			removed, err := u.images.SaveQuestImages(ctx, ownerID, expected, rows)
+			if err != nil {
+				return nil, nil, err
+			}
+			return questImageItems(rows), questImageItems(removed), nil
+		}}, nil
 	}
+	return nil, custom.NewBadRequestError("invalid image owner")
+}
 
-	questImages, err := u.images.GetQuestImageByID(questID)
+// commit stores the changed list in one transaction. Blobs of removed images
+// are deleted only after the commit; on failure the new uploads are removed.
+// New images are handed to the variant worker once they are committed.
+func (u *imageUseCase) commit(ctx context.Context, list *imageList, items []imageItem, uploads []*storedImage) ([]dto.ImageResponse, error) {
+	normalizeCover(items)
+	saved, removed, err := list.save(ctx, list.ids(), items)
 	if err != nil {
-		return err
+		u.discardUploads(ctx, uploads)
+		if errors.Is(err, repository.ErrImagesChanged) {
+			return nil, custom.NewConflictError("images were changed by another request, please retry")
+		}
+		return nil, custom.NewUnexpectedError(fmt.Sprintf("failed to save %s images", list.owner))
+	}
+	for _, it := range removed {
+		u.deleteImage(ctx, it.Path)
+		for _, v := range it.Variants {
+			u.deleteImage(ctx, v.Key)
+		}
 	}
-	if questImages != nil {
-		//loop for remove old images
-		for _, img := range questImages {
-			if err := helper.DeleteFileIfExists(img.Path); err != nil {
-				log.Println("failed to delete old quest image:", err)
+	if u.variants != nil {
+		for _, it := range saved {
+			if it.VariantStatus == model.VariantStatusPending {
+				u.variants.Enqueue(VariantJob{Owner: list.owner, ImageID: it.ID.String(), Key: imageKey(it.Path)})
 			}
 		}
-		// Delete existing image
-		if err := u.images.DeleteQuestImageByID(questID); err != nil {
-			return custom.NewUnexpectedError("failed to delete existing quest image")
+	}
+	return imageResponses(saved, list.private), nil
+}
+
+// normalizeCover leaves exactly one cover image, the first one if none is set.
+func normalizeCover(items []imageItem) {
+	cover := -1
+	for i := range items {
+		if items[i].IsCover && cover < 0 {
+			cover = i
 		}
+		items[i].IsCover = false
 	}
-	// Create new image
-	var imagePaths []string
-	for _, path := range paths {
-		questImg, err := u.images.CreateQuestImage(&model.QuestImage{
-			QuestID: quest.ID,
-			Path:    path,
-		})
-		if err != nil {
-			return custom.NewUnexpectedError("failed to create quest image")
+	if cover < 0 && len(items) > 0 {
+		cover = 0
+	}
+	if cover >= 0 {
+		items[cover].IsCover = true
+	}
+}
+
+func uploadedItems(stored []*storedImage) []imageItem {
+	items := make([]imageItem, len(stored))
+	for i, s := range stored {
+		items[i] = imageItem{Path: s.Key, VariantStatus: model.VariantStatusPending, Meta: s.Meta}
+	}
+	return items
+}
+
+func (u *imageUseCase) AddImages(ctx context.Context, userID string, owner ImageOwner, ownerID string, images []*multipart.FileHeader) ([]dto.ImageResponse, error) {
+	if len(images) == 0 {
+		return nil, custom.NewBadRequestError("at least one image is required")
+	}
+	list, err := u.loadImages(ctx, userID, owner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := helper.ValidateImages(len(list.items) + len(images)); err != nil {
+		return nil, err
+	}
+	stored, err := u.storeUploads(ctx, images)
+	if err != nil {
+		return nil, err
+	}
+	items := append(append([]imageItem{}, list.items...), uploadedItems(stored)...)
+	return u.commit(ctx, list, items, stored)
+}
+
+// ReplaceImages writes the new blobs first, swaps the rows in one
+// transaction and deletes the old blobs once that committed.
+func (u *imageUseCase) ReplaceImages(ctx context.Context, userID string, owner ImageOwner, ownerID string, images []*multipart.FileHeader) ([]dto.ImageResponse, error) {
+	list, err := u.loadImages(ctx, userID, owner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := helper.ValidateImages(len(images)); err != nil {
+		return nil, err
+	}
+	stored, err := u.storeUploads(ctx, images)
+	if err != nil {
+		return nil, err
+	}
+	return u.commit(ctx, list, uploadedItems(stored), stored)
+}
+
+func (u *imageUseCase) DeleteImage(ctx context.Context, userID string, owner ImageOwner, ownerID string, imageID string) ([]dto.ImageResponse, error) {
+	list, err := u.loadImages(ctx, userID, owner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	i := list.index(imageID)
+	if i < 0 {
+		return nil, custom.NewNotFoundError("image not found")
+	}
+	items := append(append([]imageItem{}, list.items[:i]...), list.items[i+1:]...)
+	return u.commit(ctx, list, items, nil)
+}
+
+func (u *imageUseCase) ReorderImages(ctx context.Context, userID string, owner ImageOwner, ownerID string, imageIDs []string) ([]dto.ImageResponse, error) {
+	list, err := u.loadImages(ctx, userID, owner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(imageIDs) != len(list.items) {
+		return nil, custom.NewBadRequestError("image_ids must list every image exactly once")
+	}
+	items := make([]imageItem, 0, len(imageIDs))
+	seen := map[int]bool{}
+	for _, id := range imageIDs {
+		i := list.index(id)
+		if i < 0 || seen[i] {
+			return nil, custom.NewBadRequestError("image_ids must list every image exactly once")
 		}
-		imagePaths = append(imagePaths, questImg.Path)
+		seen[i] = true
+		items = append(items, list.items[i])
 	}
-	// Update quest images as string slice
-	imageBytes, err := json.Marshal(imagePaths)
+	return u.commit(ctx, list, items, nil)
+}
+
+func (u *imageUseCase) SetCover(ctx context.Context, userID string, owner ImageOwner, ownerID string, imageID string) ([]dto.ImageResponse, error) {
+	list, err := u.loadImages(ctx, userID, owner, ownerID)
 	if err != nil {
-		return custom.NewUnexpectedError("failed to marshal quest images")
+		return nil, err
+	}
+	i := list.index(imageID)
+	if i < 0 {
+		return nil, custom.NewNotFoundError("image not found")
 	}
-	quest.ImagePath = datatypes.JSON(imageBytes)
-	if _, err := u.quests.Update(quest); err != nil {
-		return custom.NewUnexpectedError("failed to update quest images")
+	items := append([]imageItem{}, list.items...)
+	for j := range items {
+		items[j].IsCover = j == i
 	}
+	return u.commit(ctx, list, items, nil)
+}
 
-	return nil
+// isPrivateItem reports whether images of an item need signed URLs.
+func isPrivateItem(privacy model.Privacy, status model.ItemStatus) bool {
+	return !slices.Contains(publicPrivacy, privacy) || !slices.Contains(publicStatus, status)
+}
+
+func imageResponses(items []imageItem, private bool) []dto.ImageResponse {
+	res := make([]dto.ImageResponse, len(items))
+	for i, it := range items {
+		res[i] = dto.ImageResponse{
+			ID:       it.ID.String(),
+			URL:      helper.GetImageURL(it.Path, private),
+			Position: i,
+			IsCover:  it.IsCover,
+			Width:    it.Meta.Width,
+			Height:   it.Meta.Height,
+			Status:   string(it.VariantStatus),
+		}
+		// srcset lists the variants and then the original, smallest first
+		var srcset []string
+		for _, v := range it.Variants {
+			url := helper.GetImageURL(v.Key, private)
+			res[i].Variants = append(res[i].Variants, dto.ImageVariantResponse{Name: v.Name, URL: url, Width: v.Width, Height: v.Height})
+			srcset = append(srcset, fmt.Sprintf("%s %dw", url, v.Width))
+		}
+		if len(srcset) > 0 && it.Meta.Width > 0 {
+			srcset = append(srcset, fmt.Sprintf("%s %dw", res[i].URL, it.Meta.Width))
+		}
+		res[i].SrcSet = strings.Join(srcset, ", ")
+	}
+	return res
+}
+
+// ResponseCharacterImages builds the image list of a character response.
+// Images stored before covers existed get the first one as cover. Images of
+// private items get signed URLs.
+func ResponseCharacterImages(imgs []model.CharacterImage, private bool) []dto.ImageResponse {
+	items := characterImageItems(imgs)
+	normalizeCover(items)
+	return imageResponses(items, private)
+}
+
+func ResponseQuestImages(imgs []model.QuestImage, private bool) []dto.ImageResponse {
+	items := questImageItems(imgs)
+	normalizeCover(items)
+	return imageResponses(items, private)
 }
@@ -0,0 +1,224 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/port"
+	"dungeons-dragon-service/internal/domain/repository"
+	"dungeons-dragon-service/internal/helper"
+	"dungeons-dragon-service/internal/infrastructure/logging"
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+	"sync"
+	"time"
+)
+
+// variantSpec is a resized copy made of every upload. Originals smaller than
+// a spec are not upscaled; that variant is left out.
+type variantSpec struct {
+	Name string
+	Max  int
+}
+
+var variantSpecs = []variantSpec{
+	{Name: "thumb", Max: 160},
+	{Name: "medium", Max: 640},
+	{Name: "large", Max: 1280},
+}
+
+const (
+	variantQueueSize     = 256
+	variantSweepInterval = time.Minute
+	variantSweepBatch    = 50
+)
+
+// VariantJob asks for the variants of one stored image.
+type VariantJob struct {
+	Owner   ImageOwner
+	ImageID string
+	Key     string
+}
+
+// VariantQueue accepts images whose variants should be generated.
+type VariantQueue interface {
+	Enqueue(job VariantJob)
+}
+
+// VariantWorker generates image variants in the background. Jobs come from
+// uploads through Enqueue; a periodic sweep picks up images still pending
+// after a restart or a full queue.
+type VariantWorker struct {
+	images  repository.ImageRepository
+	store   port.BlobStore
+	workers int
+	jobs    chan VariantJob
+
+	mu       sync.Mutex
+	inFlight map[string]bool
+}
+
+func NewVariantWorker(images repository.ImageRepository, store port.BlobStore, workers int) *VariantWorker {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &VariantWorker{
+		images:   images,
+		store:    store,
+		workers:  workers,
+		jobs:     make(chan VariantJob, variantQueueSize),
+		inFlight: map[string]bool{},
+	}
+}
+
+// Enqueue never blocks. A job that does not fit in the queue stays pending
+// and is found by the next sweep.
+func (w *VariantWorker) Enqueue(job VariantJob) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.inFlight[job.ImageID] {
+		return
+	}
+	select {
+	case w.jobs <- job:
+		w.inFlight[job.ImageID] = true
+	default:
+	}
+}
+
+// Run processes jobs until ctx is cancelled.
+func (w *VariantWorker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < w.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-w.jobs:
+					if err := w.process(ctx, job); err != nil {
+						logging.FromContext(ctx).ErrorContext(ctx, "failed to make image variants", "owner", job.Owner, "image_id", job.ImageID, "error", err)
+					}
+					w.mu.Lock()
+					delete(w.inFlight, job.ImageID)
+					w.mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(variantSweepInterval)
+	defer ticker.Stop()
+	for {
+		w.sweep(ctx)
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *VariantWorker) sweep(ctx context.Context) {
+	chars, err := w.images.PendingCharacterImages(ctx, variantSweepBatch)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to load pending character images", "error", err)
+	}
+	for _, img := range chars {
+		w.Enqueue(VariantJob{Owner: ImageOwnerCharacter, ImageID: img.ID.String(), Key: imageKey(img.Path)})
+	}
+	quests, err := w.images.PendingQuestImages(ctx, variantSweepBatch)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to load pending quest images", "error", err)
+	}
+	for _, img := range quests {
+		w.Enqueue(VariantJob{Owner: ImageOwnerQuest, ImageID: img.ID.String(), Key: imageKey(img.Path)})
+	}
+}
+
+// process makes the variants of one image and records them. Variants of an
+// image deleted in the meantime are removed again.
+func (w *VariantWorker) process(ctx context.Context, job VariantJob) error {
+	variants, status, err := w.generate(ctx, job.Key)
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "image variants skipped", "key", job.Key, "error", err)
+	}
+	var exists bool
+	var saveErr error
+	switch job.Owner {
+	case ImageOwnerCharacter:
+		exists, saveErr = w.images.SetCharacterImageVariants(ctx, job.ImageID, status, variants)
+	case ImageOwnerQuest:
+		exists, saveErr = w.images.SetQuestImageVariants(ctx, job.ImageID, status, variants)
+	default:
+		saveErr = fmt.Errorf("unknown image owner %q", job.Owner)
+	}
+	if saveErr != nil || !exists {
+		w.discard(ctx, variants)
+	}
+	return saveErr
+}
+
+func (w *VariantWorker) generate(ctx context.Context, key string) ([]model.ImageVariant, model.VariantStatus, error) {
+	rc, _, err := w.store.Get(ctx, key)
+	if errors.Is(err, port.ErrBlobNotFound) {
+		return nil, model.VariantStatusFailed, err
+	}
+	if err != nil {
+		// storage hiccup: stay pending so the sweep retries
+		return nil, model.VariantStatusPending, err
+	}
+	defer rc.Close()
+
+	src, _, err := image.Decode(rc)
+	if errors.Is(err, image.ErrFormat) {
+		// not a format a decoder is registered for; the original is served
+		return nil, model.VariantStatusSkipped, nil
+	}
+	if err != nil {
+		return nil, model.VariantStatusFailed, err
+	}
+
+	base := strings.TrimSuffix(key, imageKeyExt(key))
+	b := src.Bounds()
+	var variants []model.ImageVariant
+	for _, spec := range variantSpecs {
+		if b.Dx() <= spec.Max && b.Dy() <= spec.Max {
+			continue
+		}
+		width, height := helper.FitSize(b.Dx(), b.Dy(), spec.Max)
+		var buf bytes.Buffer
+		contentType, ext, err := helper.EncodeVariant(&buf, helper.ResizeImage(src, width, height))
+		if err != nil {
+			return w.discard(ctx, variants), model.VariantStatusFailed, err
+		}
+		vkey := base + "_" + spec.Name + ext
+		if err := w.store.Put(ctx, vkey, &buf, int64(buf.Len()), contentType); err != nil {
+			return w.discard(ctx, variants), model.VariantStatusPending, err
+		}
+		variants = append(variants, model.ImageVariant{Name: spec.Name, Key: vkey, Width: width, Height: height})
+	}
+	return variants, model.VariantStatusReady, nil
+}
+
+// discard removes variants written before a failure and returns nil, even
+// when the failure was ctx being cancelled.
+func (w *VariantWorker) discard(ctx context.Context, variants []model.ImageVariant) []model.ImageVariant {
+	ctx = context.WithoutCancel(ctx)
+	for _, v := range variants {
+		w.store.Delete(ctx, v.Key)
+	}
+	return nil
+}
+
+func imageKeyExt(key string) string {
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		return key[i:]
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package usecases
+
+import (
+	"dungeons-dragon-service/internal/domain/model"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingQueue struct {
+	jobs []VariantJob
+}
+
+func (q *recordingQueue) Enqueue(job VariantJob) {
+	q.jobs = append(q.jobs, job)
+}
+
+func TestVariantWorkerGeneratesVariants(t *testing.T) {
+	uc, images, store, char := newImageFixture(t)
+	queue := &recordingQueue{}
+	uc.variants = queue
+	worker := NewVariantWorker(images, store, 1)
+	uid, id := char.UserID.String(), char.ID.String()
+
+	res, err := uc.AddImages(t.Context(), uid, ImageOwnerCharacter, id, uploadFiles(t, map[string][]byte{"map.png": testPNG(t, 2000, 1000)}))
+	require.NoError(t, err)
+	require.Equal(t, "pending", res[0].Status)
+	require.Empty(t, res[0].SrcSet)
+	require.Len(t, queue.jobs, 1)
+
+	require.NoError(t, worker.process(t.Context(), queue.jobs[0]))
+	img := images.characters[id][0]
+	require.Equal(t, model.VariantStatusReady, img.VariantStatus)
+	require.Len(t, img.Variants, 3)
+	base := strings.TrimSuffix(img.Path, ".png")
+	require.Equal(t, model.ImageVariant{Name: "thumb", Key: base + "_thumb.png", Width: 160, Height: 80}, img.Variants[0])
+	require.Equal(t, 1280, img.Variants[2].Width)
+	require.Len(t, store.Keys(), 4)
+
+	out := ResponseCharacterImages(images.characters[id], false)[0]
+	require.Equal(t, "ready", out.Status)
+	require.Len(t, out.Variants, 3)
+	require.Equal(t, []string{"160w", "640w", "1280w", "2000w"}, srcSetWidths(t, out.SrcSet))
+
+	// deleting the image removes its variants too
+	_, err = uc.DeleteImage(t.Context(), uid, ImageOwnerCharacter, id, res[0].ID)
+	require.NoError(t, err)
+	require.Empty(t, store.Keys())
+}
+
+func TestVariantWorkerHandlesSmallAndWebPImages(t *testing.T) {
+	uc, images, store, char := newImageFixture(t)
+	queue := &recordingQueue{}
+	uc.variants = queue
+	worker := NewVariantWorker(images, store, 1)
+	uid, id := char.UserID.String(), char.ID.String()
+
+	webp, err := os.ReadFile("testdata/rose.webp")
+	require.NoError(t, err)
+	broken := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x00\x00\x00\x00\x1f\x00\x00\x1f\x00\x00\x00\x00")
+	_, err = uc.AddImages(t.Context(), uid, ImageOwnerCharacter, id, uploadFiles(t, map[string][]byte{"small.png": testPNG(t, 200, 100)}))
+	require.NoError(t, err)
+	_, err = uc.AddImages(t.Context(), uid, ImageOwnerCharacter, id, uploadFiles(t, map[string][]byte{"a.webp": webp}))
+	require.NoError(t, err)
+	_, err = uc.AddImages(t.Context(), uid, ImageOwnerCharacter, id, uploadFiles(t, map[string][]byte{"b.webp": broken}))
+	require.NoError(t, err)
+
+	for _, job := range queue.jobs {
+		require.NoError(t, worker.process(t.Context(), job))
+	}
+	imgs := images.characters[id]
+	require.Equal(t, model.VariantStatusReady, imgs[0].VariantStatus)
+	// only the thumbnail is smaller than the original
+	require.Len(t, imgs[0].Variants, 1)
+	// WebP originals are decoded like the rest
+	require.Equal(t, model.VariantStatusReady, imgs[1].VariantStatus)
+	require.Len(t, imgs[1].Variants, 1)
+	require.Equal(t, 160, imgs[1].Variants[0].Width)
+	require.Equal(t, model.VariantStatusFailed, imgs[2].VariantStatus)
+	require.Empty(t, imgs[2].Variants)
+}
+
+// srcSetWidths returns the width descriptor of every srcset candidate, in
+// order, checking that each one is a URL followed by a single descriptor.
+func srcSetWidths(t *testing.T, srcset string) []string {
+	t.Helper()
+	var widths []string
+	for _, candidate := range strings.Split(srcset, ", ") {
+		fields := strings.Fields(candidate)
+		require.Len(t, fields, 2, candidate)
+		widths = append(widths, fields[1])
+	}
+	return widths
+}
+
+func TestVariantWorkerDropsVariantsOfDeletedImage(t *testing.T) {
+	uc, images, store, char := newImageFixture(t)
+	queue := &recordingQueue{}
+	uc.variants = queue
+	worker := NewVariantWorker(images, store, 1)
+
+	res, err := uc.AddImages(t.Context(), char.UserID.String(), ImageOwnerCharacter, char.ID.String(), uploadFiles(t, map[string][]byte{"a.png": testPNG(t, 400, 400)}))
+	require.NoError(t, err)
+	_, err = uc.DeleteImage(t.Context(), char.UserID.String(), ImageOwnerCharacter, char.ID.String(), res[0].ID)
+	require.NoError(t, err)
+
+	require.NoError(t, worker.process(t.Context(), queue.jobs[0]))
+	require.Empty(t, store.Keys())
+}
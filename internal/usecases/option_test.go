@@ -1,7 +1,9 @@
 package usecases
 
 import (
+	"context"
 	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/repository"
 	"dungeons-dragon-service/internal/dto"
 	"dungeons-dragon-service/internal/helper"
 	"errors"
@@ -15,12 +17,12 @@ type mockQuestLevelRepo struct {
 	levels map[string]*model.QuestLevel
 }
 
-func (m *mockQuestLevelRepo) Create(q *model.QuestLevel) (*model.QuestLevel, error) {
+func (m *mockQuestLevelRepo) Create(_ context.Context, q *model.QuestLevel) (*model.QuestLevel, error) {
 	m.levels[q.ID.String()] = q
 	return q, nil
 }
 
-func (m *mockQuestLevelRepo) Update(q *model.QuestLevel) (*model.QuestLevel, error) {
+func (m *mockQuestLevelRepo) Update(_ context.Context, q *model.QuestLevel) (*model.QuestLevel, error) {
 	if _, exists := m.levels[q.ID.String()]; !exists {
 		return nil, errors.New("not found")
 	}
@@ -28,7 +30,7 @@ func (m *mockQuestLevelRepo) Update(q *model.QuestLevel) (*model.QuestLevel, err
 	return q, nil
 }
 
-func (m *mockQuestLevelRepo) Delete(id string) error {
+func (m *mockQuestLevelRepo) Delete(_ context.Context, id string) error {
 	if _, exists := m.levels[id]; !exists {
 		return errors.New("not found")
 	}
@@ -36,14 +38,14 @@ func (m *mockQuestLevelRepo) Delete(id string) error {
 	return nil
 }
 
-func (m *mockQuestLevelRepo) FindByID(id string) (*model.QuestLevel, error) {
+func (m *mockQuestLevelRepo) FindByID(_ context.Context, id string) (*model.QuestLevel, error) {
 	if q, exists := m.levels[id]; exists {
 		return q, nil
 	}
 	return nil, errors.New("not found")
 }
 
-func (m *mockQuestLevelRepo) List() ([]model.QuestLevel, error) {
+func (m *mockQuestLevelRepo) List(_ context.Context) ([]model.QuestLevel, error) {
 	var res []model.QuestLevel
 	for _, v := range m.levels {
 		res = append(res, *v)
@@ -57,12 +59,12 @@ type mockQuestRepo struct {
 	archived []string
 }
 
-func (m *mockQuestRepo) Create(q *model.Quest) (*model.Quest, error) {
+func (m *mockQuestRepo) Create(_ context.Context, q *model.Quest) (*model.Quest, error) {
 	m.quests[q.ID.String()] = q
 	return q, nil
 }
 
-func (m *mockQuestRepo) Update(q *model.Quest) (*model.Quest, error) {
+func (m *mockQuestRepo) Update(_ context.Context, q *model.Quest) (*model.Quest, error) {
 	if _, exists := m.quests[q.ID.String()]; !exists {
 		return nil, errors.New("not found")
 	}
@@ -70,7 +72,7 @@ func (m *mockQuestRepo) Update(q *model.Quest) (*model.Quest, error) {
 	return q, nil
 }
 
-func (m *mockQuestRepo) Delete(id string) error {
+func (m *mockQuestRepo) Delete(_ context.Context, id string) error {
 	if _, exists := m.quests[id]; !exists {
 		return errors.New("not found")
 	}
@@ -78,14 +80,11 @@ func (m *mockQuestRepo) Delete(id string) error {
 	return nil
 }
 
-func (m *mockQuestRepo) FindByID(id string) (*model.Quest, error) {
-	if q, exists := m.quests[id]; exists {
-		return q, nil
-	}
-	return nil, errors.New("not found")
+func (m *mockQuestRepo) FindByID(_ context.Context, id string) (*model.Quest, error) {
+	return m.quests[id], nil
 }
 
-func (m *mockQuestRepo) ListAll() ([]model.Quest, error) {
+func (m *mockQuestRepo) ListAll(_ context.Context) ([]model.Quest, error) {
 	var res []model.Quest
 	for _, v := range m.quests {
 		res = append(res, *v)
@@ -93,7 +92,7 @@ func (m *mockQuestRepo) ListAll() ([]model.Quest, error) {
 	return res, nil
 }
 
-func (m *mockQuestRepo) ListPublic() ([]model.Quest, error) {
+func (m *mockQuestRepo) ListPublic(_ context.Context) ([]model.Quest, error) {
 	var res []model.Quest
 	for _, v := range m.quests {
 		if v.Privacy == model.PrivacyPublic {
@@ -103,7 +102,7 @@ func (m *mockQuestRepo) ListPublic() ([]model.Quest, error) {
 	return res, nil
 }
 
-func (m *mockQuestRepo) ListByUser(userID string) ([]model.Quest, error) {
+func (m *mockQuestRepo) ListByUser(_ context.Context, userID string) ([]model.Quest, error) {
 	var res []model.Quest
 	for _, v := range m.quests {
 		if v.UserID == helper.ParseUUIDOrNil(userID) {
@@ -113,11 +112,39 @@ func (m *mockQuestRepo) ListByUser(userID string) ([]model.Quest, error) {
 	return res, nil
 }
 
-func (m *mockQuestRepo) ArchiveByQuestLevelID(questsLevelID string) error {
+func (m *mockQuestRepo) Search(_ context.Context, f repository.QuestFilter, p repository.PageRequest) ([]model.Quest, int64, error) {
+	status := f.Status
+	if status == "" {
+		status = model.ItemStatusActive
+	}
+	var res []model.Quest
+	for _, v := range m.quests {
+		if v.Status != status ||
+			!visibleTo(f.Visibility, v.UserID, v.Privacy, v.Status) ||
+			(f.Privacy != "" && v.Privacy != f.Privacy) ||
+			(f.QuestLevelID != "" && v.QuestLevelID.String() != f.QuestLevelID) ||
+			(f.UserID != "" && v.UserID.String() != f.UserID) {
+			continue
+		}
+		res = append(res, *v)
+	}
+	return pageSlice(res, p), int64(len(res)), nil
+}
+
+func (m *mockQuestRepo) ArchiveByQuestLevelID(_ context.Context, questsLevelID string) error {
 	m.archived = append(m.archived, questsLevelID)
 	return nil
 }
 
+func (m *mockQuestRepo) ArchiveByUserID(_ context.Context, userID string) error {
+	for _, q := range m.quests {
+		if q.UserID == helper.ParseUUIDOrNil(userID) {
+			q.Status = model.ItemStatusArchived
+		}
+	}
+	return nil
+}
+
 func TestOptionDeleteArchives(t *testing.T) {
 	charRepo := newMockCharRepo()
 	classRepo := mockClassRepo{
@@ -137,10 +164,12 @@ func TestOptionDeleteArchives(t *testing.T) {
 	questLevelRepo.levels["b6e3f5d4-3b8f-4eaf-bd77-cb4a2f11e5c1"] = &model.QuestLevel{Name: "Hard"}
 	questRepo := mockQuestRepo{}
 
-	uc := NewOptionUseCase(&classRepo, &raceRepo, &questLevelRepo, charRepo, &questRepo)
+	uc := NewOptionUseCase(&classRepo, &raceRepo, &questLevelRepo, &memTx{repos: repository.Repositories{
+		Classes: &classRepo, Races: &raceRepo, QuestLevels: &questLevelRepo, Characters: charRepo, Quests: &questRepo,
+	}})
 
 	// Create a character using class and race
-	_, _ = NewCharacterUsecase(charRepo, &classRepo, &raceRepo).Create("f6d28968-b689-4c50-b4cc-03ab84b47039", &dto.CreateCharacterInput{
+	_, _ = NewCharacterUsecase(charRepo, &classRepo, &raceRepo).Create(t.Context(), "f6d28968-b689-4c50-b4cc-03ab84b47039", &dto.CreateCharacterInput{
 		Title:       "Hero",
 		Description: "ok",
 		ClassID:     "3c75ef02-b390-423b-86fc-99c590921f29",
@@ -149,10 +178,34 @@ func TestOptionDeleteArchives(t *testing.T) {
 	})
 
 	// Delete class
-	err := uc.DeleteClass("3c75ef02-b390-423b-86fc-99c590921f29")
+	err := uc.DeleteClass(t.Context(), "3c75ef02-b390-423b-86fc-99c590921f29")
 	require.NoError(t, err)
 
 	//check character is archived
-	all, _ := charRepo.ListAll()
+	all, _ := charRepo.ListAll(t.Context())
 	require.Equal(t, model.ItemStatusArchived, all[0].Status)
 }
+
+func TestOptionDeleteRollsBack(t *testing.T) {
+	charRepo := newMockCharRepo()
+	classRepo := mockClassRepo{m: map[string]*model.Class{"3c75ef02-b390-423b-86fc-99c590921f29": {Name: "Warrior"}}}
+	raceRepo := mockRaceRepo{m: map[string]*model.Race{"66e9e0cf-8b74-4e73-8c90-7a2d4351f2e6": {Name: "Human"}}}
+	uc := NewOptionUseCase(&classRepo, &raceRepo, &mockQuestLevelRepo{}, &memTx{repos: repository.Repositories{
+		Classes: &classRepo, Races: &raceRepo, Characters: charRepo,
+	}})
+
+	_, err := NewCharacterUsecase(charRepo, &classRepo, &raceRepo).Create(t.Context(), "f6d28968-b689-4c50-b4cc-03ab84b47039", &dto.CreateCharacterInput{
+		Title:   "Hero",
+		ClassID: "3c75ef02-b390-423b-86fc-99c590921f29",
+		RaceID:  "66e9e0cf-8b74-4e73-8c90-7a2d4351f2e6",
+		Privacy: "public",
+	})
+	require.NoError(t, err)
+
+	// the class vanishes before the delete, so archiving must be undone
+	delete(classRepo.m, "3c75ef02-b390-423b-86fc-99c590921f29")
+	require.Error(t, uc.DeleteClass(t.Context(), "3c75ef02-b390-423b-86fc-99c590921f29"))
+
+	all, _ := charRepo.ListAll(t.Context())
+	require.Equal(t, model.ItemStatusActive, all[0].Status)
+}
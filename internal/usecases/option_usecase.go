@@ -1,6 +1,7 @@
 package usecases
 
 import (
+	"context"
 	"dungeons-dragon-service/internal/domain/model"
 	"dungeons-dragon-service/internal/domain/repository"
 	"dungeons-dragon-service/internal/dto"
@@ -9,22 +10,22 @@ import (
 
 type OptionUseCase interface {
 	// Classes
-	CreateClass(name string) error
-	UpdateClass(id string, name string) error
-	DeleteClass(id string) error
-	ListClasses() ([]dto.ClassResponse, error)
+	CreateClass(ctx context.Context, name string) error
+	UpdateClass(ctx context.Context, id string, name string) error
+	DeleteClass(ctx context.Context, id string) error
+	ListClasses(ctx context.Context) ([]dto.ClassResponse, error)
 
 	// Races
-	CreateRace(name string) error
-	UpdateRace(id string, name string) error
-	DeleteRace(id string) error
-	ListRaces() ([]dto.RaceResponse, error)
+	CreateRace(ctx context.Context, name string) error
+	UpdateRace(ctx context.Context, id string, name string) error
+	DeleteRace(ctx context.Context, id string) error
+	ListRaces(ctx context.Context) ([]dto.RaceResponse, error)
 
 	// Quest Levels
-	CreateQuestLevel(name string) error
-	UpdateQuestLevel(id string, name string) error
-	DeleteQuestLevel(id string) error
-	ListQuestLevels() ([]dto.QuestLevelResponse, error)
+	CreateQuestLevel(ctx context.Context, name string) error
+	UpdateQuestLevel(ctx context.Context, id string, name string) error
+	DeleteQuestLevel(ctx context.Context, id string) error
+	ListQuestLevels(ctx context.Context) ([]dto.QuestLevelResponse, error)
 }
 
 type optionUseCase struct {
@@ -32,13 +33,12 @@ type optionUseCase struct {
 	races       repository.RaceRepository
 	questLevels repository.QuestLevelRepository
 
-	chars  repository.CharacterRepository
-	quests repository.QuestRepository
+	// deleting an option archives the characters or quests using it
+	tx repository.TxManager
 }
 
-func NewOptionUseCase(c repository.ClassRepository, r repository.RaceRepository, d repository.QuestLevelRepository,
-	char repository.CharacterRepository, q repository.QuestRepository) OptionUseCase {
-	return &optionUseCase{classes: c, races: r, questLevels: d, chars: char, quests: q}
+func NewOptionUseCase(c repository.ClassRepository, r repository.RaceRepository, d repository.QuestLevelRepository, tx repository.TxManager) OptionUseCase {
+	return &optionUseCase{classes: c, races: r, questLevels: d, tx: tx}
 }
 
 func ResponseClasses(c []model.Class) []dto.ClassResponse {
@@ -75,38 +75,41 @@ func ResponseQuestLevels(d []model.QuestLevel) []dto.QuestLevelResponse {
 }
 
 // Classes
-func (u *optionUseCase) CreateClass(name string) error {
+func (u *optionUseCase) CreateClass(ctx context.Context, name string) error {
 	if name == "" {
 		return custom.NewBadRequestError("name required")
 	}
 	m := &model.Class{Name: name}
-	_, err := u.classes.Create(m)
+	_, err := u.classes.Create(ctx, m)
 	if err != nil {
 		return custom.NewUnexpectedError("failed to create class")
 	}
 	return nil
 }
-func (u *optionUseCase) UpdateClass(id string, name string) error {
-	m, err := u.classes.FindByID(id)
+func (u *optionUseCase) UpdateClass(ctx context.Context, id string, name string) error {
+	m, err := u.classes.FindByID(ctx, id)
 	if err != nil {
 		return custom.NewNotFoundError("class not found")
 	}
 	m.Name = name
-	_, err = u.classes.Update(m)
+	_, err = u.classes.Update(ctx, m)
 	if err != nil {
 		return custom.NewUnexpectedError("failed to update class")
 	}
 	return nil
 }
-func (u *optionUseCase) DeleteClass(id string) error {
-	// Archive related characters, then delete class
-	if err := u.chars.ArchiveByClassID(id); err != nil {
-		return err
-	}
-	return u.classes.Delete(id)
+func (u *optionUseCase) DeleteClass(ctx context.Context, id string) error {
+	// Archive related characters and delete the class together, so no
+	// active character is left with a deleted class
+	return u.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		if err := repos.Characters.ArchiveByClassID(ctx, id); err != nil {
+			return err
+		}
+		return repos.Classes.Delete(ctx, id)
+	})
 }
-func (u *optionUseCase) ListClasses() ([]dto.ClassResponse, error) {
-	list, err := u.classes.List()
+func (u *optionUseCase) ListClasses(ctx context.Context) ([]dto.ClassResponse, error) {
+	list, err := u.classes.List(ctx)
 	if err != nil {
 		return nil, custom.NewUnexpectedError("failed to list classes")
 	}
@@ -114,34 +117,36 @@ func (u *optionUseCase) ListClasses() ([]dto.ClassResponse, error) {
 }
 
 // Races
-func (u *optionUseCase) CreateRace(name string) error {
+func (u *optionUseCase) CreateRace(ctx context.Context, name string) error {
 	if name == "" {
 		return custom.NewBadRequestError("name required")
 	}
 	m := &model.Race{Name: name}
-	_, err := u.races.Create(m)
+	_, err := u.races.Create(ctx, m)
 	return err
 }
-func (u *optionUseCase) UpdateRace(id string, name string) error {
-	m, err := u.races.FindByID(id)
+func (u *optionUseCase) UpdateRace(ctx context.Context, id string, name string) error {
+	m, err := u.races.FindByID(ctx, id)
 	if err != nil {
 		return custom.NewNotFoundError("race not found")
 	}
 	m.Name = name
-	_, err = u.races.Update(m)
+	_, err = u.races.Update(ctx, m)
 	if err != nil {
 		return custom.NewUnexpectedError("failed to update race")
 	}
 	return nil
 }
-func (u *optionUseCase) DeleteRace(id string) error {
-	if err := u.chars.ArchiveByRaceID(id); err != nil {
-		return err
-	}
-	return u.races.Delete(id)
+func (u *optionUseCase) DeleteRace(ctx context.Context, id string) error {
+	return u.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		if err := repos.Characters.ArchiveByRaceID(ctx, id); err != nil {
+			return err
+		}
+		return repos.Races.Delete(ctx, id)
+	})
 }
-func (u *optionUseCase) ListRaces() ([]dto.RaceResponse, error) {
-	list, err := u.races.List()
+func (u *optionUseCase) ListRaces(ctx context.Context) ([]dto.RaceResponse, error) {
+	list, err := u.races.List(ctx)
 	if err != nil {
 		return nil, custom.NewUnexpectedError("failed to list races")
 	}
@@ -149,37 +154,39 @@ func (u *optionUseCase) ListRaces() ([]dto.RaceResponse, error) {
 }
 
 // Difficulties
-func (u *optionUseCase) CreateQuestLevel(name string) error {
+func (u *optionUseCase) CreateQuestLevel(ctx context.Context, name string) error {
 	if name == "" {
 		return custom.NewBadRequestError("name required")
 	}
 	m := &model.QuestLevel{Name: name}
-	_, err := u.questLevels.Create(m)
+	_, err := u.questLevels.Create(ctx, m)
 	if err != nil {
 		return custom.NewUnexpectedError("failed to create quest level")
 	}
 	return nil
 }
-func (u *optionUseCase) UpdateQuestLevel(id string, name string) error {
-	m, err := u.questLevels.FindByID(id)
+func (u *optionUseCase) UpdateQuestLevel(ctx context.Context, id string, name string) error {
+	m, err := u.questLevels.FindByID(ctx, id)
 	if err != nil {
 		return custom.NewNotFoundError("quest level not found")
 	}
 	m.Name = name
-	_, err = u.questLevels.Update(m)
+	_, err = u.questLevels.Update(ctx, m)
 	if err != nil {
 		return custom.NewUnexpectedError("failed to update quest level")
 	}
 	return nil
 }
-func (u *optionUseCase) DeleteQuestLevel(id string) error {
-	if err := u.quests.ArchiveByQuestLevelID(id); err != nil {
-		return err
-	}
-	return u.questLevels.Delete(id)
+func (u *optionUseCase) DeleteQuestLevel(ctx context.Context, id string) error {
+	return u.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		if err := repos.Quests.ArchiveByQuestLevelID(ctx, id); err != nil {
+			return err
+		}
+		return repos.QuestLevels.Delete(ctx, id)
+	})
 }
-func (u *optionUseCase) ListQuestLevels() ([]dto.QuestLevelResponse, error) {
-	list, err := u.questLevels.List()
+func (u *optionUseCase) ListQuestLevels(ctx context.Context) ([]dto.QuestLevelResponse, error) {
+	list, err := u.questLevels.List(ctx)
 	if err != nil {
 		return nil, custom.NewUnexpectedError("failed to list quest levels")
 	}
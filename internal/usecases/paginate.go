@@ -0,0 +1,164 @@
+package usecases
+
+import (
+	"dungeons-dragon-service/internal/domain/repository"
+	"dungeons-dragon-service/internal/dto"
+	"dungeons-dragon-service/internal/http/custom"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	defaultSort      = "-created_at"
+)
+
+// sort keys accepted by the character and quest listings
+var listSortFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"title":      true,
+}
+
+// sort keys accepted by the admin user listing
+var userSortFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"username":   true,
+}
+
+// sort keys whose cursor value is plain text rather than a timestamp
+var textSortFields = map[string]bool{
+	"title":    true,
+	"username": true,
+}
+
+// cursorToken is the decoded form of the opaque cursor handed to clients.
+type cursorToken struct {
+	Sort     string `json:"s"`
+	Value    string `json:"v"`
+	ID       string `json:"id"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+type pageQuery struct {
+	req   repository.PageRequest
+	sort  string
+	page  int
+	limit int
+}
+
+func newPageQuery(q dto.ListQuery, sortFields map[string]bool) (*pageQuery, error) {
+	sort := q.Sort
+	if sort == "" {
+		sort = defaultSort
+	}
+	field := strings.TrimPrefix(sort, "-")
+	if !sortFields[field] {
+		return nil, custom.NewBadRequestError("invalid sort field")
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	pq := &pageQuery{
+		sort:  sort,
+		limit: limit,
+		// fetch one extra row to know whether another page exists
+		req: repository.PageRequest{Limit: limit + 1, SortBy: field, Desc: strings.HasPrefix(sort, "-")},
+	}
+
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil || c.Sort != sort {
+			return nil, custom.NewBadRequestError("invalid cursor")
+		}
+		pq.req.Cursor = &repository.Cursor{Value: c.Value, ID: c.ID, Backward: c.Backward}
+		return pq, nil
+	}
+
+	pq.page = q.Page
+	if pq.page <= 0 {
+		pq.page = 1
+	}
+	pq.req.Offset = (pq.page - 1) * limit
+	return pq, nil
+}
+
+// paginateResult trims the look-ahead row and builds the paginate block.
+// key returns the sort value and id of an item for cursor encoding.
+func paginateResult[T any](pq *pageQuery, items []T, total int64, key func(T) (string, string)) ([]T, *dto.PaginateResponse) {
+	backward := pq.req.Cursor != nil && pq.req.Cursor.Backward
+	hasMore := len(items) > pq.limit
+	if hasMore {
+		if backward {
+			items = items[len(items)-pq.limit:]
+		} else {
+			items = items[:pq.limit]
+		}
+	}
+
+	res := &dto.PaginateResponse{Limit: pq.limit, Total: total}
+	if pq.req.Cursor == nil {
+		res.Page = pq.page
+		res.TotalPages = int((total + int64(pq.limit) - 1) / int64(pq.limit))
+	}
+	if len(items) == 0 {
+		return items, res
+	}
+
+	hasNext := (!backward && hasMore) || backward
+	hasPrev := (backward && hasMore) || (!backward && pq.req.Cursor != nil) || pq.page > 1
+	if hasNext {
+		v, id := key(items[len(items)-1])
+		res.NextCursor = encodeCursor(cursorToken{Sort: pq.sort, Value: v, ID: id})
+	}
+	if hasPrev {
+		v, id := key(items[0])
+		res.PrevCursor = encodeCursor(cursorToken{Sort: pq.sort, Value: v, ID: id, Backward: true})
+	}
+	return items, res
+}
+
+// sortValue formats the value of a sortable column for a cursor. text is the
+// value of the text sort key of the listing (title or username).
+func sortValue(field string, text string, createdAt, updatedAt time.Time) string {
+	switch field {
+	case "title", "username":
+		return text
+	case "updated_at":
+		return updatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		return createdAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+func encodeCursor(c cursorToken) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*cursorToken, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c cursorToken
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(c.ID); err != nil {
+		return nil, err
+	}
+	if !textSortFields[strings.TrimPrefix(c.Sort, "-")] {
+		if _, err := time.Parse(time.RFC3339Nano, c.Value); err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
@@ -1,20 +1,22 @@
 package usecases
 
 import (
+	"context"
 	"dungeons-dragon-service/internal/domain/model"
 	"dungeons-dragon-service/internal/domain/repository"
 	"dungeons-dragon-service/internal/dto"
 	"dungeons-dragon-service/internal/helper"
 	"dungeons-dragon-service/internal/http/custom"
-	"encoding/json"
 )
 
 type QuestUseCase interface {
-	ListPublic() ([]dto.QuestResponse, error)
-	ListForUser(authenticated bool) ([]dto.QuestResponse, error)
-	Create(userID string, in *dto.CreateQuestInput) error
-	Update(userID string, id string, in *dto.UpdateQuestInput) error
-	Delete(userID string, id string) error
+	ListPublic(ctx context.Context) ([]dto.QuestResponse, error)
+	ListForUser(ctx context.Context, viewer Viewer, q *dto.QuestListQuery) ([]dto.QuestResponse, *dto.PaginateResponse, error)
+	ListByOwner(ctx context.Context, userID string) ([]dto.QuestResponse, error)
+	Get(ctx context.Context, viewer Viewer, id string) (*dto.QuestResponse, error)
+	Create(ctx context.Context, userID string, in *dto.CreateQuestInput) error
+	Update(ctx context.Context, userID string, id string, in *dto.UpdateQuestInput) error
+	Delete(ctx context.Context, userID string, id string) error
 }
 
 type questUseCase struct {
@@ -29,58 +31,81 @@ func NewQuestUsecase(q repository.QuestRepository, ql repository.QuestLevelRepos
 func ResponseQuests(q []model.Quest) []dto.QuestResponse {
 	res := make([]dto.QuestResponse, len(q))
 	for i, quest := range q {
-		//unmarshal images
-		images := []string{}
-		urls := []string{}
-		if err := json.Unmarshal(quest.ImagePath, &images); err == nil {
-			for _, img := range images {
-				url := helper.GetImageURL(img)
-				urls = append(urls, url)
-			}
-		}
 		res[i] = dto.QuestResponse{
-			ID:          quest.ID.String(),
-			Title:       quest.Title,
-			Description: quest.Description,
-			Privacy:     quest.Privacy,
-			Status:      string(quest.Status),
-			Images:      urls,
+			ID:           quest.ID.String(),
+			Title:        quest.Title,
+			Description:  quest.Description,
+			UserID:       quest.UserID.String(),
+			QuestLevelID: quest.QuestLevelID.String(),
+			Privacy:      quest.Privacy,
+			Status:       string(quest.Status),
+			Images:       ResponseQuestImages(quest.Images, isPrivateItem(quest.Privacy, quest.Status)),
 		}
 	}
 	return res
 }
 
-func (u *questUseCase) ListPublic() ([]dto.QuestResponse, error) {
-	list, err := u.quests.ListPublic()
+func (u *questUseCase) ListPublic(ctx context.Context) ([]dto.QuestResponse, error) {
+	list, err := u.quests.ListPublic(ctx)
 	if err != nil {
 		return nil, custom.NewUnexpectedError("failed to list quests")
 	}
 	return ResponseQuests(list), nil
 }
 
-func (u *questUseCase) ListForUser(authenticated bool) ([]dto.QuestResponse, error) {
-	if authenticated {
-		list, err := u.quests.ListAll()
-		if err != nil {
-			return nil, custom.NewUnexpectedError("failed to list quests")
-		}
-		return ResponseQuests(list), nil
+func (u *questUseCase) ListForUser(ctx context.Context, viewer Viewer, q *dto.QuestListQuery) ([]dto.QuestResponse, *dto.PaginateResponse, error) {
+	pq, err := newPageQuery(q.ListQuery, listSortFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter := repository.QuestFilter{
+		QuestLevelID: q.QuestLevelID,
+		UserID:       q.Owner,
+		Privacy:      q.Privacy,
+		Status:       q.Status,
+		Visibility:   viewer.visibility(),
+	}
+	list, total, err := u.quests.Search(ctx, filter, pq.req)
+	if err != nil {
+		return nil, nil, custom.NewUnexpectedError("failed to list quests")
 	}
-	list, err := u.quests.ListPublic()
+	list, paginate := paginateResult(pq, list, total, func(q model.Quest) (string, string) {
+		return sortValue(pq.req.SortBy, q.Title, q.CreatedAt, q.UpdatedAt), q.ID.String()
+	})
+	return ResponseQuests(list), paginate, nil
+}
+
+// ListByOwner returns all active quests of a user, private ones included.
+func (u *questUseCase) ListByOwner(ctx context.Context, userID string) ([]dto.QuestResponse, error) {
+	list, err := u.quests.ListByUser(ctx, userID)
 	if err != nil {
 		return nil, custom.NewUnexpectedError("failed to list quests")
 	}
 	return ResponseQuests(list), nil
 }
 
-func (u *questUseCase) Create(userID string, in *dto.CreateQuestInput) error {
+// Get returns a single quest. Quests the viewer may not see are reported as
+// not found so their existence is not leaked.
+func (u *questUseCase) Get(ctx context.Context, viewer Viewer, id string) (*dto.QuestResponse, error) {
+	m, err := u.quests.FindByID(ctx, id)
+	if err != nil || m == nil || !viewer.canView(m.UserID, m.Privacy, m.Status) {
+		return nil, custom.NewNotFoundError("quest not found")
+	}
+	response := ResponseQuests([]model.Quest{*m})[0]
+	if level, err := u.questLevels.FindByID(ctx, m.QuestLevelID.String()); err == nil {
+		response.QuestLevel = level.Name
+	}
+	return &response, nil
+}
+
+func (u *questUseCase) Create(ctx context.Context, userID string, in *dto.CreateQuestInput) error {
 	if err := helper.ValidateDescription(in.Description); err != nil {
 		return custom.NewBadRequestError("invalid description")
 	}
 	// if err := helper.ValidateImages(len(in.Images)); err != nil {
 	// 	return custom.NewBadRequestError("invalid images")
 	// }
-	if _, err := u.quests.FindByID(in.QuestLevelID); err != nil {
+	if q, err := u.quests.FindByID(ctx, in.QuestLevelID); err != nil || q == nil {
 		return custom.NewNotFoundError("quest level not found")
 	}
 	// imgJSON, _ := json.Marshal(in.Images)
@@ -93,15 +118,15 @@ func (u *questUseCase) Create(userID string, in *dto.CreateQuestInput) error {
 		Privacy: in.Privacy,
 		Status:  model.ItemStatusActive,
 	}
-	if _, err := u.quests.Create(m); err != nil {
+	if _, err := u.quests.Create(ctx, m); err != nil {
 		return custom.NewUnexpectedError("failed to create quest")
 	}
 	return nil
 }
 
-func (u *questUseCase) Update(userID string, id string, in *dto.UpdateQuestInput) error {
-	m, err := u.quests.FindByID(id)
-	if err != nil {
+func (u *questUseCase) Update(ctx context.Context, userID string, id string, in *dto.UpdateQuestInput) error {
+	m, err := u.quests.FindByID(ctx, id)
+	if err != nil || m == nil {
 		return custom.NewNotFoundError("quest not found")
 	}
 	if m.UserID != helper.ParseUUIDOrNil(userID) {
@@ -121,7 +146,7 @@ func (u *questUseCase) Update(userID string, id string, in *dto.UpdateQuestInput
 		m.Description = *in.Description
 	}
 	if in.QuestLevelID != nil {
-		if _, err := u.questLevels.FindByID(*in.QuestLevelID); err != nil {
+		if _, err := u.questLevels.FindByID(ctx, *in.QuestLevelID); err != nil {
 			return custom.NewNotFoundError("quest level not found")
 		}
 		m.QuestLevelID = helper.ParseUUIDOrNil(*in.QuestLevelID)
@@ -129,19 +154,19 @@ func (u *questUseCase) Update(userID string, id string, in *dto.UpdateQuestInput
 	if in.Privacy != nil {
 		m.Privacy = *in.Privacy
 	}
-	if _, err := u.quests.Update(m); err != nil {
+	if _, err := u.quests.Update(ctx, m); err != nil {
 		return custom.NewUnexpectedError("failed to update quest")
 	}
 	return nil
 }
 
-func (u *questUseCase) Delete(userID string, id string) error {
-	m, err := u.quests.FindByID(id)
-	if err != nil {
+func (u *questUseCase) Delete(ctx context.Context, userID string, id string) error {
+	m, err := u.quests.FindByID(ctx, id)
+	if err != nil || m == nil {
 		return custom.NewNotFoundError("quest not found")
 	}
 	if m.UserID != helper.ParseUUIDOrNil(userID) {
 		return custom.NewForbiddenError("forbidden")
 	}
-	return u.quests.Delete(id)
+	return u.quests.Delete(ctx, id)
 }
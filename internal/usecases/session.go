@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/repository"
+	"dungeons-dragon-service/internal/http/custom"
+)
+
+type sessionResolver struct {
+	users   repository.UserRepository
+	revoked repository.RevokedTokenRepository
+}
+
+// ResolveSession checks that the token was not revoked and that its user
+// still exists and is not suspended.
+func (r *sessionResolver) ResolveSession(ctx context.Context, userID, jti string) (*model.Session, error) {
+	revoked, err := r.revoked.Exists(ctx, jti)
+	if err != nil {
+		return nil, custom.NewUnexpectedError("failed to verify token")
+	}
+	if revoked {
+		return nil, custom.NewUnauthorizedError("token revoked")
+	}
+	user, err := r.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, custom.NewUnexpectedError("failed to verify token")
+	}
+	if user == nil {
+		return nil, custom.NewUnauthorizedError("invalid token")
+	}
+	if user.SuspendedAt != nil {
+		return nil, custom.NewForbiddenError("account suspended")
+	}
+	return &model.Session{
+		UserID:                userID,
+		Role:                  user.Role,
+		PasswordResetRequired: user.PasswordResetRequired,
+		EmailVerified:         user.EmailVerifiedAt != nil,
+	}, nil
+}
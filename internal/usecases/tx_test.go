@@ -0,0 +1,102 @@
+package usecases
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/repository"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memTx is the in-memory TxManager of the usecase tests. It hands fn the mock
+// repositories and undoes their changes when fn fails.
+type memTx struct {
+	repos repository.Repositories
+}
+
+// snapshotter is implemented by mocks whose changes memTx can roll back.
+type snapshotter interface {
+	snapshot() (restore func())
+}
+
+func (m *memTx) WithinTx(_ context.Context, fn func(repos repository.Repositories) error) error {
+	var restores []func()
+	for _, r := range []any{m.repos.Users, m.repos.Classes, m.repos.Races, m.repos.QuestLevels, m.repos.Characters,
+		m.repos.Quests, m.repos.Images, m.repos.RefreshTokens, m.repos.RevokedTokens, m.repos.UserTokens} {
+		if s, ok := r.(snapshotter); ok {
+			restores = append(restores, s.snapshot())
+		}
+	}
+	if err := fn(m.repos); err != nil {
+		for _, restore := range restores {
+			restore()
+		}
+		return err
+	}
+	return nil
+}
+
+// snapshotRows copies the rows of m so later changes to them can be undone.
+func snapshotRows[T any](m map[string]*T) func() {
+	saved := make(map[string]T, len(m))
+	for k, v := range m {
+		saved[k] = *v
+	}
+	return func() {
+		clear(m)
+		for k, v := range saved {
+			m[k] = &v
+		}
+	}
+}
+
+func (m *mockUserRepo) snapshot() func()         { return snapshotRows(m.m) }
+func (m *mockClassRepo) snapshot() func()        { return snapshotRows(m.m) }
+func (m *mockRaceRepo) snapshot() func()         { return snapshotRows(m.m) }
+func (m *mockQuestLevelRepo) snapshot() func()   { return snapshotRows(m.levels) }
+func (m *mockCharRepo) snapshot() func()         { return snapshotRows(m.m) }
+func (m *mockRefreshTokenRepo) snapshot() func() { return snapshotRows(m.m) }
+
+func (m *mockQuestRepo) snapshot() func() {
+	restore, archived := snapshotRows(m.quests), len(m.archived)
+	return func() {
+		restore()
+		m.archived = m.archived[:archived]
+	}
+}
+
+func (m *mockRevokedTokenRepo) snapshot() func() {
+	saved := maps.Clone(m.m)
+	return func() {
+		clear(m.m)
+		maps.Copy(m.m, saved)
+	}
+}
+
+func TestMemTxRollsBack(t *testing.T) {
+	users := newMockUserRepo()
+	revoked := &mockRevokedTokenRepo{m: map[string]time.Time{}}
+	tx := &memTx{repos: repository.Repositories{Users: users, RevokedTokens: revoked}}
+	user, _ := users.Create(t.Context(), &model.User{Username: "hero"})
+
+	err := tx.WithinTx(t.Context(), func(repos repository.Repositories) error {
+		user.Username = "renamed"
+		repos.Users.Update(t.Context(), user)
+		repos.RevokedTokens.Add(t.Context(), "jti-1", time.Now().Add(time.Minute))
+		return context.Canceled
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	got, _ := users.FindByID(t.Context(), user.ID.String())
+	require.Equal(t, "hero", got.Username)
+	require.Empty(t, revoked.m)
+
+	require.NoError(t, tx.WithinTx(t.Context(), func(repos repository.Repositories) error {
+		_, err := repos.Users.Update(t.Context(), &model.User{Base: model.Base{ID: user.ID}, Username: "renamed"})
+		return err
+	}))
+	got, _ = users.FindByID(t.Context(), user.ID.String())
+	require.Equal(t, "renamed", got.Username)
+}
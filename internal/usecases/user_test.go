@@ -0,0 +1,104 @@
+package usecases
+
+import (
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/dto"
+	"dungeons-dragon-service/internal/helper"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// userFixture is an auth harness with one registered user, "hero", whose
+// password is "secret1".
+type userFixture struct {
+	*authHarness
+	user *model.User
+}
+
+func newUserFixture(t *testing.T) *userFixture {
+	t.Helper()
+	f := &userFixture{authHarness: newAuthHarness()}
+
+	salt, err := helper.GenerateSalt(16)
+	require.NoError(t, err)
+	f.user, _ = f.users.Create(t.Context(), &model.User{
+		Username:     "hero",
+		Email:        "hero@example.com",
+		PasswordHash: helper.HashPasswordArgon2("secret1", salt),
+		Role:         model.RoleUser,
+	})
+	return f
+}
+
+func TestUpdateProfileChecksUniqueness(t *testing.T) {
+	f := newUserFixture(t)
+	f.users.Create(t.Context(), &model.User{Username: "mage", Email: "mage@example.com"})
+	id := f.user.ID.String()
+
+	_, err := f.profile.UpdateProfile(t.Context(), id, &dto.UpdateProfileRequest{Username: strPtr("mage")})
+	require.EqualError(t, err, "username already exists")
+	_, err = f.profile.UpdateProfile(t.Context(), id, &dto.UpdateProfileRequest{Email: strPtr("MAGE@example.com")})
+	require.EqualError(t, err, "email already exists")
+	_, err = f.profile.UpdateProfile(t.Context(), id, &dto.UpdateProfileRequest{Username: strPtr("  ")})
+	require.Error(t, err)
+
+	// keeping the current values is not a conflict
+	profile, err := f.profile.UpdateProfile(t.Context(), id, &dto.UpdateProfileRequest{Username: strPtr("hero"), Email: strPtr(" New@Example.com ")})
+	require.NoError(t, err)
+	require.Equal(t, "hero", profile.Username)
+	require.Equal(t, "new@example.com", profile.Email)
+
+	_, err = f.profile.GetProfile(t.Context(), uuid.NewString())
+	require.EqualError(t, err, "user not found")
+}
+
+func TestChangePasswordRevokesSessions(t *testing.T) {
+	f := newUserFixture(t)
+	id := f.user.ID.String()
+	f.tokens.Create(t.Context(), &model.RefreshToken{UserID: f.user.ID, TokenHash: "a", ExpiresAt: time.Now().Add(time.Hour)})
+
+	err := f.profile.ChangePassword(t.Context(), id, "jti-1", time.Now().Add(time.Minute), "wrong", "secret2")
+	require.EqualError(t, err, "invalid password")
+	require.Nil(t, f.tokens.m["a"].RevokedAt)
+
+	require.NoError(t, f.profile.ChangePassword(t.Context(), id, "jti-1", time.Now().Add(time.Minute), "secret1", "secret2"))
+	require.True(t, helper.VerifyPasswordArgon2("secret2", f.user.PasswordHash))
+	require.NotNil(t, f.tokens.m["a"].RevokedAt)
+	revoked, _ := f.revoked.Exists(t.Context(), "jti-1")
+	require.True(t, revoked)
+}
+
+func TestDeleteAccountArchivesContent(t *testing.T) {
+	f := newUserFixture(t)
+	id := f.user.ID.String()
+	char := &model.Character{Base: model.Base{ID: uuid.New()}, UserID: f.user.ID, Status: model.ItemStatusActive}
+	quest := &model.Quest{Base: model.Base{ID: uuid.New()}, UserID: f.user.ID, Status: model.ItemStatusActive}
+	f.chars.m[char.ID.String()] = char
+	f.quests.quests[quest.ID.String()] = quest
+
+	require.EqualError(t, f.profile.DeleteAccount(t.Context(), id, "", time.Time{}, "wrong"), "invalid password")
+	require.Equal(t, model.ItemStatusActive, char.Status)
+
+	require.NoError(t, f.profile.DeleteAccount(t.Context(), id, "jti-1", time.Now().Add(time.Minute), "secret1"))
+	require.Equal(t, model.ItemStatusArchived, char.Status)
+	require.Equal(t, model.ItemStatusArchived, quest.Status)
+	u, _ := f.users.FindByID(t.Context(), id)
+	require.Nil(t, u)
+}
+
+func TestDeletedAccountCanRegisterAgain(t *testing.T) {
+	f := newUserFixture(t)
+	require.NoError(t, f.profile.DeleteAccount(t.Context(), f.user.ID.String(), "", time.Time{}, "secret1"))
+
+	_, err := f.auth.Register(t.Context(), "hero", "hero@example.com", "secret2")
+	require.NoError(t, err)
+
+	u, _ := f.users.FindByEmail(t.Context(), "hero@example.com")
+	require.NotNil(t, u)
+	require.NotEqual(t, f.user.ID, u.ID)
+	// the deleted row is still there
+	require.True(t, f.users.m[f.user.ID.String()].DeletedAt.Valid)
+}
@@ -0,0 +1,160 @@
+package usecases
+
+import (
+	"context"
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/repository"
+	"dungeons-dragon-service/internal/dto"
+	"dungeons-dragon-service/internal/helper"
+	"dungeons-dragon-service/internal/http/custom"
+	"strings"
+	"time"
+)
+
+type UserUseCase interface {
+	GetProfile(ctx context.Context, userID string) (*dto.UserProfileResponse, error)
+	UpdateProfile(ctx context.Context, userID string, in *dto.UpdateProfileRequest) (*dto.UserProfileResponse, error)
+	ChangePassword(ctx context.Context, userID, jti string, expiresAt time.Time, oldPassword, newPassword string) error
+	DeleteAccount(ctx context.Context, userID, jti string, expiresAt time.Time, password string) error
+}
+
+type userUseCase struct {
+	users   repository.UserRepository
+	tokens  repository.RefreshTokenRepository
+	revoked repository.RevokedTokenRepository
+	tx      repository.TxManager
+}
+
+func NewUserUseCase(users repository.UserRepository, tokens repository.RefreshTokenRepository, revoked repository.RevokedTokenRepository, tx repository.TxManager) UserUseCase {
+	return &userUseCase{users: users, tokens: tokens, revoked: revoked, tx: tx}
+}
+
+func ResponseUserProfile(user *model.User) *dto.UserProfileResponse {
+	return &dto.UserProfileResponse{
+		ID:            user.ID.String(),
+		Username:      user.Username,
+		Email:         user.Email,
+		Role:          string(user.Role),
+		EmailVerified: user.EmailVerifiedAt != nil,
+	}
+}
+
+func (u *userUseCase) GetProfile(ctx context.Context, userID string) (*dto.UserProfileResponse, error) {
+	user, err := u.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return ResponseUserProfile(user), nil
+}
+
+func (u *userUseCase) UpdateProfile(ctx context.Context, userID string, in *dto.UpdateProfileRequest) (*dto.UserProfileResponse, error) {
+	user, err := u.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.Username != nil {
+		username := strings.TrimSpace(*in.Username)
+		if username == "" {
+			return nil, custom.NewBadRequestError("username cannot be empty")
+		}
+		if username != user.Username {
+			existing, err := u.users.FindByUsername(ctx, username)
+			if err != nil {
+				return nil, custom.NewUnexpectedError("failed to check if username exists")
+			}
+			if existing != nil {
+				return nil, custom.NewConflictError("username already exists")
+			}
+			user.Username = username
+		}
+	}
+	if in.Email != nil {
+		email := strings.TrimSpace(strings.ToLower(*in.Email))
+		if !validEmail(email) {
+			return nil, custom.NewBadRequestError("invalid email")
+		}
+		if email != user.Email {
+			existing, err := u.users.FindByEmail(ctx, email)
+			if err != nil {
+				return nil, custom.NewUnexpectedError("failed to check if email exists")
+			}
+			if existing != nil {
+				return nil, custom.NewConflictError("email already exists")
+			}
+			user.Email = email
+			// the new address has to be verified again
+			user.EmailVerifiedAt = nil
+		}
+	}
+
+	if _, err := u.users.Update(ctx, user); err != nil {
+		return nil, custom.NewUnexpectedError("failed to update profile")
+	}
+	return ResponseUserProfile(user), nil
+}
+
+// ChangePassword stores the new password and signs the user out everywhere,
+// including the session that made the request.
+func (u *userUseCase) ChangePassword(ctx context.Context, userID, jti string, expiresAt time.Time, oldPassword, newPassword string) error {
+	user, err := u.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !helper.VerifyPasswordArgon2(oldPassword, user.PasswordHash) {
+		return custom.NewForbiddenError("invalid password")
+	}
+	if len(newPassword) < 6 {
+		return custom.NewBadRequestError("password must be at least 6 characters")
+	}
+
+	salt, err := helper.GenerateSalt(16)
+	if err != nil {
+		return custom.NewUnexpectedError("failed to generate salt")
+	}
+	user.PasswordHash = helper.HashPasswordArgon2(newPassword, salt)
+	user.PasswordResetRequired = false
+	if _, err := u.users.Update(ctx, user); err != nil {
+		return custom.NewUnexpectedError("failed to update password")
+	}
+	return revokeSessions(ctx, u.tokens, u.revoked, userID, jti, expiresAt)
+}
+
+// DeleteAccount archives the user's characters and quests, signs the user out
+// and soft deletes the account, all in one transaction.
+func (u *userUseCase) DeleteAccount(ctx context.Context, userID, jti string, expiresAt time.Time, password string) error {
+	user, err := u.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !helper.VerifyPasswordArgon2(password, user.PasswordHash) {
+		return custom.NewForbiddenError("invalid password")
+	}
+
+	return u.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		if err := repos.Characters.ArchiveByUserID(ctx, userID); err != nil {
+			return custom.NewUnexpectedError("failed to archive characters")
+		}
+		if err := repos.Quests.ArchiveByUserID(ctx, userID); err != nil {
+			return custom.NewUnexpectedError("failed to archive quests")
+		}
+		if err := revokeSessions(ctx, repos.RefreshTokens, repos.RevokedTokens, userID, jti, expiresAt); err != nil {
+			return err
+		}
+		if err := repos.Users.Delete(ctx, userID); err != nil {
+			return custom.NewUnexpectedError("failed to delete user")
+		}
+		return nil
+	})
+}
+
+func (u *userUseCase) findUser(ctx context.Context, userID string) (*model.User, error) {
+	user, err := u.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, custom.NewUnexpectedError("failed to get user")
+	}
+	if user == nil {
+		return nil, custom.NewNotFoundError("user not found")
+	}
+	return user, nil
+}
@@ -0,0 +1,48 @@
+package usecases
+
+import (
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/repository"
+	"dungeons-dragon-service/internal/helper"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Viewer is the caller a resource is read on behalf of.
+// The zero value is an anonymous visitor.
+type Viewer struct {
+	UserID string
+	Role   model.Role
+}
+
+// the privacy and status values that make an item visible to everyone
+var (
+	publicPrivacy = []model.Privacy{model.PrivacyPublic}
+	publicStatus  = []model.ItemStatus{model.ItemStatusActive}
+)
+
+func (v Viewer) IsAdmin() bool {
+	return v.Role == model.RoleAdmin
+}
+
+func (v Viewer) owns(owner uuid.UUID) bool {
+	return v.UserID != "" && helper.ParseUUIDOrNil(v.UserID) == owner
+}
+
+// visibility is the listing counterpart of canView.
+func (v Viewer) visibility() repository.Visibility {
+	if v.IsAdmin() {
+		return repository.Visibility{All: true}
+	}
+	return repository.Visibility{Privacy: publicPrivacy, Status: publicStatus, OwnerID: v.UserID}
+}
+
+// canView reports whether the viewer may see an item. Public active items are
+// visible to everyone, anything else only to its owner or an admin.
+func (v Viewer) canView(owner uuid.UUID, privacy model.Privacy, status model.ItemStatus) bool {
+	if v.IsAdmin() || v.owns(owner) {
+		return true
+	}
+	return slices.Contains(publicPrivacy, privacy) && slices.Contains(publicStatus, status)
+}
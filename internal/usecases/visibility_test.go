@@ -0,0 +1,84 @@
+package usecases
+
+import (
+	"dungeons-dragon-service/internal/domain/model"
+	"dungeons-dragon-service/internal/domain/repository"
+	"dungeons-dragon-service/internal/dto"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// visibleTo mirrors the SQL visibility filter of the GORM repositories.
+func visibleTo(v repository.Visibility, owner uuid.UUID, privacy model.Privacy, status model.ItemStatus) bool {
+	if v.All {
+		return true
+	}
+	if v.OwnerID != "" && owner.String() == v.OwnerID {
+		return true
+	}
+	return slices.Contains(v.Privacy, privacy) && slices.Contains(v.Status, status)
+}
+
+func TestVisibilityPolicy(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	charRepo := newMockCharRepo()
+	questRepo := &mockQuestRepo{quests: map[string]*model.Quest{}}
+	for _, owner := range []uuid.UUID{alice, bob} {
+		for _, p := range []model.Privacy{model.PrivacyPublic, model.PrivacyPrivate} {
+			c := &model.Character{Title: "Hero", UserID: owner, Privacy: p, Status: model.ItemStatusActive}
+			c.ID = uuid.New()
+			charRepo.m[c.ID.String()] = c
+			q := &model.Quest{Title: "Quest", UserID: owner, Privacy: p, Status: model.ItemStatusActive}
+			q.ID = uuid.New()
+			questRepo.quests[q.ID.String()] = q
+		}
+	}
+	chars := NewCharacterUsecase(charRepo, nil, nil)
+	quests := NewQuestUsecase(questRepo, nil)
+
+	cases := []struct {
+		name   string
+		viewer Viewer
+		want   int
+	}{
+		{"anonymous sees public only", Viewer{}, 2},
+		{"user sees public and own private", Viewer{UserID: alice.String(), Role: model.RoleUser}, 3},
+		{"admin sees everything", Viewer{UserID: uuid.NewString(), Role: model.RoleAdmin}, 4},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			list, page, err := chars.ListForUser(t.Context(), tc.viewer, &dto.CharacterListQuery{})
+			require.NoError(t, err)
+			require.Len(t, list, tc.want)
+			require.Equal(t, int64(tc.want), page.Total)
+			for _, c := range list {
+				if c.Privacy == model.PrivacyPrivate && !tc.viewer.IsAdmin() {
+					require.Equal(t, tc.viewer.UserID, c.UserID)
+				}
+			}
+
+			qs, _, err := quests.ListForUser(t.Context(), tc.viewer, &dto.QuestListQuery{})
+			require.NoError(t, err)
+			require.Len(t, qs, tc.want)
+		})
+	}
+
+	// the owner view includes private items and nothing of other users
+	mine, err := chars.ListByOwner(t.Context(), bob.String())
+	require.NoError(t, err)
+	require.Len(t, mine, 2)
+	for _, c := range mine {
+		require.Equal(t, bob.String(), c.UserID)
+	}
+}
+
+func TestCanViewArchived(t *testing.T) {
+	owner := uuid.New()
+	require.False(t, Viewer{}.canView(owner, model.PrivacyPublic, model.ItemStatusArchived))
+	require.True(t, Viewer{UserID: owner.String()}.canView(owner, model.PrivacyPublic, model.ItemStatusArchived))
+	require.True(t, Viewer{Role: model.RoleAdmin}.canView(owner, model.PrivacyPrivate, model.ItemStatusArchived))
+	require.False(t, Viewer{UserID: uuid.NewString()}.canView(owner, model.PrivacyPrivate, model.ItemStatusActive))
+}